@@ -15,8 +15,16 @@ import (
 	"dishes-service-backend/service"
 	"dishes-service-backend/service/events"
 	"dishes-service-backend/service/payment"
+	"dishes-service-backend/service/payment/cash"
+	"dishes-service-backend/service/payment/corporate"
 	"dishes-service-backend/service/payment/expiration"
+	"dishes-service-backend/service/payment/gateway"
+	"dishes-service-backend/service/payment/invoice"
+	"dishes-service-backend/service/payment/reconciliation"
+	"dishes-service-backend/service/payment/refund"
 	telegram_payment "dishes-service-backend/service/payment/telegram"
+	"dishes-service-backend/service/payment/telegram_stars"
+	wallet_payment "dishes-service-backend/service/payment/wallet"
 	"dishes-service-backend/transaction"
 
 	"github.com/Falokut/go-kit/db"
@@ -84,13 +92,31 @@ func (l Locator) LocatorConfig(ctx context.Context, cfg conf.Remote) (*Config, e
 	dishService := service.NewDish(dishRepo, txRunner, fileRepo, l.logger)
 	dishCtrl := controller.NewDish(dishService)
 
+	dishOptionRepo := repository.NewDishOption(l.db)
+	dishOptionService := service.NewDishOption(dishOptionRepo, txRunner)
+	dishOptionCtrl := controller.NewDishOption(dishOptionService)
+
 	dishesCategoriesRepo := repository.NewDishCategory(l.db)
 	dishesCategoriesService := service.NewDishCategory(dishesCategoriesRepo)
 	dishesCategoriesCtrl := controller.NewDishCategory(dishesCategoriesService)
 
 	authMiddleware := routes.NewAuthMiddleware(cfg.Auth.Access.Secret)
 	orderRepo := repository.NewOrder(l.db)
-	paymentBot := bot.NewPaymentBot(cfg.Bot.PaymentToken, l.tgBot.Api(), orderRepo)
+	jobRepo := repository.NewJob(l.db)
+	paymentSessionRepo := repository.NewPaymentSession(l.db)
+	gatewayCli := client.New()
+	gatewayCli.GlobalRequestConfig().BaseUrl = cfg.Payment.Gateway.BaseUrl
+	gatewayProvider := gateway.NewHttpProvider(gatewayCli, cfg.Payment.Gateway.ApiKey)
+	gatewayPayment := gateway.NewPayment(gatewayProvider, paymentSessionRepo, cfg.Payment.Gateway.ReturnUrl)
+	refunders := map[string]refund.Refunder{}
+	if cfg.Payment.Gateway.BaseUrl != "" {
+		refunders[gateway.PaymentMethod] = gatewayPayment
+	}
+	refundScheduler := refund.NewScheduler(refunders)
+	orderStatusService := service.NewOrderStatus(txRunner)
+	invoiceScheduler := invoice.NewScheduler()
+	orderPaymentService := service.NewOrderPayment(txRunner, orderStatusService, refundScheduler, invoiceScheduler)
+	paymentBot := bot.NewPaymentBot(cfg.Bot.PaymentToken, l.tgBot.Api(), orderRepo, orderPaymentService, l.logger)
 	telegramWorkerService := telegram_payment.NewWorker(paymentBot)
 	telegramController := telegram_payment.NewWorkerController(telegramWorkerService)
 
@@ -103,32 +129,130 @@ func (l Locator) LocatorConfig(ctx context.Context, cfg conf.Remote) (*Config, e
 		bgjob.WithObserver(observer),
 	)
 
+	starsRateRepo := repository.NewStarsRate(l.db)
+	starsRateService := service.NewStarsRate(starsRateRepo)
+	starsRateCtrl := controller.NewStarsRate(starsRateService)
+	starsPayment := telegram_stars.NewPayment(userRepo, jobRepo, starsRateRepo, paymentBot, l.bgJobCli)
+	refunders[telegram_stars.PaymentMethod] = starsPayment
+	starsWorkerService := telegram_stars.NewWorker(paymentBot, starsRateRepo)
+	starsController := telegram_stars.NewWorkerController(starsWorkerService)
+	starsWorker := bgjob.NewWorker(
+		l.bgJobCli,
+		telegram_stars.WorkerQueue,
+		starsController,
+		bgjob.WithPollInterval(5*time.Second), // nolint:mnd
+		bgjob.WithObserver(observer),
+	)
+
 	paymentExpirationDelay := time.Minute * time.Duration(cfg.Payment.ExpirationDelayMinutes)
-	expirationService := expiration.NewExpiration(l.bgJobCli, paymentExpirationDelay)
-	expirationWorkerService := expiration.NewWorker(orderRepo)
+	paymentReminderBefore := time.Minute * time.Duration(cfg.Payment.ReminderMinutes)
+	expirationService := expiration.NewExpiration(l.bgJobCli, jobRepo, paymentExpirationDelay, paymentReminderBefore)
+	ratingRepo := repository.NewRating(l.db)
+	ratingService := service.NewRating(ratingRepo, orderRepo, txRunner)
+	ratingCtrl := controller.NewRating(ratingService)
+
+	orderUserService := bot_service.NewOrderUserService(l.tgBot, userRepo, orderRepo, orderPaymentService, ratingService)
+	expirationWorkerService := expiration.NewWorker(txRunner, orderPaymentService, orderRepo, orderUserService)
 	expirationController := expiration.NewWorkerController(expirationWorkerService)
 
-	paymentMethods := payment.NewPaymentMethods(userRepo, l.bgJobCli)
+	companyRepo := repository.NewCompany(l.db)
+	corporateWorkerService := corporate.NewWorker(txRunner, orderPaymentService, orderUserService)
+	corporateController := corporate.NewWorkerController(corporateWorkerService)
+	cashWorkerService := cash.NewWorker(orderRepo, orderUserService)
+	cashController := cash.NewWorkerController(cashWorkerService)
+
+	walletRepo := repository.NewWallet(l.db)
+	walletService := service.NewWallet(walletRepo, userRepo, txRunner, paymentBot)
+	walletCtrl := controller.NewWallet(walletService)
+	walletWorkerService := wallet_payment.NewWorker(txRunner, orderPaymentService, walletService, orderUserService)
+	walletController := wallet_payment.NewWorkerController(walletWorkerService)
+	refundWorkerService := refund.NewWorker(orderRepo, refunders, orderUserService, l.logger)
+	refundController := refund.NewWorkerController(refundWorkerService)
+
+	paymentMethods := payment.NewPaymentMethods(userRepo, companyRepo, walletRepo, jobRepo, l.bgJobCli)
+	paymentMethods[telegram_stars.PaymentMethod] = starsPayment
+	if cfg.Payment.Gateway.BaseUrl != "" {
+		paymentMethods[gateway.PaymentMethod] = gatewayPayment
+	}
+	paymentLedgerRepo := repository.NewPayment(l.db)
+	paymentLedgerService := service.NewPaymentLedger(paymentLedgerRepo)
+	paymentLedgerCtrl := controller.NewPaymentLedger(paymentLedgerService)
+	gatewayWebhook := gateway.NewWebhook(
+		cfg.Payment.Gateway.WebhookSecret,
+		paymentSessionRepo,
+		orderRepo,
+		orderPaymentService,
+		paymentLedgerService,
+		orderUserService,
+		l.logger,
+	)
+	paymentGatewayCtrl := controller.NewPaymentGateway(gatewayWebhook)
+	reconciliationScheduler := reconciliation.NewScheduler(l.bgJobCli, cfg.Payment.ReconciliationHourUtc)
+	reconciliationWorkerService := reconciliation.NewWorker(paymentLedgerRepo, orderUserService)
+	reconciliationController := reconciliation.NewWorkerController(reconciliationWorkerService, reconciliationScheduler)
 	paymentService := payment.NewPayment(l.logger, paymentMethods, expirationService)
+	invoiceWorkerService := invoice.NewWorker(orderRepo, paymentBot, paymentService)
+	invoiceController := invoice.NewWorkerController(invoiceWorkerService)
 
-	orderService := service.NewOrder(paymentService, orderRepo, txRunner)
+	orderService := service.NewOrder(paymentService, orderStatusService, orderPaymentService, orderRepo, txRunner, orderUserService, cfg.Orders)
 	orderCtrl := controller.NewOrder(orderService)
 
+	groupOrderRepo := repository.NewGroupOrder(l.db)
+	groupOrderService := service.NewGroupOrder(orderService, groupOrderRepo, txRunner)
+	groupOrderCtrl := controller.NewGroupOrder(groupOrderService)
+
+	cartRepo := repository.NewCart(l.db)
+	cartService := service.NewCart(orderService, cartRepo, dishRepo, txRunner)
+	cartCtrl := controller.NewCart(cartService)
+
 	restaurantRepo := repository.NewRestaurant(l.db)
 	restaurantService := service.NewRestaurant(restaurantRepo)
 	restaurantCtrl := controller.NewRestaurant(restaurantService)
 
+	deliverySlotRepo := repository.NewDeliverySlot(l.db)
+	deliverySlotService := service.NewDeliverySlot(deliverySlotRepo)
+	deliverySlotCtrl := controller.NewDeliverySlot(deliverySlotService)
+
+	promoCodeRepo := repository.NewPromoCode(l.db)
+	promoCodeService := service.NewPromoCode(promoCodeRepo, txRunner)
+	promoCodeCtrl := controller.NewPromoCode(promoCodeService)
+
+	orderLimitsRepo := repository.NewOrderLimits(l.db)
+	orderLimitsService := service.NewOrderLimits(orderLimitsRepo)
+	orderLimitsCtrl := controller.NewOrderLimits(orderLimitsService)
+
+	companyService := service.NewCompany(companyRepo)
+	companyCtrl := controller.NewCompany(companyService)
+
 	hrouter := routes.Router{
-		Auth:         authCtrl,
-		Dish:         dishCtrl,
-		DishCategory: dishesCategoriesCtrl,
-		Order:        orderCtrl,
-		Restaurant:   restaurantCtrl,
+		Auth:           authCtrl,
+		Dish:           dishCtrl,
+		DishCategory:   dishesCategoriesCtrl,
+		Order:          orderCtrl,
+		Restaurant:     restaurantCtrl,
+		DeliverySlot:   deliverySlotCtrl,
+		GroupOrder:     groupOrderCtrl,
+		Cart:           cartCtrl,
+		PromoCode:      promoCodeCtrl,
+		DishOption:     dishOptionCtrl,
+		Rating:         ratingCtrl,
+		OrderLimits:    orderLimitsCtrl,
+		Company:        companyCtrl,
+		Wallet:         walletCtrl,
+		PaymentGateway: paymentGatewayCtrl,
+		StarsRate:      starsRateCtrl,
+		PaymentLedger:  paymentLedgerCtrl,
 	}
 
-	orderUserService := bot_service.NewOrderUserService(l.tgBot, userRepo, orderRepo)
 	orderCsvExporter := service.NewCsvOrderExporter(orderRepo)
-	orderBotContrl := bcontroller.NewOrder(orderService, orderUserService, orderCsvExporter)
+	orderBotContrl := bcontroller.NewOrder(
+		orderService,
+		orderUserService,
+		orderCsvExporter,
+		companyService,
+		walletService,
+		paymentLedgerService,
+	)
 	botControllers := broutes.Controllers{
 		User:  userBotContr,
 		Order: orderBotContrl,
@@ -147,16 +271,69 @@ func (l Locator) LocatorConfig(ctx context.Context, cfg conf.Remote) (*Config, e
 		bgjob.WithPollInterval(5*time.Second), // nolint:mnd
 		bgjob.WithObserver(observer),
 	)
+	invoiceWorker := bgjob.NewWorker(
+		l.bgJobCli,
+		invoice.WorkerQueue,
+		invoiceController,
+		bgjob.WithPollInterval(5*time.Second), // nolint:mnd
+		bgjob.WithObserver(observer),
+	)
+	corporateWorker := bgjob.NewWorker(
+		l.bgJobCli,
+		corporate.WorkerQueue,
+		corporateController,
+		bgjob.WithPollInterval(5*time.Second), // nolint:mnd
+		bgjob.WithObserver(observer),
+	)
+	cashWorker := bgjob.NewWorker(
+		l.bgJobCli,
+		cash.WorkerQueue,
+		cashController,
+		bgjob.WithPollInterval(5*time.Second), // nolint:mnd
+		bgjob.WithObserver(observer),
+	)
+	walletWorker := bgjob.NewWorker(
+		l.bgJobCli,
+		wallet_payment.WorkerQueue,
+		walletController,
+		bgjob.WithPollInterval(5*time.Second), // nolint:mnd
+		bgjob.WithObserver(observer),
+	)
+	refundWorker := bgjob.NewWorker(
+		l.bgJobCli,
+		refund.WorkerQueue,
+		refundController,
+		bgjob.WithPollInterval(5*time.Second), // nolint:mnd
+		bgjob.WithObserver(observer),
+	)
+	reconciliationWorker := bgjob.NewWorker(
+		l.bgJobCli,
+		reconciliation.WorkerQueue,
+		reconciliationController,
+		bgjob.WithPollInterval(time.Minute),
+		bgjob.WithObserver(observer),
+	)
 	err := broutes.RegisterRoutes(ctx, l.tgBot, userRepo)
 	if err != nil {
 		return nil, errors.WithMessage(err, "register bot routes")
 	}
+	err = reconciliationScheduler.Schedule(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, "schedule payment reconciliation")
+	}
 	return &Config{
 		BotRouter:  brouter,
 		HttpRouter: hrouter.Handler(authMiddleware, endpoint.DefaultWrapper(l.logger, hlog.Log(l.logger, true))),
 		Workers: []*bgjob.Worker{
 			telegramWorker,
+			starsWorker,
 			expirationWorker,
+			invoiceWorker,
+			corporateWorker,
+			cashWorker,
+			walletWorker,
+			refundWorker,
+			reconciliationWorker,
 		},
 	}, nil
 }
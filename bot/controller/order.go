@@ -3,6 +3,7 @@ package controller
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,7 +19,8 @@ import (
 
 type OrderService interface {
 	GetOrder(ctx context.Context, orderId string) (*entity.Order, error)
-	SetOrderStatus(ctx context.Context, orderId string, oldStatus string, newStatus string) error
+	SetOrderStatus(ctx context.Context, req entity.OrderStatusChange) error
+	PayOrder(ctx context.Context, req entity.OrderStatusChange, payment entity.OrderPayment) error
 	GetOrderStatus(ctx context.Context, orderId string) (string, error)
 	IsOrderingAllowed(ctx context.Context) (bool, error)
 	SetOrderingAllowed(ctx context.Context, isAllowed bool) error
@@ -27,28 +29,59 @@ type OrderService interface {
 type OrderUserService interface {
 	NotifySuccessPayment(ctx context.Context, req *entity.Order) error
 	NotifyOrderArrival(ctx context.Context, req entity.QueryCallbackPayload) error
-	CancelPaidOrder(ctx context.Context, req entity.QueryCallbackPayload) error
+	CancelPaidOrder(ctx context.Context, req entity.QueryCallbackPayload, actor string) error
+	GetSlotOrdersReport(ctx context.Context, date time.Time) (string, error)
+	NotifyGroupOrderArrival(ctx context.Context, req entity.QueryCallbackPayload) error
+	CancelPaidGroupOrder(ctx context.Context, req entity.QueryCallbackPayload, actor string) error
+	RequestOrderRating(ctx context.Context, orderId string) error
+	RateOrder(ctx context.Context, req entity.QueryCallbackPayload, telegramId int64) error
+	RateRestaurant(ctx context.Context, req entity.QueryCallbackPayload, telegramId int64) error
+	CommentOrder(ctx context.Context, telegramId int64, orderId string, comment string) error
+	NotifyCashOrderArrival(ctx context.Context, req entity.QueryCallbackPayload) (tg_bot.InlineKeyboardMarkup, error)
+	AcceptCashPayment(ctx context.Context, req entity.QueryCallbackPayload, actor string) error
+	CancelCashOrder(ctx context.Context, req entity.QueryCallbackPayload, actor string) error
 }
 
 type CsvExporter interface {
 	GetOrdersCsv(ctx context.Context, start time.Time, end time.Time) ([]byte, error)
 }
 
+type WalletService interface {
+	TopUp(ctx context.Context, userId string, amount int64, chargeId string, actor string) (int64, error)
+}
+
+type CompanyStatementExporter interface {
+	GetStatementFile(ctx context.Context, companyId int32, month string, format string) ([]byte, error)
+}
+
+type PaymentRecorder interface {
+	RecordUnappliedPayment(ctx context.Context, orderId string, result entity.PaymentResult) error
+}
+
 type Order struct {
-	orderService OrderService
-	userService  OrderUserService
-	cvsExporter  CsvExporter
+	orderService      OrderService
+	userService       OrderUserService
+	cvsExporter       CsvExporter
+	statementExporter CompanyStatementExporter
+	walletService     WalletService
+	paymentRecorder   PaymentRecorder
 }
 
 func NewOrder(
 	service OrderService,
 	userService OrderUserService,
 	cvsExporter CsvExporter,
+	statementExporter CompanyStatementExporter,
+	walletService WalletService,
+	paymentRecorder PaymentRecorder,
 ) Order {
 	return Order{
-		orderService: service,
-		userService:  userService,
-		cvsExporter:  cvsExporter,
+		orderService:      service,
+		userService:       userService,
+		cvsExporter:       cvsExporter,
+		statementExporter: statementExporter,
+		walletService:     walletService,
+		paymentRecorder:   paymentRecorder,
 	}
 }
 func (c Order) HandlePayment(ctx context.Context, update tg_bot.Update) (tg_bot.Chattable, error) {
@@ -58,8 +91,40 @@ func (c Order) HandlePayment(ctx context.Context, update tg_bot.Update) (tg_bot.
 	if err != nil {
 		return nil, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument, "invalid payment payload", err)
 	}
+	if payload.TopUpUserId != "" {
+		return c.handleTopUp(ctx, update, payload)
+	}
 
-	err = c.orderService.SetOrderStatus(ctx, payload.OrderId, entity.OrderItemStatusProcess, entity.OrderItemStatusPaid)
+	// the whole successful payment is kept in the payments ledger for the reconciliation
+	rawPayment, err := json.Marshal(msg.SuccessfulPayment)
+	if err != nil {
+		return nil, errors.WithMessage(err, "marshal successful payment")
+	}
+	result := entity.PaymentResult{
+		Amount:                  int64(msg.SuccessfulPayment.TotalAmount),
+		Currency:                msg.SuccessfulPayment.Currency,
+		TelegramPaymentChargeId: msg.SuccessfulPayment.TelegramPaymentChargeID,
+		ProviderPaymentChargeId: msg.SuccessfulPayment.ProviderPaymentChargeID,
+		Payload:                 string(rawPayment),
+	}
+	err = c.orderService.PayOrder(ctx, entity.OrderStatusChange{
+		OrderId: payload.OrderId,
+		From:    entity.OrderItemStatusProcess,
+		To:      entity.OrderItemStatusPaid,
+		Actor:   entity.TelegramActor(msg.From.Id),
+		Reason:  "оплата заказа",
+	}, entity.OrderPayment{
+		ChargeId:         msg.SuccessfulPayment.TelegramPaymentChargeID,
+		ProviderChargeId: msg.SuccessfulPayment.ProviderPaymentChargeID,
+		Result:           result,
+	})
+	if errors.Is(err, domain.ErrOrderStatusConflict) {
+		// the order was canceled before the payment, e.g. expired, the reconciliation reports the payment
+		recordErr := c.paymentRecorder.RecordUnappliedPayment(ctx, payload.OrderId, result)
+		if recordErr != nil {
+			return nil, errors.WithMessage(recordErr, "record unapplied payment")
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -75,6 +140,25 @@ func (c Order) HandlePayment(ctx context.Context, update tg_bot.Update) (tg_bot.
 	return nil, nil // nolint:nilnil
 }
 
+// nolint:mnd
+func (c Order) handleTopUp(ctx context.Context, update tg_bot.Update, payload entity.PaymentPayload) (tg_bot.Chattable, error) {
+	msg := update.Message
+	amount := int64(msg.SuccessfulPayment.TotalAmount)
+	balance, err := c.walletService.TopUp(ctx,
+		payload.TopUpUserId,
+		amount,
+		msg.SuccessfulPayment.TelegramPaymentChargeID,
+		entity.TelegramActor(msg.From.Id),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return tg_bot.NewMessage(msg.Chat.Id, fmt.Sprintf(
+		"Кошелёк пополнен на %d.%02d руб, баланс: %d.%02d руб",
+		amount/100, amount%100, balance/100, balance%100,
+	)), nil
+}
+
 func (c Order) CsvOrdersInfo(ctx context.Context, update tg_bot.Update) (tg_bot.Chattable, error) {
 	arguments := update.Message.CommandArguments()
 	dates := strings.Split(arguments, "-")
@@ -111,6 +195,60 @@ func (c Order) CsvOrdersInfo(ctx context.Context, update tg_bot.Update) (tg_bot.
 	return document, nil
 }
 
+func (c Order) CompanyStatement(ctx context.Context, update tg_bot.Update) (tg_bot.Chattable, error) {
+	arguments := strings.Fields(update.Message.CommandArguments())
+	// nolint:mnd
+	if len(arguments) < 2 || len(arguments) > 3 {
+		return nil, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument,
+			"неправильный формат аргументов, должен быть: номер_компании гггг.мм [csv|xlsx]",
+			errors.New("invalid arguments count"),
+		)
+	}
+	companyId, err := strconv.ParseInt(arguments[0], 10, 32)
+	if err != nil {
+		return nil, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument,
+			"неправильный номер компании",
+			err,
+		)
+	}
+	month := arguments[1]
+	format := domain.StatementFormatCsv
+	if len(arguments) == 3 { // nolint:mnd
+		format = arguments[2]
+	}
+
+	body, err := c.statementExporter.GetStatementFile(ctx, int32(companyId), month, format)
+	switch {
+	case errors.Is(err, domain.ErrCompanyNotFound):
+		return nil, apierrors.NewBusinessError(domain.ErrCodeCompanyNotFound, domain.ErrCompanyNotFound.Error(), err)
+	case err != nil:
+		return nil, err
+	}
+	document := tg_bot.NewDocument(update.FromChat().Id, tg_bot.FileBytes{
+		Name:  fmt.Sprintf("company_%d_%s.%s", companyId, month, format),
+		Bytes: body,
+	})
+	document.Caption = fmt.Sprintf("выписка компании №%d за %s", companyId, month)
+	return document, nil
+}
+
+func (c Order) SlotOrders(ctx context.Context, update tg_bot.Update) (tg_bot.Chattable, error) {
+	date, err := time.Parse(entity.DataFormat, strings.TrimSpace(update.Message.CommandArguments()))
+	if err != nil {
+		return nil, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument,
+			"неправильный формат даты, должен быть: гггг.мм.дд",
+			err,
+		)
+	}
+	report, err := c.userService.GetSlotOrdersReport(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+	message := tg_bot.NewMessage(update.FromChat().Id, report)
+	message.ParseMode = tg_bot.ModeHTML
+	return message, nil
+}
+
 // nolint:nilerr
 func (c Order) HandlePreCheckout(ctx context.Context, update tg_bot.Update) (tg_bot.Chattable, error) {
 	query := update.PreCheckoutQuery
@@ -123,6 +261,12 @@ func (c Order) HandlePreCheckout(ctx context.Context, update tg_bot.Update) (tg_
 			ErrorMessage:       "invalid payload",
 		}, nil
 	}
+	if payload.TopUpUserId != "" {
+		return tg_bot.PreCheckoutConfig{
+			PreCheckoutQueryID: query.Id,
+			OK:                 true,
+		}, nil
+	}
 
 	orderStatus, err := c.orderService.GetOrderStatus(ctx, payload.OrderId)
 	if err != nil {
@@ -141,6 +285,12 @@ func (c Order) HandlePreCheckout(ctx context.Context, update tg_bot.Update) (tg_
 			OK:                 false,
 			ErrorMessage:       "order canceled",
 		}, nil
+	case orderStatus != entity.OrderItemStatusProcess:
+		return tg_bot.PreCheckoutConfig{
+			PreCheckoutQueryID: query.Id,
+			OK:                 false,
+			ErrorMessage:       "order can't be paid",
+		}, nil
 	}
 	allowed, err := c.orderService.IsOrderingAllowed(ctx)
 	if err != nil {
@@ -180,6 +330,7 @@ func (c Order) HandleCallbackQuery(ctx context.Context, update tg_bot.Update) (t
 	if err != nil {
 		return nil, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument, "invalid callback query payload", err)
 	}
+	markup := tg_bot.NewInlineKeyboardMarkup([]tg_bot.InlineKeyboardButton{})
 	switch {
 	case req.Command == entity.NotifyArrivalCommand:
 		err = c.userService.NotifyOrderArrival(ctx, req)
@@ -187,12 +338,57 @@ func (c Order) HandleCallbackQuery(ctx context.Context, update tg_bot.Update) (t
 			return nil, err
 		}
 	case req.Command == entity.SuccessOrderCommand:
-		err = c.orderService.SetOrderStatus(ctx, req.OrderId, entity.OrderItemStatusPaid, entity.OrderItemStatusSuccess)
+		err = c.orderService.SetOrderStatus(ctx, entity.OrderStatusChange{
+			OrderId: req.OrderId,
+			From:    entity.OrderItemStatusPaid,
+			To:      entity.OrderItemStatusSuccess,
+			Actor:   entity.TelegramActor(update.CallbackQuery.From.Id),
+			Reason:  "получение подтверждено",
+		})
+		if err != nil {
+			return nil, err
+		}
+		err = c.userService.RequestOrderRating(ctx, req.OrderId)
 		if err != nil {
 			return nil, err
 		}
 	case req.Command == entity.CancelOrderCommand:
-		err = c.userService.CancelPaidOrder(ctx, req)
+		err = c.userService.CancelPaidOrder(ctx, req, entity.TelegramActor(update.CallbackQuery.From.Id))
+		if err != nil {
+			return nil, err
+		}
+	case req.Command == entity.NotifyGroupArrivalCommand:
+		err = c.userService.NotifyGroupOrderArrival(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+	case req.Command == entity.CancelGroupOrderCommand:
+		err = c.userService.CancelPaidGroupOrder(ctx, req, entity.TelegramActor(update.CallbackQuery.From.Id))
+		if err != nil {
+			return nil, err
+		}
+	case req.Command == entity.RateOrderCommand:
+		err = c.userService.RateOrder(ctx, req, update.CallbackQuery.From.Id)
+		if err != nil {
+			return nil, ratingError(err)
+		}
+	case req.Command == entity.RateRestaurantCommand:
+		err = c.userService.RateRestaurant(ctx, req, update.CallbackQuery.From.Id)
+		if err != nil {
+			return nil, ratingError(err)
+		}
+	case req.Command == entity.NotifyCashArrivalCommand:
+		markup, err = c.userService.NotifyCashOrderArrival(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+	case req.Command == entity.CashPaidCommand:
+		err = c.userService.AcceptCashPayment(ctx, req, entity.TelegramActor(update.CallbackQuery.From.Id))
+		if err != nil {
+			return nil, err
+		}
+	case req.Command == entity.CancelCashOrderCommand:
+		err = c.userService.CancelCashOrder(ctx, req, entity.TelegramActor(update.CallbackQuery.From.Id))
 		if err != nil {
 			return nil, err
 		}
@@ -201,7 +397,38 @@ func (c Order) HandleCallbackQuery(ctx context.Context, update tg_bot.Update) (t
 	editMarkup := tg_bot.NewEditMessageReplyMarkup(
 		update.CallbackQuery.Message.Chat.Id,
 		update.CallbackQuery.Message.MessageID,
-		tg_bot.NewInlineKeyboardMarkup([]tg_bot.InlineKeyboardButton{}),
+		markup,
 	)
 	return editMarkup, nil
 }
+
+func (c Order) Feedback(ctx context.Context, update tg_bot.Update) (tg_bot.Chattable, error) {
+	orderId, comment, _ := strings.Cut(strings.TrimSpace(update.Message.CommandArguments()), " ")
+	comment = strings.TrimSpace(comment)
+	if orderId == "" || comment == "" {
+		return nil, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument,
+			"неправильный формат отзыва, должен быть: /feedback номер_заказа текст отзыва",
+			errors.New("invalid feedback format"),
+		)
+	}
+	err := c.userService.CommentOrder(ctx, update.Message.From.Id, orderId, comment)
+	if err != nil {
+		return nil, ratingError(err)
+	}
+	return tg_bot.NewMessage(update.Message.Chat.Id, "спасибо за отзыв"), nil
+}
+
+func ratingError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrOrderNotFound):
+		return apierrors.NewBusinessError(domain.ErrCodeOrderNotFound, domain.ErrOrderNotFound.Error(), err)
+	case errors.Is(err, domain.ErrForbidden):
+		return apierrors.NewBusinessError(domain.ErrCodeForbidden, domain.ErrForbidden.Error(), err)
+	case errors.Is(err, domain.ErrOrderRatingForbidden):
+		return apierrors.NewBusinessError(domain.ErrCodeOrderRatingForbidden, domain.ErrOrderRatingForbidden.Error(), err)
+	case errors.Is(err, domain.ErrOrderRatingNotFound):
+		return apierrors.NewBusinessError(domain.ErrCodeOrderRatingNotFound, domain.ErrOrderRatingNotFound.Error(), err)
+	default:
+		return err
+	}
+}
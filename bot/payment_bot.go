@@ -4,65 +4,99 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/Falokut/go-kit/json"
 
+	"dishes-service-backend/domain"
 	"dishes-service-backend/entity"
 
+	"github.com/Falokut/go-kit/log"
 	"github.com/Falokut/go-kit/tg_bot"
 	"github.com/pkg/errors"
 )
 
 type OrderService interface {
-	UpdateOrderStatus(ctx context.Context, orderId string, newStatus string) error
+	GetOrderStatus(ctx context.Context, orderId string) (string, error)
 	IsOrderingAllowed(ctx context.Context) (bool, error)
+	SetOrderInvoiceMessage(ctx context.Context, orderId string, message entity.InvoiceMessage) error
+	GetOrderInvoiceMessage(ctx context.Context, orderId string) (entity.InvoiceMessage, error)
+}
+
+type OrderPaymentService interface {
+	Cancel(ctx context.Context, req entity.OrderStatusChange, payment entity.OrderPayment) error
 }
 
 type BotAPI interface {
 	Request(c tg_bot.Chattable) (*tg_bot.ApiResponse, error)
+	MakeRequest(endpoint string, params tg_bot.Params) (*tg_bot.ApiResponse, error)
 }
 type PaymentBot struct {
-	bot          BotAPI
-	invoiceToken string
-	service      OrderService
+	bot           BotAPI
+	invoiceToken  string
+	service       OrderService
+	orderPayments OrderPaymentService
+	logger        log.Logger
 }
 
-func NewPaymentBot(token string, bot BotAPI, service OrderService) PaymentBot {
+func NewPaymentBot(
+	token string,
+	bot BotAPI,
+	service OrderService,
+	orderPayments OrderPaymentService,
+	logger log.Logger,
+) PaymentBot {
 	return PaymentBot{
-		invoiceToken: token,
-		bot:          bot,
-		service:      service,
+		invoiceToken:  token,
+		bot:           bot,
+		service:       service,
+		orderPayments: orderPayments,
+		logger:        logger,
 	}
 }
 
-const rubCurrency = "RUB"
+const (
+	rubCurrency   = "RUB"
+	starsCurrency = "XTR"
+)
+
+// the error of refundStarPayment for the charge refunded before
+const chargeAlreadyRefunded = "CHARGE_ALREADY_REFUNDED"
 
 func (b PaymentBot) ProcessPayment(ctx context.Context, order *entity.Order, chatId int64) error {
-	isOrderingAllowed, err := b.service.IsOrderingAllowed(ctx)
-	if err != nil {
-		return errors.WithMessage(err, "get is ordering allowed")
+	ready, err := b.isReadyForInvoice(ctx, order.Id)
+	if err != nil || !ready {
+		return err
 	}
-	if !isOrderingAllowed {
-		return b.cancelOrder(ctx, order.Id)
-	}
-	payload := entity.PaymentPayload{
+	args, err := json.Marshal(entity.PaymentPayload{
 		ChatId:  chatId,
 		OrderId: order.Id,
-	}
-
-	args, err := json.Marshal(payload)
+	})
 	if err != nil {
 		return errors.WithMessage(err, "marhal payload")
 	}
 
-	prices := make([]tg_bot.LabeledPrice, len(order.Items))
+	prices := make([]tg_bot.LabeledPrice, len(order.Items), len(order.Items)+2) // nolint:mnd
 	for i := range order.Items {
-		label := fmt.Sprintf("%s x %d", order.Items[i].Name, order.Items[i].Count)
+		label := fmt.Sprintf("%s x %d", order.Items[i].FullName(), order.Items[i].Count)
 		prices[i] = tg_bot.LabeledPrice{
 			Label:  label,
 			Amount: order.Items[i].Price,
 		}
 	}
+	if order.Discount > 0 {
+		prices = append(prices, tg_bot.LabeledPrice{
+			Label:  fmt.Sprintf("Скидка по промокоду %s", order.PromoCode),
+			Amount: -order.Discount,
+		})
+	}
+	if order.DeliveryFee > 0 {
+		prices = append(prices, tg_bot.LabeledPrice{
+			Label:  "Доставка",
+			Amount: order.DeliveryFee,
+		})
+	}
 
 	invoice := tg_bot.NewInvoice(
 		chatId,
@@ -74,21 +108,180 @@ func (b PaymentBot) ProcessPayment(ctx context.Context, order *entity.Order, cha
 		rubCurrency,
 		prices,
 	)
+	return b.sendOrderInvoice(ctx, order.Id, invoice)
+}
+
+// ProcessStarsPayment sends the order invoice in Telegram Stars, the order total is converted by the rate
+func (b PaymentBot) ProcessStarsPayment(ctx context.Context, order *entity.Order, chatId int64, rate entity.StarsRate) error {
+	ready, err := b.isReadyForInvoice(ctx, order.Id)
+	if err != nil || !ready {
+		return err
+	}
+	if rate.StarPrice <= 0 {
+		return b.cancelOrder(ctx, order.Id, "оплата в Telegram Stars недоступна")
+	}
+	args, err := json.Marshal(entity.PaymentPayload{
+		ChatId:  chatId,
+		OrderId: order.Id,
+	})
+	if err != nil {
+		return errors.WithMessage(err, "marhal payload")
+	}
+
+	// the invoice in stars has exactly one price and no provider token
+	title := fmt.Sprintf("Заказ № %s", order.Id)
+	invoice := tg_bot.NewInvoice(
+		chatId,
+		title,
+		"оплата заказа",
+		string(args),
+		"",
+		"invoice",
+		starsCurrency,
+		[]tg_bot.LabeledPrice{{Label: title, Amount: rate.StarsAmount(order.Total)}},
+	)
+	return b.sendOrderInvoice(ctx, order.Id, invoice)
+}
+
+// RefundStarPayment returns the stars paid by the user, the charge refunded before isn't an error
+func (b PaymentBot) RefundStarPayment(ctx context.Context, telegramId int64, chargeId string) error {
+	resp, err := b.bot.MakeRequest("refundStarPayment", tg_bot.Params{
+		"user_id":                    strconv.FormatInt(telegramId, 10),
+		"telegram_payment_charge_id": chargeId,
+	})
+	switch {
+	case resp != nil && strings.Contains(resp.Description, chargeAlreadyRefunded):
+		return nil
+	case err != nil:
+		return errors.WithMessage(err, "refund star payment")
+	case !resp.Ok:
+		return errors.Errorf("refund star payment failed: %s", resp.Description)
+	}
+	return nil
+}
+
+// isReadyForInvoice reports whether the order still waits for the invoice,
+// the order is canceled if ordering is suspended
+func (b PaymentBot) isReadyForInvoice(ctx context.Context, orderId string) (bool, error) {
+	status, err := b.service.GetOrderStatus(ctx, orderId)
+	if err != nil {
+		return false, errors.WithMessage(err, "get order status")
+	}
+	if status != entity.OrderItemStatusProcess {
+		// order was canceled before the invoice was sent
+		return false, nil
+	}
+
+	isOrderingAllowed, err := b.service.IsOrderingAllowed(ctx)
+	if err != nil {
+		return false, errors.WithMessage(err, "get is ordering allowed")
+	}
+	if !isOrderingAllowed {
+		return false, b.cancelOrder(ctx, orderId, "оформление заказов приостановлено")
+	}
+	return true, nil
+}
+
+func (b PaymentBot) sendOrderInvoice(ctx context.Context, orderId string, invoice tg_bot.InvoiceConfig) error {
+	resp, err := b.bot.Request(invoice)
+	if err != nil {
+		return errors.WithMessage(err, "send invoice")
+	}
+	switch {
+	case resp.ErrorCode == http.StatusBadRequest:
+		return b.cancelOrder(ctx, orderId, "не удалось выставить счёт")
+	case !resp.Ok:
+		return errors.New("send invoice failed")
+	}
+
+	// the invoice is already sent, failing here would send it again on retry
+	var message tg_bot.Message
+	err = json.Unmarshal(resp.Result, &message)
+	if err != nil {
+		b.logger.Warn(ctx, "unmarshal invoice message",
+			log.String("orderId", orderId),
+			log.Error(err),
+		)
+		return nil
+	}
+	err = b.service.SetOrderInvoiceMessage(ctx, orderId, entity.InvoiceMessage{
+		ChatId:    message.Chat.Id,
+		MessageId: message.MessageID,
+	})
+	if err != nil {
+		b.logger.Warn(ctx, "save invoice message",
+			log.String("orderId", orderId),
+			log.Error(err),
+		)
+	}
+	return nil
+}
+
+// DeleteOrderInvoice removes the invoice of the order from the chat,
+// so the user doesn't try to pay the order which can't be paid anymore
+func (b PaymentBot) DeleteOrderInvoice(ctx context.Context, orderId string) error {
+	message, err := b.service.GetOrderInvoiceMessage(ctx, orderId)
+	if err != nil {
+		return errors.WithMessage(err, "get order invoice message")
+	}
+	if message.MessageId == 0 {
+		return nil
+	}
+	resp, err := b.bot.Request(tg_bot.NewDeleteMessage(message.ChatId, message.MessageId))
+	if err != nil {
+		return errors.WithMessage(err, "delete invoice message")
+	}
+	switch {
+	case resp.ErrorCode == http.StatusBadRequest:
+		// the message is already deleted by the user or too old to be deleted
+		return nil
+	case !resp.Ok:
+		return errors.New("delete invoice message failed")
+	}
+	return nil
+}
+
+// SendTopUpInvoice sends the invoice for the wallet top-up, amount is in kopecks
+func (b PaymentBot) SendTopUpInvoice(ctx context.Context, chatId int64, userId string, amount int32) error {
+	args, err := json.Marshal(entity.PaymentPayload{
+		ChatId:      chatId,
+		TopUpUserId: userId,
+	})
+	if err != nil {
+		return errors.WithMessage(err, "marhal payload")
+	}
+
+	invoice := tg_bot.NewInvoice(
+		chatId,
+		"Пополнение кошелька",
+		"пополнение кошелька",
+		string(args),
+		b.invoiceToken,
+		"top_up",
+		rubCurrency,
+		[]tg_bot.LabeledPrice{{Label: "Пополнение кошелька", Amount: amount}},
+	)
 	resp, err := b.bot.Request(invoice)
 	if err != nil {
 		return errors.WithMessage(err, "send invoice")
 	}
 	switch {
 	case resp.ErrorCode == http.StatusBadRequest:
-		return b.cancelOrder(ctx, order.Id)
+		return domain.ErrTopUpInvoiceFailed
 	case !resp.Ok:
 		return errors.New("send invoice failed")
 	}
 	return nil
 }
 
-func (b PaymentBot) cancelOrder(ctx context.Context, orderId string) error {
-	err := b.service.UpdateOrderStatus(ctx, orderId, entity.OrderItemStatusCanceled)
+func (b PaymentBot) cancelOrder(ctx context.Context, orderId string, reason string) error {
+	err := b.orderPayments.Cancel(ctx, entity.OrderStatusChange{
+		OrderId: orderId,
+		From:    entity.OrderItemStatusProcess,
+		To:      entity.OrderItemStatusCanceled,
+		Actor:   entity.OrderActorSystem,
+		Reason:  reason,
+	}, entity.OrderPayment{})
 	if err != nil {
 		return errors.WithMessage(err, "cancel order")
 	}
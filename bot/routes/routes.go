@@ -96,6 +96,26 @@ func EndpointsDescriptors(c Controllers) []Endpoint {
 			Description: "получить csv файл с информацией о заказах в указанный период гггг.мм.дд-гггг.мм.дд",
 			Admin:       true,
 		},
+		{
+			Handler:     c.Order.CompanyStatement,
+			UpdateType:  tg_bot.MessageUpdateType,
+			Command:     "company_statement",
+			Description: "получить выписку компании за месяц: номер_компании гггг.мм [csv|xlsx]",
+			Admin:       true,
+		},
+		{
+			Handler:     c.Order.SlotOrders,
+			UpdateType:  tg_bot.MessageUpdateType,
+			Command:     "slot_orders",
+			Description: "получить оплаченные заказы по слотам доставки на дату гггг.мм.дд",
+			Admin:       true,
+		},
+		{
+			Handler:     c.Order.Feedback,
+			UpdateType:  tg_bot.MessageUpdateType,
+			Command:     "feedback",
+			Description: "оставить отзыв о полученном заказе: номер_заказа текст отзыва",
+		},
 		{
 			Handler:    c.Order.HandleCallbackQuery,
 			UpdateType: tg_bot.CallbackQueryUpdateType,
@@ -2,9 +2,11 @@ package service
 
 import (
 	"context"
+	"dishes-service-backend/domain"
 	"dishes-service-backend/entity"
 	"fmt"
 	"html"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,31 +18,62 @@ type UserRepo interface {
 	GetUserInfo(ctx context.Context, userId string) (entity.User, error)
 	GetAdminsChatsIds(ctx context.Context) ([]int64, error)
 	GetUserChatId(ctx context.Context, userId string) (int64, error)
+	GetUserIdByTelegramId(ctx context.Context, telegramId int64) (string, error)
 }
 
 type OrderRepo interface {
 	GetOrderedChatId(ctx context.Context, orderId string) (int64, error)
-	SetOrderStatus(ctx context.Context, orderId, oldStatus, newStatus string) error
+	GetPaidSlotOrders(ctx context.Context, date time.Time) ([]entity.DeliverySlotOrder, error)
+	GetOrder(ctx context.Context, orderId string) (*entity.Order, error)
+	GetOrders(ctx context.Context, filter entity.OrdersFilter) ([]entity.AdminOrder, error)
 }
+
+const maxGroupOrderOrders = 1000
+
+type OrderPaymentService interface {
+	Pay(ctx context.Context, req entity.OrderStatusChange, payment entity.OrderPayment) error
+	Cancel(ctx context.Context, req entity.OrderStatusChange, payment entity.OrderPayment) error
+}
+
+type RatingService interface {
+	RateOrder(ctx context.Context, userId string, req domain.RateOrderRequest) error
+	RateRestaurant(ctx context.Context, userId string, orderId string, restaurantId int32, score int32) error
+	CommentOrder(ctx context.Context, userId string, orderId string, comment string) error
+}
+
 type BotAPI interface {
 	Send(c tg_bot.Chattable) error
 }
 
 type UserOrder struct {
-	bot       BotAPI
-	userRepo  UserRepo
-	orderRepo OrderRepo
+	bot           BotAPI
+	userRepo      UserRepo
+	orderRepo     OrderRepo
+	orderPayments OrderPaymentService
+	ratingService RatingService
 }
 
-func NewOrderUserService(bot BotAPI, userRepo UserRepo, orderRepo OrderRepo) UserOrder {
+func NewOrderUserService(
+	bot BotAPI,
+	userRepo UserRepo,
+	orderRepo OrderRepo,
+	orderPayments OrderPaymentService,
+	ratingService RatingService,
+) UserOrder {
 	return UserOrder{
-		bot:       bot,
-		userRepo:  userRepo,
-		orderRepo: orderRepo,
+		bot:           bot,
+		userRepo:      userRepo,
+		orderRepo:     orderRepo,
+		orderPayments: orderPayments,
+		ratingService: ratingService,
 	}
 }
 
 func (s UserOrder) NotifySuccessPayment(ctx context.Context, order *entity.Order) error {
+	if order.GroupOrderId != "" {
+		return s.notifyGroupOrder(ctx, order.GroupOrderId)
+	}
+
 	adminIds, err := s.userRepo.GetAdminsChatsIds(ctx)
 	if err != nil {
 		return errors.WithMessage(err, "get admins chats ids")
@@ -107,8 +140,206 @@ func (s UserOrder) NotifyOrderArrival(ctx context.Context, req entity.QueryCallb
 	return nil
 }
 
-func (s UserOrder) CancelPaidOrder(ctx context.Context, req entity.QueryCallbackPayload) error {
-	err := s.orderRepo.SetOrderStatus(ctx, req.OrderId, entity.OrderItemStatusPaid, entity.OrderItemStatusCanceled)
+func (s UserOrder) CancelPaidOrder(ctx context.Context, req entity.QueryCallbackPayload, actor string) error {
+	return s.cancelOrder(ctx, req.OrderId, entity.OrderItemStatusPaid, actor)
+}
+
+// CancelCashOrder cancels the order waiting for the payment on pickup
+func (s UserOrder) CancelCashOrder(ctx context.Context, req entity.QueryCallbackPayload, actor string) error {
+	return s.cancelOrder(ctx, req.OrderId, entity.OrderItemStatusProcess, actor)
+}
+
+func (s UserOrder) cancelOrder(ctx context.Context, orderId string, from string, actor string) error {
+	err := s.orderPayments.Cancel(ctx, entity.OrderStatusChange{
+		OrderId: orderId,
+		From:    from,
+		To:      entity.OrderItemStatusCanceled,
+		Actor:   actor,
+		Reason:  "отменён администратором",
+	}, entity.OrderPayment{})
+	if err != nil {
+		return errors.WithMessage(err, "update order status")
+	}
+	order, err := s.orderRepo.GetOrder(ctx, orderId)
+	if err != nil {
+		return errors.WithMessage(err, "get order")
+	}
+	chatId, err := s.orderRepo.GetOrderedChatId(ctx, orderId)
+	if err != nil {
+		return errors.WithMessage(err, "get user chat id")
+	}
+	err = s.bot.Send(tg_bot.NewMessage(chatId, canceledOrderMessage(order)))
+	if err != nil {
+		return errors.WithMessagef(err, "send notification to chat: %d", chatId)
+	}
+	return nil
+}
+
+// nolint:mnd
+func canceledOrderMessage(order *entity.Order) string {
+	switch order.RefundStatus {
+	case entity.RefundStatusRefunded:
+		return fmt.Sprintf("Заказ №%s отменён, %d.%02d руб возвращены в кошелёк",
+			order.Id, order.Total/100, order.Total%100)
+	case entity.RefundStatusPending:
+		return fmt.Sprintf("Заказ №%s отменён, возврат %d.%02d руб оформлен, мы сообщим о его результате",
+			order.Id, order.Total/100, order.Total%100)
+	default:
+		return fmt.Sprintf("Заказ №%s отменён", order.Id)
+	}
+}
+
+// NotifyOrderRefunded notifies the user about the refund done through the payment method
+// nolint:mnd
+func (s UserOrder) NotifyOrderRefunded(ctx context.Context, order *entity.Order, amount int32) error {
+	chatId, err := s.orderRepo.GetOrderedChatId(ctx, order.Id)
+	if err != nil {
+		return errors.WithMessage(err, "get user chat id")
+	}
+	err = s.bot.Send(tg_bot.NewMessage(chatId, fmt.Sprintf(
+		"Оплата по заказу №%s возвращена: %d.%02d руб",
+		order.Id, amount/100, amount%100,
+	)))
+	if err != nil {
+		return errors.WithMessagef(err, "send notification to chat: %d", chatId)
+	}
+	return nil
+}
+
+// NotifyRefundFailed asks the admins to refund the order by hand
+// nolint:mnd
+func (s UserOrder) NotifyRefundFailed(ctx context.Context, order *entity.Order, amount int32) error {
+	adminIds, err := s.userRepo.GetAdminsChatsIds(ctx)
+	if err != nil {
+		return errors.WithMessage(err, "get admins chats ids")
+	}
+	adminMessage := fmt.Sprintf(
+		"Не удалось вернуть оплату по заказу №%s, требуется ручной возврат\n"+
+			"Способ оплаты: %s\nСумма: %d.%02d руб\nПлатёж: %s\nПлатёж у провайдера: %s",
+		order.Id, order.PaymentMethod, amount/100, amount%100,
+		order.PaymentChargeId, order.ProviderChargeId,
+	)
+	for _, chatId := range adminIds {
+		err = s.bot.Send(tg_bot.NewMessage(chatId, adminMessage))
+		if err != nil {
+			return errors.WithMessagef(err, "send notification to chat: %d", chatId)
+		}
+	}
+
+	chatId, err := s.orderRepo.GetOrderedChatId(ctx, order.Id)
+	if err != nil {
+		return errors.WithMessage(err, "get user chat id")
+	}
+	err = s.bot.Send(tg_bot.NewMessage(chatId, fmt.Sprintf(
+		"Не удалось автоматически вернуть оплату по заказу №%s, администратор свяжется с вами",
+		order.Id,
+	)))
+	if err != nil {
+		return errors.WithMessagef(err, "send notification to chat: %d", chatId)
+	}
+	return nil
+}
+
+// NotifyCashOrder notifies the admins about the order paid on pickup with the payment-due amount
+// nolint:mnd
+func (s UserOrder) NotifyCashOrder(ctx context.Context, order *entity.Order) error {
+	adminIds, err := s.userRepo.GetAdminsChatsIds(ctx)
+	if err != nil {
+		return errors.WithMessage(err, "get admins chats ids")
+	}
+	user, err := s.userRepo.GetUserInfo(ctx, order.UserId)
+	if err != nil {
+		return errors.WithMessage(err, "get user info")
+	}
+
+	orderInfoString := s.getOrderInfoString(order, &user) +
+		fmt.Sprintf("\n<b>К оплате наличными:</b> %d.%02d руб", order.Total/100, order.Total%100)
+	markup := tg_bot.NewInlineKeyboardMarkup(
+		[]tg_bot.InlineKeyboardButton{tg_bot.NewInlineKeyboardButtonData(
+			"оповестить о прибытии заказа",
+			entity.QueryCallbackPayload{Command: entity.NotifyCashArrivalCommand, OrderId: order.Id}.String(),
+		)},
+		[]tg_bot.InlineKeyboardButton{tg_bot.NewInlineKeyboardButtonData(
+			"отменить заказ",
+			entity.QueryCallbackPayload{Command: entity.CancelCashOrderCommand, OrderId: order.Id}.String(),
+		)},
+	)
+	for _, chatId := range adminIds {
+		message := tg_bot.NewMessage(chatId, orderInfoString)
+		message.ReplyMarkup = markup
+		message.ParseMode = tg_bot.ModeHTML
+		err = s.bot.Send(message)
+		if err != nil {
+			return errors.WithMessagef(err, "send notification to chat: %d", chatId)
+		}
+	}
+
+	chatId, err := s.userRepo.GetUserChatId(ctx, order.UserId)
+	if err != nil {
+		return errors.WithMessage(err, "get user chat id")
+	}
+	message := tg_bot.NewMessage(chatId, fmt.Sprintf(
+		"Заказ №%s оформлен, оплата наличными при получении: %d.%02d руб",
+		order.Id, order.Total/100, order.Total%100,
+	))
+	err = s.bot.Send(message)
+	if err != nil {
+		return errors.WithMessagef(err, "send notification to chat: %d", chatId)
+	}
+	return nil
+}
+
+// NotifyCashOrderArrival notifies the user about the arrival of the unpaid order,
+// the returned markup lets the admin accept the payment
+// nolint:mnd
+func (s UserOrder) NotifyCashOrderArrival(
+	ctx context.Context,
+	req entity.QueryCallbackPayload,
+) (tg_bot.InlineKeyboardMarkup, error) {
+	order, err := s.orderRepo.GetOrder(ctx, req.OrderId)
+	if err != nil {
+		return tg_bot.InlineKeyboardMarkup{}, errors.WithMessage(err, "get order")
+	}
+	if order.Status != entity.OrderItemStatusProcess {
+		return tg_bot.InlineKeyboardMarkup{}, errors.WithMessagef(domain.ErrOrderStatusConflict, "expected %s, got %s",
+			entity.OrderItemStatusProcess, order.Status,
+		)
+	}
+	chatId, err := s.orderRepo.GetOrderedChatId(ctx, order.Id)
+	if err != nil {
+		return tg_bot.InlineKeyboardMarkup{}, errors.WithMessage(err, "get user chat id")
+	}
+
+	message := tg_bot.NewMessage(chatId, fmt.Sprintf(
+		"Заказ №%s прибыл, к оплате наличными: %d.%02d руб",
+		order.Id, order.Total/100, order.Total%100,
+	))
+	err = s.bot.Send(message)
+	if err != nil {
+		return tg_bot.InlineKeyboardMarkup{}, errors.WithMessagef(err, "send notification to chat: %d", chatId)
+	}
+
+	return tg_bot.NewInlineKeyboardMarkup(
+		[]tg_bot.InlineKeyboardButton{tg_bot.NewInlineKeyboardButtonData(
+			fmt.Sprintf("принять оплату наличными %d.%02d руб", order.Total/100, order.Total%100),
+			entity.QueryCallbackPayload{Command: entity.CashPaidCommand, OrderId: order.Id}.String(),
+		)},
+		[]tg_bot.InlineKeyboardButton{tg_bot.NewInlineKeyboardButtonData(
+			"отменить заказ",
+			entity.QueryCallbackPayload{Command: entity.CancelCashOrderCommand, OrderId: order.Id}.String(),
+		)},
+	), nil
+}
+
+// AcceptCashPayment marks the order paid in cash, the user can confirm the receipt after it
+func (s UserOrder) AcceptCashPayment(ctx context.Context, req entity.QueryCallbackPayload, actor string) error {
+	err := s.orderPayments.Pay(ctx, entity.OrderStatusChange{
+		OrderId: req.OrderId,
+		From:    entity.OrderItemStatusProcess,
+		To:      entity.OrderItemStatusPaid,
+		Actor:   actor,
+		Reason:  "оплата наличными",
+	}, entity.OrderPayment{})
 	if err != nil {
 		return errors.WithMessage(err, "update order status")
 	}
@@ -116,13 +347,335 @@ func (s UserOrder) CancelPaidOrder(ctx context.Context, req entity.QueryCallback
 	if err != nil {
 		return errors.WithMessage(err, "get user chat id")
 	}
-	err = s.bot.Send(tg_bot.NewMessage(chatId, fmt.Sprintf("Заказ №%s отменён", req.OrderId)))
+
+	button := tg_bot.NewInlineKeyboardButtonData("подтвердить получение",
+		entity.QueryCallbackPayload{Command: entity.SuccessOrderCommand, OrderId: req.OrderId}.String(),
+	)
+	message := tg_bot.NewMessage(chatId, fmt.Sprintf("Заказ №%s оплачен наличными", req.OrderId))
+	message.ReplyMarkup = tg_bot.NewInlineKeyboardMarkup([]tg_bot.InlineKeyboardButton{button})
+	err = s.bot.Send(message)
+	if err != nil {
+		return errors.WithMessagef(err, "send notification to chat: %d", chatId)
+	}
+	return nil
+}
+
+// nolint:mnd
+func (s UserOrder) NotifyOrderItemsCanceled(
+	ctx context.Context,
+	orderId string,
+	items []entity.OrderItem,
+	refund int32,
+	reason string,
+) error {
+	chatId, err := s.orderRepo.GetOrderedChatId(ctx, orderId)
+	if err != nil {
+		return errors.WithMessage(err, "get user chat id")
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "В заказе №%s отменены блюда:\n", orderId)
+	for _, item := range items {
+		fmt.Fprintf(&builder, "%s x%d\n", item.FullName(), item.Count)
+	}
+	if reason != "" {
+		fmt.Fprintf(&builder, "Причина: %s\n", reason)
+	}
+	fmt.Fprintf(&builder, "Сумма возврата: %d.%02d руб", refund/100, refund%100)
+
+	err = s.bot.Send(tg_bot.NewMessage(chatId, builder.String()))
 	if err != nil {
 		return errors.WithMessagef(err, "send notification to chat: %d", chatId)
 	}
 	return nil
 }
 
+// NotifyPaymentRejected is called after the order was canceled because its payment was rejected
+func (s UserOrder) NotifyPaymentRejected(ctx context.Context, orderId string, reason string) error {
+	chatId, err := s.orderRepo.GetOrderedChatId(ctx, orderId)
+	if err != nil {
+		return errors.WithMessage(err, "get user chat id")
+	}
+	err = s.bot.Send(tg_bot.NewMessage(chatId, fmt.Sprintf("Заказ №%s отменён: %s", orderId, reason)))
+	if err != nil {
+		return errors.WithMessagef(err, "send notification to chat: %d", chatId)
+	}
+	return nil
+}
+
+// RequestOrderRating asks the user to rate the received order
+func (s UserOrder) RequestOrderRating(ctx context.Context, orderId string) error {
+	chatId, err := s.orderRepo.GetOrderedChatId(ctx, orderId)
+	if err != nil {
+		return errors.WithMessage(err, "get user chat id")
+	}
+
+	message := tg_bot.NewMessage(chatId, fmt.Sprintf("Оцените заказ №%s", orderId))
+	message.ReplyMarkup = getScoreMarkup(func(score int32) entity.QueryCallbackPayload {
+		return entity.QueryCallbackPayload{
+			Command:  entity.RateOrderCommand,
+			OrderId:  orderId,
+			Argument: strconv.Itoa(int(score)),
+		}
+	})
+	err = s.bot.Send(message)
+	if err != nil {
+		return errors.WithMessagef(err, "send notification to chat: %d", chatId)
+	}
+	return nil
+}
+
+// RateOrder saves the order score and asks the user to rate each restaurant of the order
+func (s UserOrder) RateOrder(ctx context.Context, req entity.QueryCallbackPayload, telegramId int64) error {
+	score, err := strconv.ParseInt(req.Argument, 10, 32)
+	if err != nil {
+		return errors.WithMessagef(err, "parse score '%s'", req.Argument)
+	}
+	userId, err := s.userRepo.GetUserIdByTelegramId(ctx, telegramId)
+	if err != nil {
+		return errors.WithMessage(err, "get user id by telegram id")
+	}
+	err = s.ratingService.RateOrder(ctx, userId, domain.RateOrderRequest{
+		Id:    req.OrderId,
+		Score: int32(score),
+	})
+	if err != nil {
+		return errors.WithMessage(err, "rate order")
+	}
+
+	order, err := s.orderRepo.GetOrder(ctx, req.OrderId)
+	if err != nil {
+		return errors.WithMessage(err, "get order")
+	}
+	chatId, err := s.userRepo.GetUserChatId(ctx, userId)
+	if err != nil {
+		return errors.WithMessage(err, "get user chat id")
+	}
+
+	rated := make(map[int32]bool)
+	for _, item := range order.Items {
+		if rated[item.RestaurantId] {
+			continue
+		}
+		rated[item.RestaurantId] = true
+		restaurantId := item.RestaurantId
+		message := tg_bot.NewMessage(chatId, fmt.Sprintf("Оцените ресторан %s", item.RestaurantName))
+		message.ReplyMarkup = getScoreMarkup(func(score int32) entity.QueryCallbackPayload {
+			return entity.QueryCallbackPayload{
+				Command:  entity.RateRestaurantCommand,
+				OrderId:  order.Id,
+				Argument: fmt.Sprintf("%d:%d", restaurantId, score),
+			}
+		})
+		err = s.bot.Send(message)
+		if err != nil {
+			return errors.WithMessagef(err, "send notification to chat: %d", chatId)
+		}
+	}
+
+	hint := fmt.Sprintf("Спасибо за оценку! Оставить отзыв о заказе можно командой /feedback %s текст отзыва", order.Id)
+	err = s.bot.Send(tg_bot.NewMessage(chatId, hint))
+	if err != nil {
+		return errors.WithMessagef(err, "send notification to chat: %d", chatId)
+	}
+	return nil
+}
+
+func (s UserOrder) RateRestaurant(ctx context.Context, req entity.QueryCallbackPayload, telegramId int64) error {
+	restaurantIdStr, scoreStr, found := strings.Cut(req.Argument, ":")
+	if !found {
+		return errors.Errorf("invalid restaurant score '%s'", req.Argument)
+	}
+	restaurantId, err := strconv.ParseInt(restaurantIdStr, 10, 32)
+	if err != nil {
+		return errors.WithMessagef(err, "parse restaurant id '%s'", restaurantIdStr)
+	}
+	score, err := strconv.ParseInt(scoreStr, 10, 32)
+	if err != nil {
+		return errors.WithMessagef(err, "parse score '%s'", scoreStr)
+	}
+	userId, err := s.userRepo.GetUserIdByTelegramId(ctx, telegramId)
+	if err != nil {
+		return errors.WithMessage(err, "get user id by telegram id")
+	}
+	err = s.ratingService.RateRestaurant(ctx, userId, req.OrderId, int32(restaurantId), int32(score))
+	if err != nil {
+		return errors.WithMessage(err, "rate restaurant")
+	}
+	return nil
+}
+
+func (s UserOrder) CommentOrder(ctx context.Context, telegramId int64, orderId string, comment string) error {
+	userId, err := s.userRepo.GetUserIdByTelegramId(ctx, telegramId)
+	if err != nil {
+		return errors.WithMessage(err, "get user id by telegram id")
+	}
+	err = s.ratingService.CommentOrder(ctx, userId, orderId, comment)
+	if err != nil {
+		return errors.WithMessage(err, "comment order")
+	}
+	return nil
+}
+
+func getScoreMarkup(payload func(score int32) entity.QueryCallbackPayload) tg_bot.InlineKeyboardMarkup {
+	buttons := make([]tg_bot.InlineKeyboardButton, 0, entity.MaxRatingScore)
+	for score := int32(entity.MinRatingScore); score <= entity.MaxRatingScore; score++ {
+		buttons = append(buttons, tg_bot.NewInlineKeyboardButtonData(
+			strconv.Itoa(int(score)),
+			payload(score).String(),
+		))
+	}
+	return tg_bot.NewInlineKeyboardMarkup(buttons)
+}
+
+// OrderExpired is called after unpaid order was canceled by expiration
+func (s UserOrder) OrderExpired(ctx context.Context, orderId string) error {
+	order, err := s.orderRepo.GetOrder(ctx, orderId)
+	if err != nil {
+		return errors.WithMessage(err, "get order")
+	}
+	chatId, err := s.orderRepo.GetOrderedChatId(ctx, orderId)
+	if err != nil {
+		return errors.WithMessage(err, "get user chat id")
+	}
+	err = s.bot.Send(tg_bot.NewMessage(chatId, fmt.Sprintf(
+		"Заказ №%s отменён: истёк срок оплаты. Оформите заказ заново, если он ещё нужен",
+		orderId,
+	)))
+	if err != nil {
+		return errors.WithMessagef(err, "send notification to chat: %d", chatId)
+	}
+	if order.GroupOrderId != "" {
+		return s.notifyGroupOrder(ctx, order.GroupOrderId)
+	}
+	return nil
+}
+
+// RemindOrderPayment reminds the user to pay the order before it expires
+// nolint:mnd
+func (s UserOrder) RemindOrderPayment(ctx context.Context, order *entity.Order, minutesLeft int) error {
+	chatId, err := s.orderRepo.GetOrderedChatId(ctx, order.Id)
+	if err != nil {
+		return errors.WithMessage(err, "get user chat id")
+	}
+	err = s.bot.Send(tg_bot.NewMessage(chatId, fmt.Sprintf(
+		"Заказ №%s на сумму %d.%02d руб ожидает оплаты. Если не оплатить его в течение %d мин, заказ будет отменён",
+		order.Id, order.Total/100, order.Total%100, minutesLeft,
+	)))
+	if err != nil {
+		return errors.WithMessagef(err, "send notification to chat: %d", chatId)
+	}
+	return nil
+}
+
+// notifyGroupOrder sends a single notification for the whole group order,
+// once none of its orders waits for payment
+func (s UserOrder) notifyGroupOrder(ctx context.Context, groupOrderId string) error {
+	orders, err := s.orderRepo.GetOrders(ctx, entity.OrdersFilter{
+		GroupOrderId: groupOrderId,
+		SortBy:       "createdAt",
+		SortOrder:    "asc",
+		Limit:        maxGroupOrderOrders,
+	})
+	if err != nil {
+		return errors.WithMessage(err, "get group order orders")
+	}
+	paidOrders := make([]entity.AdminOrder, 0, len(orders))
+	for _, order := range orders {
+		switch order.Status {
+		case entity.OrderItemStatusProcess:
+			return nil
+		case entity.OrderItemStatusPaid:
+			paidOrders = append(paidOrders, order)
+		}
+	}
+	if len(paidOrders) == 0 {
+		return nil
+	}
+
+	adminIds, err := s.userRepo.GetAdminsChatsIds(ctx)
+	if err != nil {
+		return errors.WithMessage(err, "get admins chats ids")
+	}
+	groupInfoString := s.getGroupOrderInfoString(groupOrderId, paidOrders)
+	markup := s.getMarkupForGroupOrder(groupOrderId)
+	for _, chatId := range adminIds {
+		message := tg_bot.NewMessage(chatId, groupInfoString)
+		message.ReplyMarkup = markup
+		message.ParseMode = tg_bot.ModeHTML
+		err = s.bot.Send(message)
+		if err != nil {
+			return errors.WithMessagef(err, "send notification to chat: %d", chatId)
+		}
+	}
+	return nil
+}
+
+func (s UserOrder) getMarkupForGroupOrder(groupOrderId string) tg_bot.InlineKeyboardMarkup {
+	arrivalPayload := entity.QueryCallbackPayload{
+		Command: entity.NotifyGroupArrivalCommand,
+		OrderId: groupOrderId,
+	}
+	cancelPayload := entity.QueryCallbackPayload{
+		Command: entity.CancelGroupOrderCommand,
+		OrderId: groupOrderId,
+	}
+	return tg_bot.NewInlineKeyboardMarkup(
+		[]tg_bot.InlineKeyboardButton{
+			tg_bot.NewInlineKeyboardButtonData("оповестить участников о прибытии заказа", arrivalPayload.String()),
+		},
+		[]tg_bot.InlineKeyboardButton{
+			tg_bot.NewInlineKeyboardButtonData("отменить групповой заказ", cancelPayload.String()),
+		},
+	)
+}
+
+func (s UserOrder) NotifyGroupOrderArrival(ctx context.Context, req entity.QueryCallbackPayload) error {
+	orders, err := s.getPaidGroupOrders(ctx, req.OrderId)
+	if err != nil {
+		return errors.WithMessage(err, "get paid group orders")
+	}
+	for _, order := range orders {
+		err = s.NotifyOrderArrival(ctx, entity.QueryCallbackPayload{
+			Command: entity.NotifyArrivalCommand,
+			OrderId: order.Id,
+		})
+		if err != nil {
+			return errors.WithMessagef(err, "notify order arrival, orderId=%s", order.Id)
+		}
+	}
+	return nil
+}
+
+func (s UserOrder) CancelPaidGroupOrder(ctx context.Context, req entity.QueryCallbackPayload, actor string) error {
+	orders, err := s.getPaidGroupOrders(ctx, req.OrderId)
+	if err != nil {
+		return errors.WithMessage(err, "get paid group orders")
+	}
+	for _, order := range orders {
+		err = s.CancelPaidOrder(ctx, entity.QueryCallbackPayload{
+			Command: entity.CancelOrderCommand,
+			OrderId: order.Id,
+		}, actor)
+		if err != nil {
+			return errors.WithMessagef(err, "cancel paid order, orderId=%s", order.Id)
+		}
+	}
+	return nil
+}
+
+func (s UserOrder) getPaidGroupOrders(ctx context.Context, groupOrderId string) ([]entity.AdminOrder, error) {
+	orders, err := s.orderRepo.GetOrders(ctx, entity.OrdersFilter{
+		GroupOrderId: groupOrderId,
+		Status:       entity.OrderItemStatusPaid,
+		Limit:        maxGroupOrderOrders,
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "get orders")
+	}
+	return orders, nil
+}
+
 // nolint:gosmopolitan,mnd
 func (s UserOrder) getOrderInfoString(order *entity.Order, user *entity.User) string {
 	var builder strings.Builder
@@ -130,8 +683,102 @@ func (s UserOrder) getOrderInfoString(order *entity.Order, user *entity.User) st
 	fmt.Fprintf(&builder, "<b>Заказ №%s</b>\n\n", html.EscapeString(order.Id))
 	builder.WriteString("<b>Состав заказа:</b>\n")
 
-	itemsByRestaurant := make(map[string][]entity.OrderItem, len(order.Items))
-	for _, item := range order.Items {
+	writeItemsByRestaurant(&builder, order.Items)
+
+	fmt.Fprintf(&builder, "<b>Имя заказавшего:</b> %s\n", html.EscapeString(user.Name))
+	fmt.Fprintf(&builder, "<b>Telegram ник:</b> @%s\n", html.EscapeString(user.Username))
+	fmt.Fprintf(&builder, "<b>Стоимость:</b> %d.%02d руб\n", order.Total/100, order.Total%100)
+	fmt.Fprintf(&builder, "<b>Пожелания:</b> '%s'\n", html.EscapeString(order.Wishes))
+	if order.DeliverySlot != "" {
+		fmt.Fprintf(&builder, "<b>Слот доставки:</b> %s\n", html.EscapeString(order.DeliverySlot))
+	}
+	fmt.Fprintf(&builder, "<b>Дата:</b> %s", html.EscapeString(order.CreatedAt.Local().Format(time.DateTime)))
+
+	return builder.String()
+}
+
+// nolint:mnd
+func (s UserOrder) GetSlotOrdersReport(ctx context.Context, date time.Time) (string, error) {
+	orders, err := s.orderRepo.GetPaidSlotOrders(ctx, date)
+	if err != nil {
+		return "", errors.WithMessage(err, "get paid slot orders")
+	}
+	if len(orders) == 0 {
+		return fmt.Sprintf("на %s нет оплаченных заказов со слотом доставки", date.Format(entity.DataFormat)), nil
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "<b>Оплаченные заказы на %s</b>\n", date.Format(entity.DataFormat))
+	currentSlot := ""
+	for _, order := range orders {
+		if order.DeliverySlot != currentSlot {
+			currentSlot = order.DeliverySlot
+			fmt.Fprintf(&builder, "\n<u>Слот: %s</u>\n", html.EscapeString(currentSlot))
+		}
+		items := make([]string, 0, len(order.Items))
+		for _, item := range order.Items {
+			items = append(items, fmt.Sprintf("%s x%d", html.EscapeString(item.FullName()), item.Count))
+		}
+		fmt.Fprintf(&builder, "№%s @%s %d.%02d руб: %s\n",
+			html.EscapeString(order.Id),
+			html.EscapeString(order.Username),
+			order.Total/100, order.Total%100,
+			strings.Join(items, ", "),
+		)
+	}
+	return builder.String(), nil
+}
+
+// nolint:gosmopolitan,mnd
+func (s UserOrder) getGroupOrderInfoString(groupOrderId string, orders []entity.AdminOrder) string {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "<b>Групповой заказ №%s</b>\n\n", html.EscapeString(groupOrderId))
+	builder.WriteString("<b>Состав заказа:</b>\n")
+
+	// the same dishes with the same options of different participants are summed up
+	items := make(entity.OrderItems, 0)
+	itemIdx := make(map[string]int)
+	var total int32
+	for _, order := range orders {
+		total += order.Total
+		for _, item := range order.Items {
+			key := fmt.Sprintf("%d:%s", item.DishId, item.OptionsString())
+			idx, ok := itemIdx[key]
+			if !ok {
+				itemIdx[key] = len(items)
+				items = append(items, item)
+				continue
+			}
+			items[idx].Count += item.Count
+		}
+	}
+	writeItemsByRestaurant(&builder, items)
+
+	builder.WriteString("<b>Участники:</b>\n")
+	for _, order := range orders {
+		fmt.Fprintf(&builder, "@%s - %d.%02d руб (заказ №%s)\n",
+			html.EscapeString(order.Username),
+			order.Total/100, order.Total%100,
+			html.EscapeString(order.Id),
+		)
+	}
+	builder.WriteString("\n")
+
+	fmt.Fprintf(&builder, "<b>Стоимость:</b> %d.%02d руб\n", total/100, total%100)
+	fmt.Fprintf(&builder, "<b>Пожелания:</b> '%s'\n", html.EscapeString(orders[0].Wishes))
+	if orders[0].DeliverySlot != "" {
+		fmt.Fprintf(&builder, "<b>Слот доставки:</b> %s\n", html.EscapeString(orders[0].DeliverySlot))
+	}
+	fmt.Fprintf(&builder, "<b>Дата:</b> %s", html.EscapeString(orders[0].CreatedAt.Local().Format(time.DateTime)))
+
+	return builder.String()
+}
+
+// nolint:mnd
+func writeItemsByRestaurant(builder *strings.Builder, orderItems entity.OrderItems) {
+	itemsByRestaurant := make(map[string][]entity.OrderItem, len(orderItems))
+	for _, item := range orderItems {
 		itemsByRestaurant[item.RestaurantName] = append(itemsByRestaurant[item.RestaurantName], item)
 	}
 
@@ -146,15 +793,70 @@ func (s UserOrder) getOrderInfoString(order *entity.Order, user *entity.User) st
 			}
 			line := fmt.Sprintf("<code>%-4d %-26s %6d</code>\n", item.DishId, name, item.Count)
 			builder.WriteString(line)
+			if len(item.Options) > 0 {
+				fmt.Fprintf(builder, "<code>     + %s</code>\n", html.EscapeString(item.OptionsString()))
+			}
 		}
 		builder.WriteString("\n")
 	}
+}
 
-	fmt.Fprintf(&builder, "<b>Имя заказавшего:</b> %s\n", html.EscapeString(user.Name))
-	fmt.Fprintf(&builder, "<b>Telegram ник:</b> @%s\n", html.EscapeString(user.Username))
-	fmt.Fprintf(&builder, "<b>Стоимость:</b> %d.%02d руб\n", order.Total/100, order.Total%100)
-	fmt.Fprintf(&builder, "<b>Пожелания:</b> '%s'\n", html.EscapeString(order.Wishes))
-	fmt.Fprintf(&builder, "<b>Дата:</b> %s", html.EscapeString(order.CreatedAt.Local().Format(time.DateTime)))
+// the report lists only the first discrepancies to fit the telegram message,
+// the full list is available through the admin endpoint
+const maxReportedDiscrepancies = 30
 
-	return builder.String()
+// nolint:gochecknoglobals
+var discrepancyDescriptions = map[string]string{
+	entity.DiscrepancyPaidWithoutPayment:   "оплаченный заказ без платежа",
+	entity.DiscrepancyCanceledOrderPayment: "платёж по отменённому заказу",
+	entity.DiscrepancyAmountMismatch:       "сумма платежа не совпадает с заказом",
+}
+
+// NotifyPaymentDiscrepancies sends the admins the payment reconciliation report for the period
+// nolint:mnd
+func (s UserOrder) NotifyPaymentDiscrepancies(
+	ctx context.Context,
+	from time.Time,
+	to time.Time,
+	discrepancies []entity.PaymentDiscrepancy,
+) error {
+	adminIds, err := s.userRepo.GetAdminsChatsIds(ctx)
+	if err != nil {
+		return errors.WithMessage(err, "get admins chats ids")
+	}
+
+	var report strings.Builder
+	fmt.Fprintf(&report, "Сверка платежей за %s - %s (UTC)\n",
+		from.Format("02.01.2006 15:04"), to.Format("02.01.2006 15:04"))
+	if len(discrepancies) == 0 {
+		report.WriteString("Расхождений не найдено")
+	} else {
+		fmt.Fprintf(&report, "Найдено расхождений: %d\n", len(discrepancies))
+	}
+	for i, discrepancy := range discrepancies {
+		if i == maxReportedDiscrepancies {
+			fmt.Fprintf(&report, "\n...и ещё %d, полный список доступен администраторам в API", len(discrepancies)-i)
+			break
+		}
+		fmt.Fprintf(&report, "\n%s\nЗаказ №%s (%s, %s), сумма: %d.%02d руб",
+			discrepancyDescriptions[discrepancy.Kind],
+			discrepancy.OrderId, discrepancy.OrderStatus, discrepancy.PaymentMethod,
+			discrepancy.OrderAmount/100, discrepancy.OrderAmount%100,
+		)
+		if discrepancy.PaymentId != "" {
+			fmt.Fprintf(&report, "\nПлатёж: %d %s, Telegram: %s, провайдер: %s",
+				discrepancy.PaymentAmount, discrepancy.Currency,
+				discrepancy.TelegramPaymentChargeId, discrepancy.ProviderPaymentChargeId,
+			)
+		}
+		report.WriteString("\n")
+	}
+
+	for _, chatId := range adminIds {
+		err = s.bot.Send(tg_bot.NewMessage(chatId, report.String()))
+		if err != nil {
+			return errors.WithMessagef(err, "send notification to chat: %d", chatId)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,26 @@
+// fake_gateway runs the local payment gateway for the development without network access,
+// the service config must point payment.gateway.baseUrl to it
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"dishes-service-backend/service/payment/gateway/fake"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:8091", "listen address")
+	secret := flag.String("secret", "", "webhook secret, must match payment.gateway.webhookSecret")
+	webhookUrl := flag.String(
+		"webhook",
+		"http://localhost:8080/api/dishes-service-backend/payments/gateway/webhook",
+		"service webhook url",
+	)
+	flag.Parse()
+
+	server := fake.NewServer(*secret, *webhookUrl)
+	log.Printf("fake payment gateway is listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, server)) // nolint:gosec
+}
@@ -24,6 +24,7 @@ type Remote struct {
 	Db       db.Config
 	Images   Images
 	Payment  Payment
+	Orders   Orders
 	Auth     Auth
 }
 
@@ -38,6 +39,20 @@ type Images struct {
 
 type Payment struct {
 	ExpirationDelayMinutes int `validate:"required,gte=1"`
+	ReminderMinutes        int `validate:"gte=0" schema:"За сколько минут до истечения срока оплаты напомнить об оплате, 0 - не напоминать"`
+	ReconciliationHourUtc  int `validate:"gte=0,lte=23" schema:"Час (UTC) ежедневной сверки платежей"`
+	Gateway                PaymentGateway
+}
+
+type PaymentGateway struct {
+	BaseUrl       string `schema:"Адрес API платёжного шлюза, способ оплаты gateway выключен если не задан"`
+	ApiKey        string `schema:"secret"`
+	WebhookSecret string `schema:"secret"`
+	ReturnUrl     string `schema:"Адрес, на который шлюз возвращает пользователя после оплаты"`
+}
+
+type Orders struct {
+	IdempotencyKeyTtlHours int `validate:"min=0" schema:"Время хранения ключей идемпотентности, по умолчанию 24 часа"`
 }
 
 type Auth struct {
@@ -0,0 +1,152 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"dishes-service-backend/domain"
+
+	"github.com/Falokut/go-kit/http/apierrors"
+)
+
+type CartService interface {
+	GetCart(ctx context.Context, userId string) (*domain.Cart, error)
+	AddItem(ctx context.Context, userId string, req domain.AddCartItemRequest) error
+	SetItemCount(ctx context.Context, userId string, req domain.SetCartItemCountRequest) error
+	RemoveItem(ctx context.Context, userId string, dishId int32) error
+	Clear(ctx context.Context, userId string) error
+	Checkout(ctx context.Context, userId string, req domain.CheckoutCartRequest) (*domain.ProcessOrderResponse, error)
+}
+
+type Cart struct {
+	service CartService
+}
+
+func NewCart(service CartService) Cart {
+	return Cart{service: service}
+}
+
+// Get cart
+//
+//	@Tags		cart
+//	@Summary	Получить корзину
+//	@Produce	json
+//	@Security	Bearer
+//	@Success	200	{object}	domain.Cart
+//	@Failure	401	{object}	apierrors.Error
+//	@Failure	500	{object}	apierrors.Error
+//	@Router		/cart [GET]
+func (c Cart) GetCart(ctx context.Context, r *http.Request) (*domain.Cart, error) {
+	return c.service.GetCart(ctx, r.Header.Get(userIdHeader))
+}
+
+// Add cart item
+//
+//	@Tags		cart
+//	@Summary	Добавить блюдо в корзину
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body	domain.AddCartItemRequest	true	"request body"
+//	@Security	Bearer
+//	@Success	200	{object}	any
+//	@Failure	400	{object}	apierrors.Error
+//	@Failure	401	{object}	apierrors.Error
+//	@Failure	404	{object}	apierrors.Error
+//	@Failure	500	{object}	apierrors.Error
+//	@Router		/cart/items [POST]
+func (c Cart) AddItem(ctx context.Context, req domain.AddCartItemRequest, r *http.Request) error {
+	err := c.service.AddItem(ctx, r.Header.Get(userIdHeader), req)
+	switch {
+	case errors.Is(err, domain.ErrDishNotFound):
+		return apierrors.New(http.StatusNotFound, domain.ErrCodeDishNotFound, domain.ErrDishNotFound.Error(), err)
+	default:
+		return err
+	}
+}
+
+// Set cart item count
+//
+//	@Tags		cart
+//	@Summary	Изменить количество блюда в корзине
+//	@Accept		json
+//	@Produce	json
+//	@Param		id		path	int								true	"идентификатор блюда"
+//	@Param		body	body	domain.SetCartItemCountRequest	true	"request body"
+//	@Security	Bearer
+//	@Success	200	{object}	any
+//	@Failure	400	{object}	apierrors.Error
+//	@Failure	401	{object}	apierrors.Error
+//	@Failure	404	{object}	apierrors.Error
+//	@Failure	500	{object}	apierrors.Error
+//	@Router		/cart/items/{id} [POST]
+func (c Cart) SetItemCount(ctx context.Context, req domain.SetCartItemCountRequest, r *http.Request) error {
+	err := c.service.SetItemCount(ctx, r.Header.Get(userIdHeader), req)
+	switch {
+	case errors.Is(err, domain.ErrDishNotFound):
+		return apierrors.New(http.StatusNotFound, domain.ErrCodeDishNotFound, domain.ErrDishNotFound.Error(), err)
+	case errors.Is(err, domain.ErrCartItemNotFound):
+		return apierrors.New(http.StatusNotFound, domain.ErrCodeCartItemNotFound, domain.ErrCartItemNotFound.Error(), err)
+	default:
+		return err
+	}
+}
+
+// Remove cart item
+//
+//	@Tags		cart
+//	@Summary	Удалить блюдо из корзины
+//	@Produce	json
+//	@Param		id	path	int	true	"идентификатор блюда"
+//	@Security	Bearer
+//	@Success	200	{object}	any
+//	@Failure	400	{object}	apierrors.Error
+//	@Failure	401	{object}	apierrors.Error
+//	@Failure	500	{object}	apierrors.Error
+//	@Router		/cart/items/{id} [DELETE]
+func (c Cart) RemoveItem(ctx context.Context, req domain.RemoveCartItemRequest, r *http.Request) error {
+	return c.service.RemoveItem(ctx, r.Header.Get(userIdHeader), req.Id)
+}
+
+// Clear cart
+//
+//	@Tags		cart
+//	@Summary	Очистить корзину
+//	@Produce	json
+//	@Security	Bearer
+//	@Success	200	{object}	any
+//	@Failure	401	{object}	apierrors.Error
+//	@Failure	500	{object}	apierrors.Error
+//	@Router		/cart [DELETE]
+func (c Cart) Clear(ctx context.Context, r *http.Request) error {
+	return c.service.Clear(ctx, r.Header.Get(userIdHeader))
+}
+
+// Checkout cart
+//
+//	@Tags			cart
+//	@Summary		Оформить заказ из корзины
+//	@Description	цены и наличие блюд проверяются повторно, после оформления корзина очищается
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body	domain.CheckoutCartRequest	true	"request body"
+//	@Security		Bearer
+//	@Success		200	{object}	domain.ProcessOrderResponse
+//	@Failure		400	{object}	apierrors.Error
+//	@Failure		401	{object}	apierrors.Error
+//	@Failure		404	{object}	apierrors.Error
+//	@Failure		500	{object}	apierrors.Error
+//	@Router			/cart/checkout [POST]
+func (c Cart) Checkout(ctx context.Context, req domain.CheckoutCartRequest, r *http.Request) (*domain.ProcessOrderResponse, error) {
+	resp, err := c.service.Checkout(ctx, r.Header.Get(userIdHeader), req)
+	switch {
+	case errors.Is(err, domain.ErrCartEmpty):
+		return nil, apierrors.NewBusinessError(domain.ErrCodeCartEmpty, domain.ErrCartEmpty.Error(), err)
+	case errors.Is(err, domain.ErrCartPricesChanged):
+		return nil, apierrors.NewBusinessError(domain.ErrCodeCartPricesChanged, domain.ErrCartPricesChanged.Error(), err)
+	case err != nil:
+		return nil, createOrderError(err)
+	default:
+		return resp, nil
+	}
+}
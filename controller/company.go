@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/Falokut/go-kit/http/apierrors"
+
+	"dishes-service-backend/domain"
+)
+
+type CompanyService interface {
+	GetCompanies(ctx context.Context) ([]domain.Company, error)
+	AddCompany(ctx context.Context, req domain.AddCompanyRequest) (int32, error)
+	EditCompany(ctx context.Context, req domain.EditCompanyRequest) error
+	AddCompanyUser(ctx context.Context, req domain.AddCompanyUserRequest) error
+	DeleteCompanyUser(ctx context.Context, userId string) error
+	GetStatement(ctx context.Context, companyId int32, month string) (*domain.CompanyStatement, error)
+}
+
+type Company struct {
+	service CompanyService
+}
+
+func NewCompany(service CompanyService) Company {
+	return Company{
+		service: service,
+	}
+}
+
+// Get companies
+//
+//	@Tags		companies
+//	@Summary	Получить компании
+//	@Produce	json
+//	@Security	Bearer
+//	@Success	200	{array}		domain.Company
+//	@Failure	403	{object}	apierrors.Error
+//	@Failure	500	{object}	apierrors.Error
+//	@Router		/companies [GET]
+func (c Company) GetCompanies(ctx context.Context) ([]domain.Company, error) {
+	return c.service.GetCompanies(ctx)
+}
+
+// Add company
+//
+//	@Tags			companies
+//	@Summary		Добавить компанию
+//	@Description	заказы сотрудников с оплатой corporate списываются на счёт компании
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body	domain.AddCompanyRequest	true	"request body"
+//	@Security		Bearer
+//	@Success		200	{object}	domain.AddCompanyResponse
+//	@Failure		400	{object}	apierrors.Error
+//	@Failure		403	{object}	apierrors.Error
+//	@Failure		409	{object}	apierrors.Error
+//	@Failure		500	{object}	apierrors.Error
+//	@Router			/companies [POST]
+func (c Company) AddCompany(ctx context.Context, req domain.AddCompanyRequest) (*domain.AddCompanyResponse, error) {
+	id, err := c.service.AddCompany(ctx, req)
+	switch {
+	case errors.Is(err, domain.ErrCompanyConflict):
+		return nil, apierrors.New(http.StatusConflict, domain.ErrCodeCompanyConflict, domain.ErrCompanyConflict.Error(), err)
+	case err != nil:
+		return nil, err
+	default:
+		return &domain.AddCompanyResponse{Id: id}, nil
+	}
+}
+
+// Edit company
+//
+//	@Tags		companies
+//	@Summary	Изменить компанию
+//	@Accept		json
+//	@Produce	json
+//	@Param		id		path	int							true	"идентификатор компании"
+//	@Param		body	body	domain.EditCompanyRequest	true	"request body"
+//	@Security	Bearer
+//	@Success	200	{object}	any
+//	@Failure	400	{object}	apierrors.Error
+//	@Failure	403	{object}	apierrors.Error
+//	@Failure	404	{object}	apierrors.Error
+//	@Failure	409	{object}	apierrors.Error
+//	@Failure	500	{object}	apierrors.Error
+//	@Router		/companies/{id} [POST]
+func (c Company) EditCompany(ctx context.Context, req domain.EditCompanyRequest) error {
+	err := c.service.EditCompany(ctx, req)
+	switch {
+	case errors.Is(err, domain.ErrCompanyNotFound):
+		return apierrors.New(http.StatusNotFound, domain.ErrCodeCompanyNotFound, domain.ErrCompanyNotFound.Error(), err)
+	case errors.Is(err, domain.ErrCompanyConflict):
+		return apierrors.New(http.StatusConflict, domain.ErrCodeCompanyConflict, domain.ErrCompanyConflict.Error(), err)
+	default:
+		return err
+	}
+}
+
+// Add company user
+//
+//	@Tags			companies
+//	@Summary		Привязать пользователя к компании
+//	@Description	пользователь может быть привязан только к одной компании, прежняя привязка заменяется
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path	int								true	"идентификатор компании"
+//	@Param			body	body	domain.AddCompanyUserRequest	true	"request body"
+//	@Security		Bearer
+//	@Success		200	{object}	any
+//	@Failure		400	{object}	apierrors.Error
+//	@Failure		403	{object}	apierrors.Error
+//	@Failure		404	{object}	apierrors.Error
+//	@Failure		500	{object}	apierrors.Error
+//	@Router			/companies/{id}/users [POST]
+func (c Company) AddCompanyUser(ctx context.Context, req domain.AddCompanyUserRequest) error {
+	err := c.service.AddCompanyUser(ctx, req)
+	switch {
+	case errors.Is(err, domain.ErrCompanyNotFound):
+		return apierrors.New(http.StatusNotFound, domain.ErrCodeCompanyNotFound, domain.ErrCompanyNotFound.Error(), err)
+	case errors.Is(err, domain.ErrUserNotFound):
+		return apierrors.New(http.StatusNotFound, domain.ErrCodeUserNotFound, domain.ErrUserNotFound.Error(), err)
+	default:
+		return err
+	}
+}
+
+// Delete company user
+//
+//	@Tags		companies
+//	@Summary	Отвязать пользователя от компании
+//	@Produce	json
+//	@Param		id	path	string	true	"идентификатор пользователя"
+//	@Security	Bearer
+//	@Success	200	{object}	any
+//	@Failure	400	{object}	apierrors.Error
+//	@Failure	403	{object}	apierrors.Error
+//	@Failure	500	{object}	apierrors.Error
+//	@Router		/companies/users/{id} [DELETE]
+func (c Company) DeleteCompanyUser(ctx context.Context, req domain.DeleteCompanyUserRequest) error {
+	return c.service.DeleteCompanyUser(ctx, req.Id)
+}
+
+// Get company statement
+//
+//	@Tags			companies
+//	@Summary		Получить выписку компании за месяц
+//	@Description	оплаченные со счёта компании заказы, файл выписки в csv или xlsx можно получить через бота
+//	@Produce		json
+//	@Param			id		path	int		true	"идентификатор компании"
+//	@Param			month	query	string	true	"месяц в формате гггг.мм"
+//	@Security		Bearer
+//	@Success		200	{object}	domain.CompanyStatement
+//	@Failure		400	{object}	apierrors.Error
+//	@Failure		403	{object}	apierrors.Error
+//	@Failure		404	{object}	apierrors.Error
+//	@Failure		500	{object}	apierrors.Error
+//	@Router			/companies/{id}/statement [GET]
+func (c Company) GetStatement(ctx context.Context, req domain.GetCompanyStatementRequest) (*domain.CompanyStatement, error) {
+	statement, err := c.service.GetStatement(ctx, req.Id, req.Month)
+	switch {
+	case errors.Is(err, domain.ErrCompanyNotFound):
+		return nil, apierrors.New(http.StatusNotFound, domain.ErrCodeCompanyNotFound, domain.ErrCompanyNotFound.Error(), err)
+	default:
+		return statement, err
+	}
+}
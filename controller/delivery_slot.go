@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"dishes-service-backend/domain"
+
+	"github.com/Falokut/go-kit/http/apierrors"
+)
+
+type DeliverySlotService interface {
+	GetDeliverySlots(ctx context.Context, req domain.GetDeliverySlotsRequest) ([]domain.DeliverySlot, error)
+	AddDeliverySlot(ctx context.Context, req domain.AddDeliverySlotRequest) (int32, error)
+	EditDeliverySlot(ctx context.Context, req domain.EditDeliverySlotRequest) error
+	DeleteDeliverySlot(ctx context.Context, id int32) error
+}
+
+type DeliverySlot struct {
+	service DeliverySlotService
+}
+
+func NewDeliverySlot(service DeliverySlotService) DeliverySlot {
+	return DeliverySlot{service: service}
+}
+
+// Get delivery slots
+//
+//	@Tags		delivery_slots
+//	@Summary	Получить слоты доставки на дату
+//	@Produce	json
+//	@Param		date	query		string	true	"дата в формате гггг-мм-дд"
+//	@Success	200		{array}		domain.DeliverySlot
+//	@Failure	400		{object}	apierrors.Error
+//	@Failure	500		{object}	apierrors.Error
+//	@Router		/delivery_slots [GET]
+func (c DeliverySlot) GetDeliverySlots(ctx context.Context, req domain.GetDeliverySlotsRequest) ([]domain.DeliverySlot, error) {
+	return c.service.GetDeliverySlots(ctx, req)
+}
+
+// Add delivery slot
+//
+//	@Tags		delivery_slots
+//	@Summary	Создать слот доставки
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body	domain.AddDeliverySlotRequest	true	"request body"
+//	@Security	Bearer
+//	@Success	200	{object}	domain.AddDeliverySlotResponse
+//	@Failure	400	{object}	apierrors.Error
+//	@Failure	403	{object}	apierrors.Error
+//	@Failure	409	{object}	apierrors.Error
+//	@Failure	500	{object}	apierrors.Error
+//	@Router		/delivery_slots [POST]
+func (c DeliverySlot) AddDeliverySlot(ctx context.Context, req domain.AddDeliverySlotRequest) (*domain.AddDeliverySlotResponse, error) {
+	id, err := c.service.AddDeliverySlot(ctx, req)
+	switch {
+	case errors.Is(err, domain.ErrDeliverySlotConflict):
+		return nil, apierrors.New(http.StatusConflict,
+			domain.ErrCodeDeliverySlotConflict,
+			domain.ErrDeliverySlotConflict.Error(),
+			err,
+		)
+	case err != nil:
+		return nil, err
+	default:
+		return &domain.AddDeliverySlotResponse{Id: id}, nil
+	}
+}
+
+// Edit delivery slot
+//
+//	@Tags		delivery_slots
+//	@Summary	Изменить вместимость слота доставки
+//	@Accept		json
+//	@Produce	json
+//	@Param		id		path	int								true	"идентификатор слота"
+//	@Param		body	body	domain.EditDeliverySlotRequest	true	"request body"
+//	@Security	Bearer
+//	@Success	200	{object}	any
+//	@Failure	400	{object}	apierrors.Error
+//	@Failure	403	{object}	apierrors.Error
+//	@Failure	404	{object}	apierrors.Error
+//	@Failure	500	{object}	apierrors.Error
+//	@Router		/delivery_slots/{id} [POST]
+func (c DeliverySlot) EditDeliverySlot(ctx context.Context, req domain.EditDeliverySlotRequest) error {
+	err := c.service.EditDeliverySlot(ctx, req)
+	switch {
+	case errors.Is(err, domain.ErrDeliverySlotNotFound):
+		return apierrors.New(http.StatusNotFound,
+			domain.ErrCodeDeliverySlotNotFound,
+			domain.ErrDeliverySlotNotFound.Error(),
+			err,
+		)
+	default:
+		return err
+	}
+}
+
+// Delete delivery slot
+//
+//	@Tags		delivery_slots
+//	@Summary	Удалить слот доставки
+//	@Produce	json
+//	@Param		id	path	int	true	"идентификатор слота"
+//	@Security	Bearer
+//	@Success	200	{object}	any
+//	@Failure	400	{object}	apierrors.Error
+//	@Failure	403	{object}	apierrors.Error
+//	@Failure	500	{object}	apierrors.Error
+//	@Router		/delivery_slots/{id} [DELETE]
+func (c DeliverySlot) DeleteDeliverySlot(ctx context.Context, req domain.DeleteDeliverySlotRequest) error {
+	return c.service.DeleteDeliverySlot(ctx, req.Id)
+}
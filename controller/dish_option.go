@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"dishes-service-backend/domain"
+
+	"github.com/Falokut/go-kit/http/apierrors"
+)
+
+type DishOptionService interface {
+	GetDishOptions(ctx context.Context, dishId int32) ([]domain.DishOptionGroup, error)
+	AddDishOptionGroup(ctx context.Context, req domain.AddDishOptionGroupRequest) (int32, error)
+	DeleteDishOptionGroup(ctx context.Context, id int32) error
+}
+
+type DishOption struct {
+	service DishOptionService
+}
+
+func NewDishOption(service DishOptionService) DishOption {
+	return DishOption{service: service}
+}
+
+// Get dish options
+//
+//	@Tags		dishes
+//	@Summary	Получить группы опций блюда
+//	@Produce	json
+//	@Param		id	path		int	true	"идентификатор блюда"
+//	@Success	200	{array}		domain.DishOptionGroup
+//	@Failure	400	{object}	apierrors.Error
+//	@Failure	500	{object}	apierrors.Error
+//	@Router		/dishes/options/{id} [GET]
+func (c DishOption) GetDishOptions(ctx context.Context, req domain.GetDishOptionsRequest) ([]domain.DishOptionGroup, error) {
+	return c.service.GetDishOptions(ctx, req.Id)
+}
+
+// Add dish option group
+//
+//	@Tags			dishes
+//	@Summary		Добавить группу опций блюда
+//	@Description	например размер порции или соус, цена опции прибавляется к цене блюда
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path	int									true	"идентификатор блюда"
+//	@Param			body	body	domain.AddDishOptionGroupRequest	true	"request body"
+//	@Security		Bearer
+//	@Success		200	{object}	domain.AddDishOptionGroupResponse
+//	@Failure		400	{object}	apierrors.Error
+//	@Failure		403	{object}	apierrors.Error
+//	@Failure		404	{object}	apierrors.Error
+//	@Failure		500	{object}	apierrors.Error
+//	@Router			/dishes/options/{id} [POST]
+func (c DishOption) AddDishOptionGroup(
+	ctx context.Context,
+	req domain.AddDishOptionGroupRequest,
+) (*domain.AddDishOptionGroupResponse, error) {
+	id, err := c.service.AddDishOptionGroup(ctx, req)
+	switch {
+	case errors.Is(err, domain.ErrDishNotFound):
+		return nil, apierrors.New(http.StatusNotFound, domain.ErrCodeDishNotFound, domain.ErrDishNotFound.Error(), err)
+	case err != nil:
+		return nil, err
+	default:
+		return &domain.AddDishOptionGroupResponse{Id: id}, nil
+	}
+}
+
+// Delete dish option group
+//
+//	@Tags		dishes
+//	@Summary	Удалить группу опций блюда
+//	@Produce	json
+//	@Param		id	path	int	true	"идентификатор группы опций"
+//	@Security	Bearer
+//	@Success	200	{object}	any
+//	@Failure	400	{object}	apierrors.Error
+//	@Failure	403	{object}	apierrors.Error
+//	@Failure	500	{object}	apierrors.Error
+//	@Router		/dishes/option_groups/{id} [DELETE]
+func (c DishOption) DeleteDishOptionGroup(ctx context.Context, req domain.DeleteDishOptionGroupRequest) error {
+	return c.service.DeleteDishOptionGroup(ctx, req.Id)
+}
@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"dishes-service-backend/domain"
+
+	"github.com/Falokut/go-kit/http/apierrors"
+)
+
+type GroupOrderService interface {
+	CreateGroupOrder(ctx context.Context, userId string, req domain.CreateGroupOrderRequest) (string, error)
+	GetGroupOrder(ctx context.Context, id string) (*domain.GroupOrder, error)
+	SetGroupOrderItems(ctx context.Context, userId string, req domain.SetGroupOrderItemsRequest) error
+	LockGroupOrder(ctx context.Context, userId string, req domain.LockGroupOrderRequest) (*domain.LockGroupOrderResponse, error)
+}
+
+type GroupOrder struct {
+	service GroupOrderService
+}
+
+func NewGroupOrder(service GroupOrderService) GroupOrder {
+	return GroupOrder{service: service}
+}
+
+// Create group order
+//
+//	@Tags			group_order
+//	@Summary		Создать групповой заказ
+//	@Description	идентификатор заказа передаётся коллегам, чтобы они добавили свои блюда
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body	domain.CreateGroupOrderRequest	true	"request body"
+//	@Security		Bearer
+//	@Success		200	{object}	domain.CreateGroupOrderResponse
+//	@Failure		400	{object}	apierrors.Error
+//	@Failure		401	{object}	apierrors.Error
+//	@Failure		500	{object}	apierrors.Error
+//	@Router			/group_orders [POST]
+func (c GroupOrder) CreateGroupOrder(
+	ctx context.Context,
+	req domain.CreateGroupOrderRequest,
+	r *http.Request,
+) (*domain.CreateGroupOrderResponse, error) {
+	id, err := c.service.CreateGroupOrder(ctx, r.Header.Get(userIdHeader), req)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.CreateGroupOrderResponse{Id: id}, nil
+}
+
+// Get group order
+//
+//	@Tags		group_order
+//	@Summary	Получить групповой заказ
+//	@Produce	json
+//	@Param		id	path	string	true	"идентификатор группового заказа"
+//	@Security	Bearer
+//	@Success	200	{object}	domain.GroupOrder
+//	@Failure	400	{object}	apierrors.Error
+//	@Failure	401	{object}	apierrors.Error
+//	@Failure	404	{object}	apierrors.Error
+//	@Failure	500	{object}	apierrors.Error
+//	@Router		/group_orders/{id} [GET]
+func (c GroupOrder) GetGroupOrder(ctx context.Context, req domain.GetGroupOrderRequest) (*domain.GroupOrder, error) {
+	order, err := c.service.GetGroupOrder(ctx, req.Id)
+	switch {
+	case errors.Is(err, domain.ErrGroupOrderNotFound):
+		return nil, apierrors.New(http.StatusNotFound, domain.ErrCodeGroupOrderNotFound, domain.ErrGroupOrderNotFound.Error(), err)
+	default:
+		return order, err
+	}
+}
+
+// Set group order items
+//
+//	@Tags			group_order
+//	@Summary		Задать свои блюда в групповом заказе
+//	@Description	заменяет блюда текущего пользователя, пустой список удаляет его из заказа
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path	string								true	"идентификатор группового заказа"
+//	@Param			body	body	domain.SetGroupOrderItemsRequest	true	"request body"
+//	@Security		Bearer
+//	@Success		200	{object}	any
+//	@Failure		400	{object}	apierrors.Error
+//	@Failure		401	{object}	apierrors.Error
+//	@Failure		404	{object}	apierrors.Error
+//	@Failure		500	{object}	apierrors.Error
+//	@Router			/group_orders/{id}/items [POST]
+func (c GroupOrder) SetGroupOrderItems(ctx context.Context, req domain.SetGroupOrderItemsRequest, r *http.Request) error {
+	err := c.service.SetGroupOrderItems(ctx, r.Header.Get(userIdHeader), req)
+	switch {
+	case errors.Is(err, domain.ErrGroupOrderNotFound):
+		return apierrors.New(http.StatusNotFound, domain.ErrCodeGroupOrderNotFound, domain.ErrGroupOrderNotFound.Error(), err)
+	case errors.Is(err, domain.ErrGroupOrderLocked):
+		return apierrors.NewBusinessError(domain.ErrCodeGroupOrderLocked, domain.ErrGroupOrderLocked.Error(), err)
+	case errors.Is(err, domain.ErrDishNotFound):
+		return apierrors.New(http.StatusNotFound, domain.ErrCodeDishNotFound, domain.ErrDishNotFound.Error(), err)
+	case errors.Is(err, domain.ErrInvalidDishCount):
+		return apierrors.NewBusinessError(domain.ErrCodeInvalidDishCount, domain.ErrInvalidDishCount.Error(), err)
+	default:
+		return err
+	}
+}
+
+// Lock group order
+//
+//	@Tags			group_order
+//	@Summary		Закрыть групповой заказ
+//	@Description	доступно только организатору, создаёт заказы на оплату для участников или для организатора
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path	string							true	"идентификатор группового заказа"
+//	@Param			body	body	domain.LockGroupOrderRequest	true	"request body"
+//	@Security		Bearer
+//	@Success		200	{object}	domain.LockGroupOrderResponse
+//	@Failure		400	{object}	apierrors.Error
+//	@Failure		401	{object}	apierrors.Error
+//	@Failure		403	{object}	apierrors.Error
+//	@Failure		404	{object}	apierrors.Error
+//	@Failure		500	{object}	apierrors.Error
+//	@Router			/group_orders/{id}/lock [POST]
+func (c GroupOrder) LockGroupOrder(
+	ctx context.Context,
+	req domain.LockGroupOrderRequest,
+	r *http.Request,
+) (*domain.LockGroupOrderResponse, error) {
+	resp, err := c.service.LockGroupOrder(ctx, r.Header.Get(userIdHeader), req)
+	switch {
+	case errors.Is(err, domain.ErrGroupOrderNotFound):
+		return nil, apierrors.New(http.StatusNotFound, domain.ErrCodeGroupOrderNotFound, domain.ErrGroupOrderNotFound.Error(), err)
+	case errors.Is(err, domain.ErrForbidden):
+		return nil, apierrors.New(http.StatusForbidden, domain.ErrCodeForbidden, domain.ErrForbidden.Error(), err)
+	case errors.Is(err, domain.ErrGroupOrderLocked):
+		return nil, apierrors.NewBusinessError(domain.ErrCodeGroupOrderLocked, domain.ErrGroupOrderLocked.Error(), err)
+	case errors.Is(err, domain.ErrGroupOrderEmpty):
+		return nil, apierrors.NewBusinessError(domain.ErrCodeGroupOrderEmpty, domain.ErrGroupOrderEmpty.Error(), err)
+	case err != nil:
+		return nil, createOrderError(err)
+	default:
+		return resp, nil
+	}
+}
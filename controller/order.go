@@ -8,15 +8,30 @@ import (
 	"github.com/Falokut/go-kit/http/apierrors"
 
 	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
 )
 
 const (
-	userIdHeader = "X-User-Id"
+	userIdHeader            = "X-User-Id"
+	maxIdempotencyKeyLength = 255
 )
 
 type OrderService interface {
-	ProcessOrder(ctx context.Context, userId string, req domain.ProcessOrderRequest) (string, error)
+	ProcessOrder(
+		ctx context.Context,
+		userId string,
+		idempotencyKey string,
+		req domain.ProcessOrderRequest,
+	) (*domain.ProcessOrderResponse, error)
 	GetUserOrders(ctx context.Context, userId string, req domain.GetMyOrdersRequest) ([]domain.UserOrder, error)
+	GetUserOrder(ctx context.Context, authInfo entity.UserAuthInfo, orderId string) (*domain.UserOrder, error)
+	CancelOrder(ctx context.Context, userId string, orderId string) error
+	RepeatOrder(ctx context.Context, userId string, req domain.RepeatOrderRequest) (*domain.RepeatOrderResponse, error)
+	ListOrders(ctx context.Context, req domain.GetOrdersRequest) (*domain.GetOrdersResponse, error)
+	ChangeOrderStatus(ctx context.Context, adminId string, req domain.SetOrderStatusRequest) error
+	CancelOrderItems(ctx context.Context, adminId string, req domain.CancelOrderItemsRequest) (*domain.CancelOrderItemsResponse, error)
+	SetFulfilmentStatus(ctx context.Context, adminId string, req domain.SetFulfilmentStatusRequest) error
+	GetOrderStatusHistory(ctx context.Context, orderId string) ([]domain.OrderStatusHistory, error)
 }
 
 type Order struct {
@@ -36,23 +51,63 @@ func NewOrder(service OrderService) Order {
 //	@Accept		json
 //	@Produce	json
 //	@Security	Bearer
-//	@Param		body	body		domain.ProcessOrderRequest	true	"request body"
-//	@Success	200		{object}	domain.ProcessOrderResponse
-//	@Failure	400		{object}	apierrors.Error
-//	@Failure	404		{object}	apierrors.Error
-//	@Failure	500		{object}	apierrors.Error
+//	@Param		Idempotency-Key	header		string						false	"ключ идемпотентности"
+//	@Param		body			body		domain.ProcessOrderRequest	true	"request body"
+//	@Success	200				{object}	domain.ProcessOrderResponse
+//	@Failure	400				{object}	apierrors.Error
+//	@Failure	404				{object}	apierrors.Error
+//	@Failure	409				{object}	apierrors.Error
+//	@Failure	500				{object}	apierrors.Error
 //	@Router		/orders [POST]
 func (c Order) ProcessOrder(ctx context.Context, r *http.Request, req domain.ProcessOrderRequest) (*domain.ProcessOrderResponse, error) {
-	url, err := c.service.ProcessOrder(ctx, r.Header.Get(userIdHeader), req)
+	idempotencyKey := r.Header.Get(domain.IdempotencyKeyHeader)
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		return nil, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument, "invalid idempotency key", errors.New("idempotency key is too long"))
+	}
+	resp, err := c.service.ProcessOrder(ctx, r.Header.Get(userIdHeader), idempotencyKey, req)
+	switch {
+	case errors.Is(err, domain.ErrIdempotencyKeyConflict):
+		return nil, apierrors.New(http.StatusConflict, domain.ErrCodeIdempotencyConflict, domain.ErrIdempotencyKeyConflict.Error(), err)
+	case err != nil:
+		return nil, createOrderError(err)
+	default:
+		return resp, nil
+	}
+}
+
+// createOrderError maps the errors of the order creation shared by all ordering endpoints
+func createOrderError(err error) error {
 	switch {
 	case errors.Is(err, domain.ErrDishNotFound):
-		return nil, apierrors.New(http.StatusNotFound, domain.ErrCodeDishNotFound, domain.ErrDishNotFound.Error(), err)
+		return apierrors.New(http.StatusNotFound, domain.ErrCodeDishNotFound, domain.ErrDishNotFound.Error(), err)
 	case errors.Is(err, domain.ErrInvalidDishCount):
-		return nil, apierrors.NewBusinessError(domain.ErrCodeInvalidDishCount, domain.ErrInvalidDishCount.Error(), err)
-	case err != nil:
-		return nil, err
+		return apierrors.NewBusinessError(domain.ErrCodeInvalidDishCount, domain.ErrInvalidDishCount.Error(), err)
+	case errors.Is(err, domain.ErrDeliverySlotNotFound):
+		return apierrors.New(http.StatusNotFound, domain.ErrCodeDeliverySlotNotFound, domain.ErrDeliverySlotNotFound.Error(), err)
+	case errors.Is(err, domain.ErrDeliverySlotFull):
+		return apierrors.NewBusinessError(domain.ErrCodeDeliverySlotFull, domain.ErrDeliverySlotFull.Error(), err)
+	case errors.Is(err, domain.ErrDeliverySlotExpired):
+		return apierrors.NewBusinessError(domain.ErrCodeDeliverySlotExpired, domain.ErrDeliverySlotExpired.Error(), err)
+	case errors.Is(err, domain.ErrPromoCodeNotFound):
+		return apierrors.New(http.StatusNotFound, domain.ErrCodePromoCodeNotFound, domain.ErrPromoCodeNotFound.Error(), err)
+	case errors.Is(err, domain.ErrPromoCodeInactive):
+		return apierrors.NewBusinessError(domain.ErrCodePromoCodeInactive, domain.ErrPromoCodeInactive.Error(), err)
+	case errors.Is(err, domain.ErrPromoCodeLimitExceeded):
+		return apierrors.NewBusinessError(domain.ErrCodePromoCodeLimitExceeded, domain.ErrPromoCodeLimitExceeded.Error(), err)
+	case errors.Is(err, domain.ErrPromoCodeNotApplicable):
+		return apierrors.NewBusinessError(domain.ErrCodePromoCodeNotApplicable, domain.ErrPromoCodeNotApplicable.Error(), err)
+	case errors.Is(err, domain.ErrUserCompanyNotFound):
+		return apierrors.NewBusinessError(domain.ErrCodeUserCompanyNotFound, domain.ErrUserCompanyNotFound.Error(), err)
+	case errors.Is(err, domain.ErrCreditLimitExceeded):
+		return apierrors.NewBusinessError(domain.ErrCodeCreditLimitExceeded, domain.ErrCreditLimitExceeded.Error(), err)
+	case errors.Is(err, domain.ErrInsufficientFunds):
+		return apierrors.NewBusinessError(domain.ErrCodeInsufficientFunds, domain.ErrInsufficientFunds.Error(), err)
+	case errors.Is(err, domain.ErrStarsRateNotSet):
+		return apierrors.NewBusinessError(domain.ErrCodeStarsRateNotSet, domain.ErrStarsRateNotSet.Error(), err)
+	case errors.Is(err, domain.ErrInvalidPaymentMethod):
+		return apierrors.NewBusinessError(domain.ErrCodeInvalidArgument, domain.ErrInvalidPaymentMethod.Error(), err)
 	default:
-		return &domain.ProcessOrderResponse{PaymentUrl: url}, nil
+		return err
 	}
 }
 
@@ -76,3 +131,227 @@ func (c Order) GetUserOrders(ctx context.Context,
 ) ([]domain.UserOrder, error) {
 	return c.service.GetUserOrders(ctx, r.Header.Get(userIdHeader), req)
 }
+
+// Get order
+//
+//	@Tags		order
+//	@Summary	Получить заказ
+//	@Produce	json
+//	@Param		id	path	string	true	"идентификатор заказа"
+//	@Security	Bearer
+//	@Success	200	{object}	domain.UserOrder
+//	@Failure	400	{object}	apierrors.Error
+//	@Failure	403	{object}	apierrors.Error
+//	@Failure	404	{object}	apierrors.Error
+//	@Failure	500	{object}	apierrors.Error
+//	@Router		/orders/details/{id} [GET]
+func (c Order) GetOrder(ctx context.Context, req domain.GetOrderRequest, r *http.Request) (*domain.UserOrder, error) {
+	order, err := c.service.GetUserOrder(ctx, getUserAuthInfo(r), req.Id)
+	switch {
+	case errors.Is(err, domain.ErrOrderNotFound):
+		return nil, apierrors.New(http.StatusNotFound, domain.ErrCodeOrderNotFound, domain.ErrOrderNotFound.Error(), err)
+	case errors.Is(err, domain.ErrForbidden):
+		return nil, apierrors.New(http.StatusForbidden, domain.ErrCodeForbidden, domain.ErrForbidden.Error(), err)
+	default:
+		return order, err
+	}
+}
+
+// Cancel order
+//
+//	@Tags		order
+//	@Summary	Отменить неоплаченный заказ
+//	@Produce	json
+//	@Param		id	path	string	true	"идентификатор заказа"
+//	@Security	Bearer
+//	@Success	200	{object}	any
+//	@Failure	400	{object}	apierrors.Error
+//	@Failure	403	{object}	apierrors.Error
+//	@Failure	404	{object}	apierrors.Error
+//	@Failure	500	{object}	apierrors.Error
+//	@Router		/orders/{id}/cancel [POST]
+func (c Order) CancelOrder(ctx context.Context, req domain.CancelOrderRequest, r *http.Request) error {
+	err := c.service.CancelOrder(ctx, r.Header.Get(userIdHeader), req.Id)
+	switch {
+	case errors.Is(err, domain.ErrOrderNotFound):
+		return apierrors.New(http.StatusNotFound, domain.ErrCodeOrderNotFound, domain.ErrOrderNotFound.Error(), err)
+	case errors.Is(err, domain.ErrForbidden):
+		return apierrors.New(http.StatusForbidden, domain.ErrCodeForbidden, domain.ErrForbidden.Error(), err)
+	case errors.Is(err, domain.ErrOrderCancelForbidden):
+		return apierrors.NewBusinessError(domain.ErrCodeOrderCancelForbidden, domain.ErrOrderCancelForbidden.Error(), err)
+	default:
+		return err
+	}
+}
+
+// Repeat order
+//
+//	@Tags			order
+//	@Summary		Повторить заказ
+//	@Description	создаёт новый заказ из блюд прошлого заказа по текущим ценам, отсутствующие блюда возвращаются в MissingDishes
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string						true	"идентификатор заказа"
+//	@Param			body	body		domain.RepeatOrderRequest	true	"request body"
+//	@Security		Bearer
+//	@Success		200		{object}	domain.RepeatOrderResponse
+//	@Failure		400		{object}	apierrors.Error
+//	@Failure		403		{object}	apierrors.Error
+//	@Failure		404		{object}	apierrors.Error
+//	@Failure		500		{object}	apierrors.Error
+//	@Router			/orders/{id}/repeat [POST]
+func (c Order) RepeatOrder(ctx context.Context, req domain.RepeatOrderRequest, r *http.Request) (*domain.RepeatOrderResponse, error) {
+	resp, err := c.service.RepeatOrder(ctx, r.Header.Get(userIdHeader), req)
+	switch {
+	case errors.Is(err, domain.ErrOrderNotFound):
+		return nil, apierrors.New(http.StatusNotFound, domain.ErrCodeOrderNotFound, domain.ErrOrderNotFound.Error(), err)
+	case errors.Is(err, domain.ErrForbidden):
+		return nil, apierrors.New(http.StatusForbidden, domain.ErrCodeForbidden, domain.ErrForbidden.Error(), err)
+	case errors.Is(err, domain.ErrRepeatOrderEmpty):
+		return nil, apierrors.NewBusinessError(domain.ErrCodeRepeatOrderEmpty, domain.ErrRepeatOrderEmpty.Error(), err)
+	case err != nil:
+		return nil, createOrderError(err)
+	default:
+		return resp, nil
+	}
+}
+
+// List orders
+//
+//	@Tags		order
+//	@Summary	Получить список заказов
+//	@Produce	json
+//	@Param		status			query	string	false	"статус заказа"
+//	@Param		createdFrom		query	string	false	"начало периода в формате RFC3339"
+//	@Param		createdTo		query	string	false	"конец периода в формате RFC3339"
+//	@Param		restaurantId	query	int		false	"идентификатор ресторана"
+//	@Param		groupOrderId	query	string	false	"идентификатор группового заказа"
+//	@Param		userId			query	string	false	"идентификатор пользователя"
+//	@Param		username		query	string	false	"telegram ник пользователя"
+//	@Param		paymentMethod	query	string	false	"способ оплаты"
+//	@Param		sortBy			query	string	false	"поле сортировки: createdAt, total, status"
+//	@Param		sortOrder		query	string	false	"направление сортировки: asc, desc"
+//	@Param		limit			query	int		false	"максимальное количество заказов"
+//	@Param		offset			query	int		false	"смещение"
+//	@Security	Bearer
+//	@Success	200	{object}	domain.GetOrdersResponse
+//	@Failure	400	{object}	apierrors.Error
+//	@Failure	403	{object}	apierrors.Error
+//	@Failure	500	{object}	apierrors.Error
+//	@Router		/orders [GET]
+func (c Order) ListOrders(ctx context.Context, req domain.GetOrdersRequest) (*domain.GetOrdersResponse, error) {
+	return c.service.ListOrders(ctx, req)
+}
+
+// Change order status
+//
+//	@Tags		order
+//	@Summary	Изменить статус заказа
+//	@Accept		json
+//	@Produce	json
+//	@Param		id		path	string							true	"идентификатор заказа"
+//	@Param		body	body	domain.SetOrderStatusRequest	true	"request body"
+//	@Security	Bearer
+//	@Success	200	{object}	any
+//	@Failure	400	{object}	apierrors.Error
+//	@Failure	403	{object}	apierrors.Error
+//	@Failure	404	{object}	apierrors.Error
+//	@Failure	500	{object}	apierrors.Error
+//	@Router		/orders/{id}/status [POST]
+func (c Order) ChangeOrderStatus(ctx context.Context, req domain.SetOrderStatusRequest, r *http.Request) error {
+	err := c.service.ChangeOrderStatus(ctx, r.Header.Get(userIdHeader), req)
+	switch {
+	case errors.Is(err, domain.ErrOrderNotFound):
+		return apierrors.New(http.StatusNotFound, domain.ErrCodeOrderNotFound, domain.ErrOrderNotFound.Error(), err)
+	case errors.Is(err, domain.ErrOrderStatusTransitionForbidden), errors.Is(err, domain.ErrOrderStatusConflict):
+		return apierrors.NewBusinessError(domain.ErrCodeOrderStatusForbidden, domain.ErrOrderStatusTransitionForbidden.Error(), err)
+	default:
+		return err
+	}
+}
+
+// Cancel order items
+//
+//	@Tags			order
+//	@Summary		Отменить блюда оплаченного заказа
+//	@Description	пересчитывает стоимость заказа и записывает сумму возврата, пользователь получает уведомление в telegram
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string							true	"идентификатор заказа"
+//	@Param			body	body		domain.CancelOrderItemsRequest	true	"request body"
+//	@Security		Bearer
+//	@Success		200		{object}	domain.CancelOrderItemsResponse
+//	@Failure		400		{object}	apierrors.Error
+//	@Failure		403		{object}	apierrors.Error
+//	@Failure		404		{object}	apierrors.Error
+//	@Failure		500		{object}	apierrors.Error
+//	@Router			/orders/{id}/items/cancel [POST]
+func (c Order) CancelOrderItems(
+	ctx context.Context,
+	req domain.CancelOrderItemsRequest,
+	r *http.Request,
+) (*domain.CancelOrderItemsResponse, error) {
+	resp, err := c.service.CancelOrderItems(ctx, r.Header.Get(userIdHeader), req)
+	switch {
+	case errors.Is(err, domain.ErrOrderNotFound):
+		return nil, apierrors.New(http.StatusNotFound, domain.ErrCodeOrderNotFound, domain.ErrOrderNotFound.Error(), err)
+	case errors.Is(err, domain.ErrOrderItemNotFound):
+		return nil, apierrors.New(http.StatusNotFound, domain.ErrCodeOrderItemNotFound, domain.ErrOrderItemNotFound.Error(), err)
+	case errors.Is(err, domain.ErrOrderItemsCancelForbidden):
+		return nil, apierrors.NewBusinessError(domain.ErrCodeItemsCancelForbidden, domain.ErrOrderItemsCancelForbidden.Error(), err)
+	case errors.Is(err, domain.ErrOrderItemAlreadyCanceled):
+		return nil, apierrors.NewBusinessError(domain.ErrCodeItemAlreadyCanceled, domain.ErrOrderItemAlreadyCanceled.Error(), err)
+	case errors.Is(err, domain.ErrOrderItemsCancelAll):
+		return nil, apierrors.NewBusinessError(domain.ErrCodeOrderItemsCancelAll, domain.ErrOrderItemsCancelAll.Error(), err)
+	default:
+		return resp, err
+	}
+}
+
+// Set fulfilment status
+//
+//	@Tags			order
+//	@Summary		Изменить статус части заказа ресторана
+//	@Description	когда все части заказа доставлены или отменены, статус заказа меняется на SUCCESS или CANCELED
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path	string								true	"идентификатор заказа"
+//	@Param			body	body	domain.SetFulfilmentStatusRequest	true	"request body"
+//	@Security		Bearer
+//	@Success		200	{object}	any
+//	@Failure		400	{object}	apierrors.Error
+//	@Failure		403	{object}	apierrors.Error
+//	@Failure		404	{object}	apierrors.Error
+//	@Failure		500	{object}	apierrors.Error
+//	@Router			/orders/{id}/fulfilments [POST]
+func (c Order) SetFulfilmentStatus(ctx context.Context, req domain.SetFulfilmentStatusRequest, r *http.Request) error {
+	err := c.service.SetFulfilmentStatus(ctx, r.Header.Get(userIdHeader), req)
+	switch {
+	case errors.Is(err, domain.ErrOrderNotFound):
+		return apierrors.New(http.StatusNotFound, domain.ErrCodeOrderNotFound, domain.ErrOrderNotFound.Error(), err)
+	case errors.Is(err, domain.ErrFulfilmentNotFound):
+		return apierrors.New(http.StatusNotFound, domain.ErrCodeFulfilmentNotFound, domain.ErrFulfilmentNotFound.Error(), err)
+	case errors.Is(err, domain.ErrFulfilmentStatusForbidden):
+		return apierrors.NewBusinessError(domain.ErrCodeFulfilmentForbidden, domain.ErrFulfilmentStatusForbidden.Error(), err)
+	case errors.Is(err, domain.ErrOrderItemsCancelAll):
+		return apierrors.NewBusinessError(domain.ErrCodeOrderItemsCancelAll, domain.ErrOrderItemsCancelAll.Error(), err)
+	default:
+		return err
+	}
+}
+
+// Get order status history
+//
+//	@Tags		order
+//	@Summary	Получить историю статусов заказа
+//	@Produce	json
+//	@Param		id	path	string	true	"идентификатор заказа"
+//	@Security	Bearer
+//	@Success	200	{array}		domain.OrderStatusHistory
+//	@Failure	400	{object}	apierrors.Error
+//	@Failure	403	{object}	apierrors.Error
+//	@Failure	500	{object}	apierrors.Error
+//	@Router		/orders/details/{id}/history [GET]
+func (c Order) GetOrderStatusHistory(ctx context.Context, req domain.GetOrderStatusHistoryRequest) ([]domain.OrderStatusHistory, error) {
+	return c.service.GetOrderStatusHistory(ctx, req.Id)
+}
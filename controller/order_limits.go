@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/Falokut/go-kit/http/apierrors"
+
+	"dishes-service-backend/domain"
+)
+
+type OrderLimitsService interface {
+	GetOrderLimits(ctx context.Context) (*domain.OrderLimits, error)
+	SetOrderLimits(ctx context.Context, req domain.OrderLimits) error
+	GetUserOrderLimits(ctx context.Context, userId string) (*domain.UserOrderLimits, error)
+	SetUserOrderLimits(ctx context.Context, req domain.SetUserOrderLimitsRequest) error
+	DeleteUserOrderLimits(ctx context.Context, userId string) error
+}
+
+type OrderLimits struct {
+	service OrderLimitsService
+}
+
+func NewOrderLimits(service OrderLimitsService) OrderLimits {
+	return OrderLimits{
+		service: service,
+	}
+}
+
+// Get order limits
+//
+//	@Tags		order_limits
+//	@Summary	Получить общие лимиты заказов
+//	@Produce	json
+//	@Security	Bearer
+//	@Success	200	{object}	domain.OrderLimits
+//	@Failure	403	{object}	apierrors.Error
+//	@Failure	500	{object}	apierrors.Error
+//	@Router		/order_limits [GET]
+func (c OrderLimits) GetOrderLimits(ctx context.Context) (*domain.OrderLimits, error) {
+	return c.service.GetOrderLimits(ctx)
+}
+
+// Set order limits
+//
+//	@Tags			order_limits
+//	@Summary		Изменить общие лимиты заказов
+//	@Description	действуют для пользователей без своих лимитов, 0 - без ограничения
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body	domain.OrderLimits	true	"request body"
+//	@Security		Bearer
+//	@Success		200	{object}	any
+//	@Failure		400	{object}	apierrors.Error
+//	@Failure		403	{object}	apierrors.Error
+//	@Failure		500	{object}	apierrors.Error
+//	@Router			/order_limits [POST]
+func (c OrderLimits) SetOrderLimits(ctx context.Context, req domain.OrderLimits) error {
+	return c.service.SetOrderLimits(ctx, req)
+}
+
+// Get user order limits
+//
+//	@Tags		order_limits
+//	@Summary	Получить лимиты заказов пользователя
+//	@Produce	json
+//	@Param		id	path	string	true	"идентификатор пользователя"
+//	@Security	Bearer
+//	@Success	200	{object}	domain.UserOrderLimits
+//	@Failure	400	{object}	apierrors.Error
+//	@Failure	403	{object}	apierrors.Error
+//	@Failure	404	{object}	apierrors.Error
+//	@Failure	500	{object}	apierrors.Error
+//	@Router		/order_limits/users/{id} [GET]
+func (c OrderLimits) GetUserOrderLimits(ctx context.Context, req domain.GetUserOrderLimitsRequest) (*domain.UserOrderLimits, error) {
+	limits, err := c.service.GetUserOrderLimits(ctx, req.Id)
+	switch {
+	case errors.Is(err, domain.ErrUserNotFound):
+		return nil, apierrors.New(http.StatusNotFound, domain.ErrCodeUserNotFound, domain.ErrUserNotFound.Error(), err)
+	default:
+		return limits, err
+	}
+}
+
+// Set user order limits
+//
+//	@Tags			order_limits
+//	@Summary		Задать лимиты заказов пользователя
+//	@Description	лимиты пользователя полностью заменяют общие, 0 - без ограничения
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path	string								true	"идентификатор пользователя"
+//	@Param			body	body	domain.SetUserOrderLimitsRequest	true	"request body"
+//	@Security		Bearer
+//	@Success		200	{object}	any
+//	@Failure		400	{object}	apierrors.Error
+//	@Failure		403	{object}	apierrors.Error
+//	@Failure		404	{object}	apierrors.Error
+//	@Failure		500	{object}	apierrors.Error
+//	@Router			/order_limits/users/{id} [POST]
+func (c OrderLimits) SetUserOrderLimits(ctx context.Context, req domain.SetUserOrderLimitsRequest) error {
+	err := c.service.SetUserOrderLimits(ctx, req)
+	switch {
+	case errors.Is(err, domain.ErrUserNotFound):
+		return apierrors.New(http.StatusNotFound, domain.ErrCodeUserNotFound, domain.ErrUserNotFound.Error(), err)
+	default:
+		return err
+	}
+}
+
+// Delete user order limits
+//
+//	@Tags			order_limits
+//	@Summary		Удалить лимиты заказов пользователя
+//	@Description	после удаления для пользователя действуют общие лимиты
+//	@Produce		json
+//	@Param			id	path	string	true	"идентификатор пользователя"
+//	@Security		Bearer
+//	@Success		200	{object}	any
+//	@Failure		400	{object}	apierrors.Error
+//	@Failure		403	{object}	apierrors.Error
+//	@Failure		500	{object}	apierrors.Error
+//	@Router			/order_limits/users/{id} [DELETE]
+func (c OrderLimits) DeleteUserOrderLimits(ctx context.Context, req domain.DeleteUserOrderLimitsRequest) error {
+	return c.service.DeleteUserOrderLimits(ctx, req.Id)
+}
@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/Falokut/go-kit/http/apierrors"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/service/payment/gateway"
+)
+
+type PaymentGatewayWebhook interface {
+	Handle(ctx context.Context, body []byte, signature string) error
+}
+
+type PaymentGateway struct {
+	webhook PaymentGatewayWebhook
+}
+
+func NewPaymentGateway(webhook PaymentGatewayWebhook) PaymentGateway {
+	return PaymentGateway{
+		webhook: webhook,
+	}
+}
+
+// Payment gateway webhook
+//
+//	@Tags			payment
+//	@Summary		Уведомление платёжного шлюза
+//	@Description	тело запроса подписывается HMAC-SHA256 секретом уведомлений, подпись передаётся в заголовке X-Signature
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body	gateway.WebhookEvent	true	"request body"
+//	@Success		200		{object}	any
+//	@Failure		400		{object}	apierrors.Error
+//	@Failure		401		{object}	apierrors.Error
+//	@Failure		404		{object}	apierrors.Error
+//	@Failure		500		{object}	apierrors.Error
+//	@Router			/payments/gateway/webhook [POST]
+func (c PaymentGateway) Webhook(ctx context.Context, r *http.Request) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return apierrors.NewBusinessError(domain.ErrCodeInvalidArgument, "невалидное тело запроса", err)
+	}
+	err = c.webhook.Handle(ctx, body, r.Header.Get(gateway.SignatureHeader))
+	switch {
+	case errors.Is(err, domain.ErrInvalidWebhookSignature):
+		return apierrors.New(
+			http.StatusUnauthorized,
+			domain.ErrCodeUnauthorized,
+			domain.ErrInvalidWebhookSignature.Error(),
+			err,
+		)
+	case errors.Is(err, domain.ErrPaymentSessionNotFound):
+		return apierrors.New(
+			http.StatusNotFound,
+			domain.ErrCodePaymentSessionNotFound,
+			domain.ErrPaymentSessionNotFound.Error(),
+			err,
+		)
+	default:
+		return err
+	}
+}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"context"
+
+	_ "github.com/Falokut/go-kit/http/apierrors"
+
+	"dishes-service-backend/domain"
+)
+
+type PaymentLedgerService interface {
+	ListPayments(ctx context.Context, req domain.GetPaymentsRequest) (*domain.GetPaymentsResponse, error)
+	ListDiscrepancies(ctx context.Context, req domain.GetPaymentDiscrepanciesRequest) (*domain.PaymentDiscrepanciesReport, error)
+}
+
+type PaymentLedger struct {
+	service PaymentLedgerService
+}
+
+func NewPaymentLedger(service PaymentLedgerService) PaymentLedger {
+	return PaymentLedger{
+		service: service,
+	}
+}
+
+// List payments
+//
+//	@Tags		payment
+//	@Summary	Получить журнал платежей
+//	@Produce	json
+//	@Param		orderId	query	string	false	"идентификатор заказа"
+//	@Param		method	query	string	false	"способ оплаты"
+//	@Param		status	query	string	false	"статус платежа: PENDING, SUCCEEDED, FAILED"
+//	@Param		limit	query	int		false	"максимальное количество платежей"
+//	@Param		offset	query	int		false	"смещение"
+//	@Security	Bearer
+//	@Success	200	{object}	domain.GetPaymentsResponse
+//	@Failure	400	{object}	apierrors.Error
+//	@Failure	403	{object}	apierrors.Error
+//	@Failure	500	{object}	apierrors.Error
+//	@Router		/payments [GET]
+func (c PaymentLedger) ListPayments(ctx context.Context, req domain.GetPaymentsRequest) (*domain.GetPaymentsResponse, error) {
+	return c.service.ListPayments(ctx, req)
+}
+
+// List payment discrepancies
+//
+//	@Tags			payment
+//	@Summary		Получить расхождения между заказами и платежами
+//	@Description	оплаченные заказы без платежа, платежи по отменённым до оплаты заказам и несовпадения сумм
+//	@Produce		json
+//	@Param			from	query	string	false	"начало периода в формате RFC3339"
+//	@Param			to		query	string	false	"конец периода в формате RFC3339"
+//	@Security		Bearer
+//	@Success		200	{object}	domain.PaymentDiscrepanciesReport
+//	@Failure		400	{object}	apierrors.Error
+//	@Failure		403	{object}	apierrors.Error
+//	@Failure		500	{object}	apierrors.Error
+//	@Router			/payments/discrepancies [GET]
+func (c PaymentLedger) ListDiscrepancies(
+	ctx context.Context,
+	req domain.GetPaymentDiscrepanciesRequest,
+) (*domain.PaymentDiscrepanciesReport, error) {
+	return c.service.ListDiscrepancies(ctx, req)
+}
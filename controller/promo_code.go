@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"dishes-service-backend/domain"
+
+	"github.com/Falokut/go-kit/http/apierrors"
+)
+
+type PromoCodeService interface {
+	GetPromoCodes(ctx context.Context) ([]domain.PromoCode, error)
+	AddPromoCode(ctx context.Context, req domain.AddPromoCodeRequest) (int32, error)
+	DeletePromoCode(ctx context.Context, id int32) error
+}
+
+type PromoCode struct {
+	service PromoCodeService
+}
+
+func NewPromoCode(service PromoCodeService) PromoCode {
+	return PromoCode{service: service}
+}
+
+// Get promo codes
+//
+//	@Tags		promo_codes
+//	@Summary	Получить промокоды
+//	@Produce	json
+//	@Security	Bearer
+//	@Success	200	{array}		domain.PromoCode
+//	@Failure	403	{object}	apierrors.Error
+//	@Failure	500	{object}	apierrors.Error
+//	@Router		/promo_codes [GET]
+func (c PromoCode) GetPromoCodes(ctx context.Context) ([]domain.PromoCode, error) {
+	return c.service.GetPromoCodes(ctx)
+}
+
+// Add promo code
+//
+//	@Tags			promo_codes
+//	@Summary		Создать промокод
+//	@Description	промокод регистронезависимый, если рестораны и категории не указаны - действует на все блюда
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body	domain.AddPromoCodeRequest	true	"request body"
+//	@Security		Bearer
+//	@Success		200	{object}	domain.AddPromoCodeResponse
+//	@Failure		400	{object}	apierrors.Error
+//	@Failure		403	{object}	apierrors.Error
+//	@Failure		404	{object}	apierrors.Error
+//	@Failure		409	{object}	apierrors.Error
+//	@Failure		500	{object}	apierrors.Error
+//	@Router			/promo_codes [POST]
+func (c PromoCode) AddPromoCode(ctx context.Context, req domain.AddPromoCodeRequest) (*domain.AddPromoCodeResponse, error) {
+	id, err := c.service.AddPromoCode(ctx, req)
+	switch {
+	case errors.Is(err, domain.ErrPromoCodeConflict):
+		return nil, apierrors.New(http.StatusConflict, domain.ErrCodePromoCodeConflict, domain.ErrPromoCodeConflict.Error(), err)
+	case errors.Is(err, domain.ErrRestaurantNotFound):
+		return nil, apierrors.New(http.StatusNotFound, domain.ErrCodeRestaurantNotFound, domain.ErrRestaurantNotFound.Error(), err)
+	case errors.Is(err, domain.ErrDishCategoryNotFound):
+		return nil, apierrors.New(http.StatusNotFound, domain.ErrCodeDishCategoryNotFound, domain.ErrDishCategoryNotFound.Error(), err)
+	case err != nil:
+		return nil, err
+	default:
+		return &domain.AddPromoCodeResponse{Id: id}, nil
+	}
+}
+
+// Delete promo code
+//
+//	@Tags		promo_codes
+//	@Summary	Удалить промокод
+//	@Produce	json
+//	@Param		id	path	int	true	"идентификатор промокода"
+//	@Security	Bearer
+//	@Success	200	{object}	any
+//	@Failure	400	{object}	apierrors.Error
+//	@Failure	403	{object}	apierrors.Error
+//	@Failure	500	{object}	apierrors.Error
+//	@Router		/promo_codes/{id} [DELETE]
+func (c PromoCode) DeletePromoCode(ctx context.Context, req domain.DeletePromoCodeRequest) error {
+	return c.service.DeletePromoCode(ctx, req.Id)
+}
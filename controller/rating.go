@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/Falokut/go-kit/http/apierrors"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+)
+
+type RatingService interface {
+	RateOrder(ctx context.Context, userId string, req domain.RateOrderRequest) error
+	GetOrderRating(ctx context.Context, authInfo entity.UserAuthInfo, orderId string) (*domain.OrderRating, error)
+	GetLowRatings(ctx context.Context, req domain.GetLowRatingsRequest) ([]domain.LowRating, error)
+}
+
+type Rating struct {
+	service RatingService
+}
+
+func NewRating(service RatingService) Rating {
+	return Rating{
+		service: service,
+	}
+}
+
+// Rate order
+//
+//	@Tags			rating
+//	@Summary		Оценить заказ
+//	@Description	оценить можно только заказ в статусе SUCCESS, повторная оценка заменяет прежнюю
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path	string					true	"идентификатор заказа"
+//	@Param			body	body	domain.RateOrderRequest	true	"request body"
+//	@Security		Bearer
+//	@Success		200	{object}	any
+//	@Failure		400	{object}	apierrors.Error
+//	@Failure		403	{object}	apierrors.Error
+//	@Failure		404	{object}	apierrors.Error
+//	@Failure		500	{object}	apierrors.Error
+//	@Router			/orders/{id}/rating [POST]
+func (c Rating) RateOrder(ctx context.Context, req domain.RateOrderRequest, r *http.Request) error {
+	err := c.service.RateOrder(ctx, r.Header.Get(userIdHeader), req)
+	switch {
+	case errors.Is(err, domain.ErrOrderNotFound):
+		return apierrors.New(http.StatusNotFound, domain.ErrCodeOrderNotFound, domain.ErrOrderNotFound.Error(), err)
+	case errors.Is(err, domain.ErrForbidden):
+		return apierrors.New(http.StatusForbidden, domain.ErrCodeForbidden, domain.ErrForbidden.Error(), err)
+	case errors.Is(err, domain.ErrFulfilmentNotFound):
+		return apierrors.New(http.StatusNotFound, domain.ErrCodeFulfilmentNotFound, domain.ErrFulfilmentNotFound.Error(), err)
+	case errors.Is(err, domain.ErrOrderRatingForbidden):
+		return apierrors.NewBusinessError(domain.ErrCodeOrderRatingForbidden, domain.ErrOrderRatingForbidden.Error(), err)
+	default:
+		return err
+	}
+}
+
+// Get order rating
+//
+//	@Tags		rating
+//	@Summary	Получить оценку заказа
+//	@Produce	json
+//	@Param		id	path	string	true	"идентификатор заказа"
+//	@Security	Bearer
+//	@Success	200	{object}	domain.OrderRating
+//	@Failure	400	{object}	apierrors.Error
+//	@Failure	403	{object}	apierrors.Error
+//	@Failure	404	{object}	apierrors.Error
+//	@Failure	500	{object}	apierrors.Error
+//	@Router		/orders/details/{id}/rating [GET]
+func (c Rating) GetOrderRating(ctx context.Context, req domain.GetOrderRatingRequest, r *http.Request) (*domain.OrderRating, error) {
+	rating, err := c.service.GetOrderRating(ctx, getUserAuthInfo(r), req.Id)
+	switch {
+	case errors.Is(err, domain.ErrOrderNotFound):
+		return nil, apierrors.New(http.StatusNotFound, domain.ErrCodeOrderNotFound, domain.ErrOrderNotFound.Error(), err)
+	case errors.Is(err, domain.ErrForbidden):
+		return nil, apierrors.New(http.StatusForbidden, domain.ErrCodeForbidden, domain.ErrForbidden.Error(), err)
+	case errors.Is(err, domain.ErrOrderRatingNotFound):
+		return nil, apierrors.New(http.StatusNotFound, domain.ErrCodeOrderRatingNotFound, domain.ErrOrderRatingNotFound.Error(), err)
+	default:
+		return rating, err
+	}
+}
+
+// Get low ratings
+//
+//	@Tags			rating
+//	@Summary		Получить низкие оценки
+//	@Description	заказы, у которых оценка заказа или одного из ресторанов не выше maxScore
+//	@Produce		json
+//	@Param			maxScore	query	int	false	"максимальная оценка, по умолчанию 2"
+//	@Param			limit		query	int	false	"лимит"
+//	@Param			offset		query	int	false	"смещение"
+//	@Security		Bearer
+//	@Success		200	{array}		domain.LowRating
+//	@Failure		400	{object}	apierrors.Error
+//	@Failure		403	{object}	apierrors.Error
+//	@Failure		500	{object}	apierrors.Error
+//	@Router			/ratings/low [GET]
+func (c Rating) GetLowRatings(ctx context.Context, req domain.GetLowRatingsRequest) ([]domain.LowRating, error) {
+	return c.service.GetLowRatings(ctx, req)
+}
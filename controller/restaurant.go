@@ -14,6 +14,7 @@ type RestaurantService interface {
 	GetRestaurant(ctx context.Context, id int32) (domain.Restaurant, error)
 	AddRestaurant(ctx context.Context, category string) (int32, error)
 	RenameRestaurant(ctx context.Context, req domain.RenameRestaurantRequest) error
+	SetRestaurantTerms(ctx context.Context, req domain.SetRestaurantTermsRequest) error
 	DeleteRestaurant(ctx context.Context, id int32) error
 }
 type Restaurant struct {
@@ -125,6 +126,36 @@ func (c Restaurant) RenameRestaurant(ctx context.Context, req domain.RenameResta
 	}
 }
 
+// Set restaurant terms
+//
+//	@Tags			restaurants
+//	@Summary		Изменить условия заказа в ресторане
+//	@Description	минимальная сумма заказа, стоимость доставки и порог бесплатной доставки в минимальных единицах валюты
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path	int32								true	"Идентификатор ресторана"
+//	@Param			body	body	domain.SetRestaurantTermsRequest	true	"request body"
+//	@Security		Bearer
+//	@Success		200	{object}	any
+//	@Failure		400	{object}	apierrors.Error
+//	@Failure		403	{object}	apierrors.Error
+//	@Failure		404	{object}	apierrors.Error
+//	@Failure		500	{object}	apierrors.Error
+//	@Router			/restaurants/{id}/terms [POST]
+func (c Restaurant) SetRestaurantTerms(ctx context.Context, req domain.SetRestaurantTermsRequest) error {
+	err := c.service.SetRestaurantTerms(ctx, req)
+	switch {
+	case errors.Is(err, domain.ErrRestaurantNotFound):
+		return apierrors.New(http.StatusNotFound,
+			domain.ErrCodeRestaurantNotFound,
+			domain.ErrRestaurantNotFound.Error(),
+			err,
+		)
+	default:
+		return err
+	}
+}
+
 // Delete category
 //
 //	@Tags		restaurants
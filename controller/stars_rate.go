@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"context"
+
+	_ "github.com/Falokut/go-kit/http/apierrors"
+
+	"dishes-service-backend/domain"
+)
+
+type StarsRateService interface {
+	GetStarsRate(ctx context.Context) (*domain.StarsRate, error)
+	SetStarsRate(ctx context.Context, req domain.SetStarsRateRequest) error
+}
+
+type StarsRate struct {
+	service StarsRateService
+}
+
+func NewStarsRate(service StarsRateService) StarsRate {
+	return StarsRate{
+		service: service,
+	}
+}
+
+// Get stars rate
+//
+//	@Tags		payment
+//	@Summary	Получить курс Telegram Stars
+//	@Produce	json
+//	@Security	Bearer
+//	@Success	200	{object}	domain.StarsRate
+//	@Failure	403	{object}	apierrors.Error
+//	@Failure	500	{object}	apierrors.Error
+//	@Router		/payments/stars_rate [GET]
+func (c StarsRate) GetStarsRate(ctx context.Context) (*domain.StarsRate, error) {
+	return c.service.GetStarsRate(ctx)
+}
+
+// Set stars rate
+//
+//	@Tags			payment
+//	@Summary		Изменить курс Telegram Stars
+//	@Description	сумма счёта в звёздах округляется вверх, новый курс применяется к ещё не выставленным счетам
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body	domain.SetStarsRateRequest	true	"request body"
+//	@Security		Bearer
+//	@Success		200	{object}	any
+//	@Failure		400	{object}	apierrors.Error
+//	@Failure		403	{object}	apierrors.Error
+//	@Failure		500	{object}	apierrors.Error
+//	@Router			/payments/stars_rate [POST]
+func (c StarsRate) SetStarsRate(ctx context.Context, req domain.SetStarsRateRequest) error {
+	return c.service.SetStarsRate(ctx, req)
+}
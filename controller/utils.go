@@ -1,9 +1,14 @@
 package controller
 
 import (
-	"github.com/pkg/errors"
+	"net/http"
 	"strconv"
 	"strings"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/pkg/errors"
 )
 
 func stringToIntSlice(s string) ([]int32, error) {
@@ -22,3 +27,10 @@ func stringToIntSlice(s string) ([]int32, error) {
 	}
 	return res, nil
 }
+
+func getUserAuthInfo(r *http.Request) entity.UserAuthInfo {
+	return entity.UserAuthInfo{
+		UserId:   r.Header.Get(domain.UserIdHeader),
+		RoleName: r.Header.Get(domain.UserRoleHeader),
+	}
+}
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/Falokut/go-kit/http/apierrors"
+
+	"dishes-service-backend/domain"
+)
+
+type WalletService interface {
+	GetWallet(ctx context.Context, userId string) (*domain.Wallet, error)
+	GetTransactions(ctx context.Context, userId string, req domain.GetWalletTransactionsRequest) ([]domain.WalletTransaction, error)
+	RequestTopUp(ctx context.Context, userId string, req domain.TopUpWalletRequest) error
+	Adjust(ctx context.Context, adminId string, req domain.AdjustWalletRequest) (*domain.Wallet, error)
+}
+
+type Wallet struct {
+	service WalletService
+}
+
+func NewWallet(service WalletService) Wallet {
+	return Wallet{
+		service: service,
+	}
+}
+
+// Get wallet
+//
+//	@Tags		wallet
+//	@Summary	Получить баланс кошелька
+//	@Produce	json
+//	@Security	Bearer
+//	@Success	200	{object}	domain.Wallet
+//	@Failure	401	{object}	apierrors.Error
+//	@Failure	500	{object}	apierrors.Error
+//	@Router		/wallet [GET]
+func (c Wallet) GetWallet(ctx context.Context, r *http.Request) (*domain.Wallet, error) {
+	return c.service.GetWallet(ctx, r.Header.Get(userIdHeader))
+}
+
+// Get wallet transactions
+//
+//	@Tags			wallet
+//	@Summary		Получить операции по кошельку
+//	@Description	операции отсортированы от новых к старым
+//	@Produce		json
+//	@Param			limit	query	int	false	"максимальное количество операций"
+//	@Param			offset	query	int	false	"смещение"
+//	@Security		Bearer
+//	@Success		200	{array}		domain.WalletTransaction
+//	@Failure		400	{object}	apierrors.Error
+//	@Failure		401	{object}	apierrors.Error
+//	@Failure		500	{object}	apierrors.Error
+//	@Router			/wallet/transactions [GET]
+func (c Wallet) GetTransactions(
+	ctx context.Context,
+	req domain.GetWalletTransactionsRequest,
+	r *http.Request,
+) ([]domain.WalletTransaction, error) {
+	return c.service.GetTransactions(ctx, r.Header.Get(userIdHeader), req)
+}
+
+// Top up wallet
+//
+//	@Tags			wallet
+//	@Summary		Пополнить кошелёк
+//	@Description	счёт на пополнение отправляется в чат с ботом, кошелёк пополняется после оплаты счёта
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body	domain.TopUpWalletRequest	true	"request body"
+//	@Security		Bearer
+//	@Success		200	{object}	any
+//	@Failure		400	{object}	apierrors.Error
+//	@Failure		401	{object}	apierrors.Error
+//	@Failure		500	{object}	apierrors.Error
+//	@Router			/wallet/top_up [POST]
+func (c Wallet) TopUp(ctx context.Context, req domain.TopUpWalletRequest, r *http.Request) error {
+	err := c.service.RequestTopUp(ctx, r.Header.Get(userIdHeader), req)
+	switch {
+	case errors.Is(err, domain.ErrTopUpInvoiceFailed):
+		return apierrors.NewBusinessError(domain.ErrCodeTopUpInvoiceFailed, domain.ErrTopUpInvoiceFailed.Error(), err)
+	default:
+		return err
+	}
+}
+
+// Adjust wallet
+//
+//	@Tags			wallet
+//	@Summary		Изменить баланс кошелька пользователя
+//	@Description	положительная сумма зачисляется, отрицательная списывается
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path	string						true	"идентификатор пользователя"
+//	@Param			body	body	domain.AdjustWalletRequest	true	"request body"
+//	@Security		Bearer
+//	@Success		200	{object}	domain.Wallet
+//	@Failure		400	{object}	apierrors.Error
+//	@Failure		403	{object}	apierrors.Error
+//	@Failure		404	{object}	apierrors.Error
+//	@Failure		500	{object}	apierrors.Error
+//	@Router			/wallet/users/{id}/adjust [POST]
+func (c Wallet) Adjust(ctx context.Context, req domain.AdjustWalletRequest, r *http.Request) (*domain.Wallet, error) {
+	wallet, err := c.service.Adjust(ctx, r.Header.Get(userIdHeader), req)
+	switch {
+	case errors.Is(err, domain.ErrUserNotFound):
+		return nil, apierrors.New(http.StatusNotFound, domain.ErrCodeUserNotFound, domain.ErrUserNotFound.Error(), err)
+	case errors.Is(err, domain.ErrInsufficientFunds):
+		return nil, apierrors.NewBusinessError(domain.ErrCodeInsufficientFunds, domain.ErrInsufficientFunds.Error(), err)
+	default:
+		return wallet, err
+	}
+}
@@ -7,6 +7,7 @@ import (
 const (
 	AuthHeaderName = "Authorization"
 	UserIdHeader   = "X-User-Id"
+	UserRoleHeader = "X-User-Role"
 	BearerToken    = "Bearer"
 )
 
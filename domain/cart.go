@@ -0,0 +1,40 @@
+package domain
+
+type Cart struct {
+	Items []CartItem
+	Total int32
+}
+
+type CartItem struct {
+	DishId         int32
+	Name           string
+	RestaurantName string
+	Price          int32
+	Count          int32
+	TotalPrice     int32
+	// цена изменилась с момента добавления блюда в корзину
+	PriceChanged bool
+}
+
+type AddCartItemRequest struct {
+	DishId int32 `validate:"required"`
+	Count  int32 `validate:"required,min=1"`
+}
+
+type SetCartItemCountRequest struct {
+	// идентификатор блюда
+	Id    int32 `json:",omitempty" validate:"required"`
+	Count int32 `validate:"required,min=1"`
+}
+
+type RemoveCartItemRequest struct {
+	// идентификатор блюда
+	Id int32 `validate:"required"`
+}
+
+type CheckoutCartRequest struct {
+	PaymentMethod  string `validate:"required,min=1"`
+	Wishes         string `json:",omitempty"`
+	DeliverySlotId int32  `json:",omitempty"`
+	PromoCode      string `json:",omitempty"`
+}
@@ -0,0 +1,66 @@
+package domain
+
+import "time"
+
+const (
+	StatementFormatCsv  = "csv"
+	StatementFormatXlsx = "xlsx"
+)
+
+type Company struct {
+	Id   int32
+	Name string
+	// максимальная сумма оплаченных заказов компании за календарный месяц, 0 - без ограничения
+	CreditLimit int32
+	CreatedAt   time.Time
+}
+
+type AddCompanyRequest struct {
+	Name        string `validate:"required,min=1"`
+	CreditLimit int32  `validate:"min=0"`
+}
+
+type AddCompanyResponse struct {
+	Id int32
+}
+
+type EditCompanyRequest struct {
+	Id          int32  `json:",omitempty" validate:"required"`
+	Name        string `validate:"required,min=1"`
+	CreditLimit int32  `validate:"min=0"`
+}
+
+type AddCompanyUserRequest struct {
+	Id     int32  `json:",omitempty" validate:"required"`
+	UserId string `validate:"required,uuid"`
+}
+
+type DeleteCompanyUserRequest struct {
+	// идентификатор пользователя
+	Id string `validate:"required,uuid"`
+}
+
+type GetCompanyStatementRequest struct {
+	Id int32 `validate:"required"`
+	// месяц в формате гггг.мм
+	Month string `query:"month" validate:"required"`
+}
+
+type CompanyStatement struct {
+	CompanyId   int32
+	CompanyName string
+	Month       string
+	Orders      []CompanyStatementOrder
+	// сумма заказов к оплате за месяц
+	Total int32
+}
+
+type CompanyStatementOrder struct {
+	OrderId   string
+	CreatedAt time.Time
+	Status    string
+	Username  string
+	Name      string
+	Total     int32
+	Refunded  int32
+}
@@ -0,0 +1,36 @@
+package domain
+
+type DeliverySlot struct {
+	Id int32
+	// дата в формате гггг-мм-дд
+	Date string
+	// время в формате чч:мм
+	StartTime string
+	EndTime   string
+	Capacity  int32
+	Available int32
+}
+
+type GetDeliverySlotsRequest struct {
+	Date string `query:"date" validate:"required"`
+}
+
+type AddDeliverySlotRequest struct {
+	Date      string `validate:"required"`
+	StartTime string `validate:"required"`
+	EndTime   string `validate:"required"`
+	Capacity  int32  `validate:"required,min=1"`
+}
+
+type AddDeliverySlotResponse struct {
+	Id int32
+}
+
+type EditDeliverySlotRequest struct {
+	Id       int32 `json:",omitempty" validate:"required"`
+	Capacity int32 `validate:"required,min=1"`
+}
+
+type DeleteDeliverySlotRequest struct {
+	Id int32 `validate:"required"`
+}
@@ -8,6 +8,9 @@ type Dish struct {
 	Url            string   `json:",omitempty"`
 	Categories     []string `json:",omitempty"`
 	RestaurantName string
+	// средняя оценка заказов с блюдом от 1 до 5, 0 - оценок нет
+	Rating       float64 `json:",omitempty"`
+	RatingsCount int64   `json:",omitempty"`
 }
 
 type GetDishesRequest struct {
@@ -0,0 +1,49 @@
+package domain
+
+type DishOptionGroup struct {
+	Id   int32
+	Name string
+	// обязательная группа должна быть выбрана при заказе блюда
+	Required bool
+	// количество опций, которое можно выбрать в группе
+	MinCount int32
+	MaxCount int32
+	Options  []DishOption
+}
+
+type DishOption struct {
+	Id   int32
+	Name string
+	// изменение цены блюда в минимальных единицах валюты
+	PriceDelta int32
+}
+
+type GetDishOptionsRequest struct {
+	// идентификатор блюда
+	Id int32 `validate:"required"`
+}
+
+type AddDishOptionGroupRequest struct {
+	// идентификатор блюда
+	Id       int32  `json:",omitempty" validate:"required"`
+	Name     string `validate:"required,min=1"`
+	Required bool
+	// для обязательной группы не меньше 1
+	MinCount int32 `validate:"min=0"`
+	// если не указано - количество опций в группе
+	MaxCount int32                  `json:",omitempty" validate:"min=0"`
+	Options  []AddDishOptionRequest `validate:"required,min=1,dive"`
+}
+
+type AddDishOptionRequest struct {
+	Name       string `validate:"required,min=1"`
+	PriceDelta int32
+}
+
+type AddDishOptionGroupResponse struct {
+	Id int32
+}
+
+type DeleteDishOptionGroupRequest struct {
+	Id int32 `validate:"required"`
+}
@@ -8,40 +8,121 @@ import (
 )
 
 var (
-	ErrInvalidPaymentMethod       = errors.New("невалидный способ оплаты")
-	ErrUserAlreadyExists          = errors.New("пользователь уже существует")
-	ErrUserNotFound               = errors.New("пользователь не найден")
-	ErrTelegramSignMissing        = errors.New("отсутствует подпись от telegram")
-	ErrTelegramAuthDateMissing    = errors.New("отсутствует дата создания токена от telegram")
-	ErrTelegramCredentialsExpired = errors.New("данные от telegram устарели")
-	ErrInvalidTelegramCredentials = errors.New("невалидные данные от telegram")
-	ErrUserOperationForbidden     = errors.New("данная операция запрещена для пользователя")
-	ErrWrongSecret                = errors.New("неверный пароль")
-	ErrDishNotFound               = errors.New("не все блюда были найдены")
-	ErrInvalidDishCount           = errors.New("невалидное значение количества блюд")
-	ErrDishCategoryNotFound       = errors.New("категория не найдена")
-	ErrDishCategoryConflict       = errors.New("категория с таким именем уже существует")
-	ErrRestaurantNotFound         = errors.New("ресторан не найдена")
-	ErrRestaurantConflict         = errors.New("ресторан с таким названием уже существует")
-	ErrInvalidToken               = errors.New("невалидный токен")
-	ErrForbidden                  = errors.New("доступ запрещён")
-	ErrOrderingForbidden          = errors.New("оформление заказов приостановлено")
-	ErrOrderNotFound              = errors.New("заказ не найден")
+	ErrInvalidPaymentMethod           = errors.New("невалидный способ оплаты")
+	ErrUserAlreadyExists              = errors.New("пользователь уже существует")
+	ErrUserNotFound                   = errors.New("пользователь не найден")
+	ErrTelegramSignMissing            = errors.New("отсутствует подпись от telegram")
+	ErrTelegramAuthDateMissing        = errors.New("отсутствует дата создания токена от telegram")
+	ErrTelegramCredentialsExpired     = errors.New("данные от telegram устарели")
+	ErrInvalidTelegramCredentials     = errors.New("невалидные данные от telegram")
+	ErrUserOperationForbidden         = errors.New("данная операция запрещена для пользователя")
+	ErrWrongSecret                    = errors.New("неверный пароль")
+	ErrDishNotFound                   = errors.New("не все блюда были найдены")
+	ErrInvalidDishCount               = errors.New("невалидное значение количества блюд")
+	ErrDishCategoryNotFound           = errors.New("категория не найдена")
+	ErrDishCategoryConflict           = errors.New("категория с таким именем уже существует")
+	ErrRestaurantNotFound             = errors.New("ресторан не найдена")
+	ErrRestaurantConflict             = errors.New("ресторан с таким названием уже существует")
+	ErrInvalidToken                   = errors.New("невалидный токен")
+	ErrForbidden                      = errors.New("доступ запрещён")
+	ErrOrderingForbidden              = errors.New("оформление заказов приостановлено")
+	ErrOrderNotFound                  = errors.New("заказ не найден")
+	ErrOrderCancelForbidden           = errors.New("заказ уже оплачен или отменён")
+	ErrOrderStatusConflict            = errors.New("статус заказа изменился")
+	ErrOrderStatusTransitionForbidden = errors.New("недопустимый переход статуса заказа")
+	ErrIdempotencyKeyNotFound         = errors.New("ключ идемпотентности не найден")
+	ErrIdempotencyKeyConflict         = errors.New("ключ идемпотентности уже использован для другого запроса")
+	ErrRepeatOrderEmpty               = errors.New("ни одного блюда из заказа больше нет в наличии")
+	ErrDeliverySlotNotFound           = errors.New("слот доставки не найден")
+	ErrDeliverySlotConflict           = errors.New("слот доставки на это время уже существует")
+	ErrDeliverySlotFull               = errors.New("в выбранном слоте доставки не осталось мест")
+	ErrDeliverySlotExpired            = errors.New("выбранный слот доставки уже недоступен")
+	ErrGroupOrderNotFound             = errors.New("групповой заказ не найден")
+	ErrGroupOrderLocked               = errors.New("групповой заказ уже закрыт")
+	ErrGroupOrderEmpty                = errors.New("в групповом заказе нет блюд")
+	ErrCartEmpty                      = errors.New("корзина пуста")
+	ErrCartItemNotFound               = errors.New("блюдо не найдено в корзине")
+	ErrCartPricesChanged              = errors.New("цены блюд в корзине изменились, проверьте корзину")
+	ErrPromoCodeNotFound              = errors.New("промокод не найден")
+	ErrPromoCodeConflict              = errors.New("промокод уже существует")
+	ErrPromoCodeInactive              = errors.New("промокод сейчас не действует")
+	ErrPromoCodeLimitExceeded         = errors.New("превышен лимит использований промокода")
+	ErrPromoCodeNotApplicable         = errors.New("промокод не применим к заказу")
+	ErrOrderBelowMinimum              = errors.New("сумма заказа меньше минимальной")
+	ErrInvalidDishOptions             = errors.New("неправильно выбраны опции блюда")
+	ErrOrderItemNotFound              = errors.New("позиция не найдена в заказе")
+	ErrOrderItemsCancelForbidden      = errors.New("отменить блюда можно только в оплаченном заказе")
+	ErrOrderItemAlreadyCanceled       = errors.New("позиция заказа уже отменена")
+	ErrOrderItemsCancelAll            = errors.New("нельзя отменить все блюда заказа, отмените заказ целиком")
+	ErrFulfilmentNotFound             = errors.New("в заказе нет блюд из этого ресторана")
+	ErrFulfilmentStatusForbidden      = errors.New("недопустимый переход статуса части заказа")
+	ErrOrderRatingForbidden           = errors.New("оценить можно только полученный заказ")
+	ErrOrderRatingNotFound            = errors.New("заказ ещё не оценён")
+	ErrOrderLimitExceeded             = errors.New("превышен лимит заказов")
+	ErrCompanyNotFound                = errors.New("компания не найдена")
+	ErrCompanyConflict                = errors.New("компания с таким названием уже существует")
+	ErrUserCompanyNotFound            = errors.New("пользователь не привязан к компании")
+	ErrCreditLimitExceeded            = errors.New("превышен кредитный лимит компании")
+	ErrInsufficientFunds              = errors.New("недостаточно средств в кошельке")
+	ErrWalletTransactionExists        = errors.New("операция по кошельку уже проведена")
+	ErrTopUpInvoiceFailed             = errors.New("не удалось выставить счёт на пополнение")
+	ErrInvalidWebhookSignature        = errors.New("невалидная подпись уведомления")
+	ErrPaymentSessionNotFound         = errors.New("платёж не найден")
+	ErrStarsRateNotSet                = errors.New("оплата в Telegram Stars недоступна, курс не задан")
 )
 
 const (
 	ErrCodeInvalidArgument = 400
 
-	ErrCodeInvalidDishCount     = 600
-	ErrCodeDishNotFound         = 601
-	ErrCodeDishCategoryNotFound = 602
-	ErrCodeDishCategoryConflict = 603
-	ErrCodeRestaurantNotFound   = 602
-	ErrCodeRestaurantConflict   = 603
-	ErrCodeUserNotFound         = 604
-	ErrCodeUserAlreadyExists    = 605
-	ErrCodeWrongSecret          = 606
-	ErrCodeOrderingForbidden    = 607
+	ErrCodeInvalidDishCount       = 600
+	ErrCodeDishNotFound           = 601
+	ErrCodeDishCategoryNotFound   = 602
+	ErrCodeDishCategoryConflict   = 603
+	ErrCodeRestaurantNotFound     = 602
+	ErrCodeRestaurantConflict     = 603
+	ErrCodeUserNotFound           = 604
+	ErrCodeUserAlreadyExists      = 605
+	ErrCodeWrongSecret            = 606
+	ErrCodeOrderingForbidden      = 607
+	ErrCodeOrderNotFound          = 608
+	ErrCodeOrderCancelForbidden   = 609
+	ErrCodeOrderStatusForbidden   = 610
+	ErrCodeIdempotencyConflict    = 611
+	ErrCodeRepeatOrderEmpty       = 612
+	ErrCodeDeliverySlotNotFound   = 613
+	ErrCodeDeliverySlotConflict   = 614
+	ErrCodeDeliverySlotFull       = 615
+	ErrCodeDeliverySlotExpired    = 616
+	ErrCodeGroupOrderNotFound     = 617
+	ErrCodeGroupOrderLocked       = 618
+	ErrCodeGroupOrderEmpty        = 619
+	ErrCodeCartEmpty              = 620
+	ErrCodeCartItemNotFound       = 621
+	ErrCodeCartPricesChanged      = 622
+	ErrCodePromoCodeNotFound      = 623
+	ErrCodePromoCodeConflict      = 624
+	ErrCodePromoCodeInactive      = 625
+	ErrCodePromoCodeLimitExceeded = 626
+	ErrCodePromoCodeNotApplicable = 627
+	ErrCodeOrderBelowMinimum      = 628
+	ErrCodeInvalidDishOptions     = 629
+	ErrCodeOrderItemNotFound      = 630
+	ErrCodeItemsCancelForbidden   = 631
+	ErrCodeItemAlreadyCanceled    = 632
+	ErrCodeOrderItemsCancelAll    = 633
+	ErrCodeFulfilmentNotFound     = 634
+	ErrCodeFulfilmentForbidden    = 635
+	ErrCodeOrderRatingForbidden   = 636
+	ErrCodeOrderRatingNotFound    = 637
+	ErrCodeOrderLimitExceeded     = 638
+	ErrCodeCompanyNotFound        = 639
+	ErrCodeCompanyConflict        = 640
+	ErrCodeUserCompanyNotFound    = 641
+	ErrCodeCreditLimitExceeded    = 642
+	ErrCodeInsufficientFunds      = 643
+	ErrCodeTopUpInvoiceFailed     = 644
+	ErrCodePaymentSessionNotFound = 645
+	ErrCodeStarsRateNotSet        = 646
 
 	ErrCodeUnauthorized = 700
 	ErrCodeForbidden    = 701
@@ -0,0 +1,59 @@
+package domain
+
+import "time"
+
+type CreateGroupOrderRequest struct {
+	// participants - каждый участник оплачивает свою часть, organizer - организатор оплачивает весь заказ
+	PaymentMode string `validate:"required,oneof=participants organizer"`
+	Wishes      string `json:",omitempty"`
+}
+
+type CreateGroupOrderResponse struct {
+	Id string
+}
+
+type GetGroupOrderRequest struct {
+	Id string `validate:"required"`
+}
+
+type GroupOrder struct {
+	Id                string
+	OrganizerId       string
+	OrganizerUsername string
+	PaymentMode       string
+	Status            string
+	Wishes            string `json:",omitempty"`
+	CreatedAt         time.Time
+	Participants      []GroupOrderParticipant
+	Total             int32
+}
+
+type GroupOrderParticipant struct {
+	UserId   string
+	Username string
+	Items    []OrderItem
+	Total    int32
+}
+
+type SetGroupOrderItemsRequest struct {
+	Id string `json:",omitempty" validate:"required"`
+	// блюда участника, пустой список удаляет участника из заказа
+	Items map[string]int32
+}
+
+type LockGroupOrderRequest struct {
+	Id             string `json:",omitempty" validate:"required"`
+	PaymentMethod  string `validate:"required,min=1"`
+	DeliverySlotId int32  `json:",omitempty"`
+}
+
+type LockGroupOrderResponse struct {
+	Orders []GroupOrderPayment
+}
+
+type GroupOrderPayment struct {
+	UserId  string
+	OrderId string
+	// for some payment methods may be empty
+	PaymentUrl string
+}
@@ -3,12 +3,27 @@ package domain
 import "time"
 
 type ProcessOrderRequest struct {
-	Items         map[string]int32 `validate:"required"`
-	PaymentMethod string           `validate:"required,min=1"`
-	Wishes        string           `json:",omitempty"`
+	// идентификатор блюда - количество, для блюд без опций
+	Items map[string]int32 `json:",omitempty"`
+	// блюда с выбранными опциями
+	ItemsWithOptions []OrderItemRequest `json:",omitempty" validate:"dive"`
+	PaymentMethod    string             `validate:"required,min=1"`
+	Wishes           string             `json:",omitempty"`
+	// идентификатор слота доставки, если не указан - доставка без слота
+	DeliverySlotId int32  `json:",omitempty"`
+	PromoCode      string `json:",omitempty"`
 }
 
+type OrderItemRequest struct {
+	DishId    int32   `validate:"required"`
+	Count     int32   `validate:"required,min=1"`
+	OptionIds []int32 `json:",omitempty"`
+}
+
+const IdempotencyKeyHeader = "Idempotency-Key"
+
 type ProcessOrderResponse struct {
+	OrderId string
 	// for some payment methods may be empty
 	PaymentUrl string
 }
@@ -18,6 +33,33 @@ type GetMyOrdersRequest struct {
 	Offset int32 `validate:"min=0"`
 }
 
+type GetOrderRequest struct {
+	Id string `validate:"required"`
+}
+
+type CancelOrderRequest struct {
+	Id string `validate:"required"`
+}
+
+type RepeatOrderRequest struct {
+	Id string `json:",omitempty" validate:"required"`
+	// if empty, the payment method of the original order is used
+	PaymentMethod  string `json:",omitempty"`
+	DeliverySlotId int32  `json:",omitempty"`
+}
+
+type RepeatOrderResponse struct {
+	OrderId string
+	// for some payment methods may be empty
+	PaymentUrl    string
+	MissingDishes []MissingDish
+}
+
+type MissingDish struct {
+	DishId int32
+	Name   string
+}
+
 type UserOrder struct {
 	Id            string
 	Items         []OrderItem
@@ -26,13 +68,119 @@ type UserOrder struct {
 	Status        string
 	Wishes        string `json:",omitempty"`
 	CreatedAt     time.Time
+	DeliverySlot  string `json:",omitempty"`
+	GroupOrderId  string `json:",omitempty"`
+	PromoCode     string `json:",omitempty"`
+	// скидка по промокоду, Total указан с её учётом
+	Discount int32 `json:",omitempty"`
+	// стоимость доставки, Total указан с её учётом
+	DeliveryFee int32 `json:",omitempty"`
+	// сумма возврата за отменённые блюда, Total указан с её учётом
+	Refunded int32 `json:",omitempty"`
+	// статус возврата оплаты отменённого заказа: PENDING, REFUNDED или FAILED
+	RefundStatus string `json:",omitempty"`
+	// части заказа по ресторанам, заполняются только при получении заказа по идентификатору
+	Fulfilments []OrderFulfilment `json:",omitempty"`
+}
+
+type OrderFulfilment struct {
+	RestaurantId   int32
+	RestaurantName string
+	// ACCEPTED, READY, DELIVERED или CANCELED
+	Status    string
+	UpdatedAt time.Time
 }
 
 type OrderItem struct {
+	// идентификатор позиции заказа
+	Id         int64
 	DishId     int32
 	Name       string
 	Price      int32
 	Count      int32
 	TotalPrice int32
-	Status     string
+	// ACTIVE или CANCELED
+	Status  string
+	Options []OrderItemOption `json:",omitempty"`
+}
+
+type OrderItemOption struct {
+	Group      string
+	Name       string
+	PriceDelta int32
+}
+
+type GetOrdersRequest struct {
+	Status        string `query:"status" validate:"omitempty,oneof=PROCESS PAID CANCELED SUCCESS"`
+	CreatedFrom   string `query:"createdFrom"`
+	CreatedTo     string `query:"createdTo"`
+	RestaurantId  int32  `query:"restaurantId"`
+	GroupOrderId  string `query:"groupOrderId" validate:"omitempty,uuid"`
+	UserId        string `query:"userId" validate:"omitempty,uuid"`
+	Username      string `query:"username"`
+	PaymentMethod string `query:"paymentMethod"`
+	SortBy        string `query:"sortBy" validate:"omitempty,oneof=createdAt total status"`
+	SortOrder     string `query:"sortOrder" validate:"omitempty,oneof=asc desc"`
+	Limit         int32  `query:"limit" validate:"min=0,max=100"`
+	Offset        int32  `query:"offset" validate:"min=0"`
+}
+
+type GetOrdersResponse struct {
+	Orders []AdminOrder
+	Total  int64
+}
+
+type AdminOrder struct {
+	Id            string
+	Items         []OrderItem
+	PaymentMethod string
+	Total         int32
+	Status        string
+	Wishes        string `json:",omitempty"`
+	CreatedAt     time.Time
+	UserId        string
+	Username      string
+	DeliverySlot  string `json:",omitempty"`
+	GroupOrderId  string `json:",omitempty"`
+	PromoCode     string `json:",omitempty"`
+	Discount      int32  `json:",omitempty"`
+	DeliveryFee   int32  `json:",omitempty"`
+	Refunded      int32  `json:",omitempty"`
+}
+
+type SetOrderStatusRequest struct {
+	Id     string `json:",omitempty" validate:"required"`
+	Status string `validate:"required,oneof=PROCESS PAID CANCELED SUCCESS"`
+}
+
+type CancelOrderItemsRequest struct {
+	Id string `json:",omitempty" validate:"required"`
+	// идентификаторы позиций заказа
+	ItemIds []int64 `validate:"required,min=1"`
+	Reason  string  `json:",omitempty"`
+}
+
+type CancelOrderItemsResponse struct {
+	// стоимость заказа после отмены
+	Total int32
+	// сумма возврата за отменённые блюда
+	Refund int32
+}
+
+type SetFulfilmentStatusRequest struct {
+	Id           string `json:",omitempty" validate:"required"`
+	RestaurantId int32  `validate:"required"`
+	Status       string `validate:"required,oneof=ACCEPTED READY DELIVERED CANCELED"`
+}
+
+type GetOrderStatusHistoryRequest struct {
+	Id string `validate:"required"`
+}
+
+type OrderStatusHistory struct {
+	OldStatus string `json:",omitempty"`
+	NewStatus string
+	Actor     string
+	Reason    string `json:",omitempty"`
+	CreatedAt time.Time
 }
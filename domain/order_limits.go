@@ -0,0 +1,37 @@
+package domain
+
+// OrderLimits лимиты заказов пользователя, суммы в минимальных единицах валюты, 0 - без ограничения
+type OrderLimits struct {
+	OrdersPerDay int32 `validate:"min=0"`
+	DailyTotal   int32 `validate:"min=0"`
+	MonthlyTotal int32 `validate:"min=0"`
+	// максимальное количество одного блюда в заказе
+	MaxDishCount int32 `validate:"min=0"`
+}
+
+type GetUserOrderLimitsRequest struct {
+	Id string `validate:"required,uuid"`
+}
+
+type UserOrderLimits struct {
+	UserId       string
+	OrdersPerDay int32
+	DailyTotal   int32
+	MonthlyTotal int32
+	MaxDishCount int32
+	// true, если для пользователя заданы свои лимиты вместо общих
+	Overridden bool
+}
+
+// SetUserOrderLimitsRequest лимиты пользователя полностью заменяют общие
+type SetUserOrderLimitsRequest struct {
+	Id           string `json:",omitempty" validate:"required,uuid"`
+	OrdersPerDay int32  `validate:"min=0"`
+	DailyTotal   int32  `validate:"min=0"`
+	MonthlyTotal int32  `validate:"min=0"`
+	MaxDishCount int32  `validate:"min=0"`
+}
+
+type DeleteUserOrderLimitsRequest struct {
+	Id string `validate:"required,uuid"`
+}
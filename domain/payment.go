@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type Payment struct {
+	Id      string
+	OrderId string
+	// способ оплаты заказа
+	Method string
+	// PENDING - ожидает оплаты, SUCCEEDED - оплачен, FAILED - не оплачен
+	Status string
+	// сумма в минимальных единицах валюты: копейки для RUB, звёзды для XTR
+	Amount   int64
+	Currency string
+	// идентификаторы платежа в Telegram и у платёжного провайдера, нужны для возврата и сверки
+	TelegramPaymentChargeId string `json:",omitempty"`
+	ProviderPaymentChargeId string `json:",omitempty"`
+	// исходные данные платежа от провайдера
+	Payload   json.RawMessage `json:",omitempty" swaggertype:"object"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type GetPaymentsRequest struct {
+	OrderId string `query:"orderId" validate:"omitempty,uuid"`
+	Method  string `query:"method"`
+	Status  string `query:"status" validate:"omitempty,oneof=PENDING SUCCEEDED FAILED"`
+	Limit   int32  `query:"limit" validate:"min=0,max=100"`
+	Offset  int32  `query:"offset" validate:"min=0"`
+}
+
+type GetPaymentsResponse struct {
+	Payments []Payment
+	Total    int64
+}
+
+type GetPaymentDiscrepanciesRequest struct {
+	// начало периода в формате RFC3339, по умолчанию сутки до конца периода
+	From string `query:"from"`
+	// конец периода в формате RFC3339, по умолчанию текущее время
+	To string `query:"to"`
+}
+
+type PaymentDiscrepanciesReport struct {
+	From          time.Time
+	To            time.Time
+	Discrepancies []PaymentDiscrepancy
+}
+
+type PaymentDiscrepancy struct {
+	// PAID_WITHOUT_PAYMENT - заказ оплачен, но платёж не записан,
+	// CANCELED_ORDER_PAYMENT - платёж по заказу, отменённому до оплаты,
+	// AMOUNT_MISMATCH - сумма платежа не совпадает с суммой заказа
+	Kind          string
+	OrderId       string
+	OrderStatus   string
+	PaymentMethod string
+	// сумма заказа с учётом возвратов за отменённые позиции в копейках
+	OrderAmount             int64
+	PaymentId               string `json:",omitempty"`
+	PaymentAmount           int64  `json:",omitempty"`
+	Currency                string `json:",omitempty"`
+	TelegramPaymentChargeId string `json:",omitempty"`
+	ProviderPaymentChargeId string `json:",omitempty"`
+	CreatedAt               time.Time
+}
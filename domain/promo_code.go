@@ -0,0 +1,43 @@
+package domain
+
+import "time"
+
+type PromoCode struct {
+	Id   int32
+	Code string
+	// PERCENT или FIXED
+	DiscountType string
+	// процент скидки или сумма скидки в минимальных единицах валюты
+	DiscountValue int32
+	ValidFrom     *time.Time `json:",omitempty"`
+	ValidTo       *time.Time `json:",omitempty"`
+	// 0 - без ограничения
+	TotalLimit    int32
+	PerUserLimit  int32
+	RestaurantIds []int32
+	CategoryIds   []int32
+	CreatedAt     time.Time
+}
+
+type AddPromoCodeRequest struct {
+	Code          string `validate:"required,max=64"`
+	DiscountType  string `validate:"required,oneof=PERCENT FIXED"`
+	DiscountValue int32  `validate:"required,min=1"`
+	// начало и конец действия в формате RFC3339, если не указаны - без ограничения
+	ValidFrom string `json:",omitempty"`
+	ValidTo   string `json:",omitempty"`
+	// 0 - без ограничения
+	TotalLimit   int32 `json:",omitempty" validate:"min=0"`
+	PerUserLimit int32 `json:",omitempty" validate:"min=0"`
+	// если не указаны, промокод действует на все блюда
+	RestaurantIds []int32 `json:",omitempty"`
+	CategoryIds   []int32 `json:",omitempty"`
+}
+
+type AddPromoCodeResponse struct {
+	Id int32
+}
+
+type DeletePromoCodeRequest struct {
+	Id int32 `validate:"required"`
+}
@@ -0,0 +1,53 @@
+package domain
+
+import "time"
+
+type RateOrderRequest struct {
+	Id string `json:",omitempty" validate:"required"`
+	// оценка заказа от 1 до 5
+	Score int32 `validate:"required,min=1,max=5"`
+	// оценки ресторанов заказа
+	Restaurants []RestaurantScore `json:",omitempty" validate:"dive"`
+	// если не указан, сохраняется прежний комментарий
+	Comment string `json:",omitempty" validate:"max=1024"`
+}
+
+type RestaurantScore struct {
+	RestaurantId int32 `validate:"required"`
+	Score        int32 `validate:"required,min=1,max=5"`
+}
+
+type GetOrderRatingRequest struct {
+	Id string `validate:"required"`
+}
+
+type OrderRating struct {
+	OrderId     string
+	Score       int32
+	Comment     string `json:",omitempty"`
+	Restaurants []RestaurantRating
+	CreatedAt   time.Time
+}
+
+type RestaurantRating struct {
+	RestaurantId   int32
+	RestaurantName string
+	Score          int32
+}
+
+type GetLowRatingsRequest struct {
+	// максимальная оценка заказа или ресторана, по умолчанию 2
+	MaxScore int32 `query:"maxScore" validate:"min=0,max=5"`
+	Limit    int32 `query:"limit" validate:"min=0,max=100"`
+	Offset   int32 `query:"offset" validate:"min=0"`
+}
+
+type LowRating struct {
+	OrderId     string
+	UserId      string
+	Username    string
+	Score       int32
+	Comment     string `json:",omitempty"`
+	Restaurants []RestaurantRating
+	CreatedAt   time.Time
+}
@@ -3,6 +3,14 @@ package domain
 type Restaurant struct {
 	Id   int32
 	Name string
+	// минимальная сумма заказа блюд ресторана в минимальных единицах валюты, 0 - без ограничения
+	MinOrderTotal int32
+	DeliveryFee   int32
+	// сумма заказа блюд ресторана, начиная с которой доставка бесплатна, 0 - доставка всегда платная
+	FreeDeliveryThreshold int32
+	// средняя оценка ресторана от 1 до 5, 0 - оценок нет
+	Rating       float64 `json:",omitempty"`
+	RatingsCount int64   `json:",omitempty"`
 }
 
 type AddRestaurantRequest struct {
@@ -18,6 +26,13 @@ type RenameRestaurantRequest struct {
 	Name string `validate:"required"`
 }
 
+type SetRestaurantTermsRequest struct {
+	Id                    int32 `validate:"required" json:",omitempty"`
+	MinOrderTotal         int32 `validate:"min=0"`
+	DeliveryFee           int32 `validate:"min=0"`
+	FreeDeliveryThreshold int32 `validate:"min=0"`
+}
+
 type DeleteRestaurantRequest struct {
 	Id int32
 }
@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// StarsRate курс Telegram Stars
+type StarsRate struct {
+	// стоимость одной звезды в копейках, 0 - оплата звёздами недоступна
+	StarPrice int32 `validate:"min=0"`
+	UpdatedAt time.Time
+}
+
+type SetStarsRateRequest struct {
+	// стоимость одной звезды в копейках, 0 - оплата звёздами недоступна
+	StarPrice int32 `validate:"min=0"`
+}
@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+type Wallet struct {
+	// баланс в копейках
+	Balance int64
+}
+
+type WalletTransaction struct {
+	Id int64
+	// TOP_UP, CHARGE, REFUND или ADJUSTMENT
+	Type string
+	// изменение баланса в копейках, отрицательное для оплат
+	Amount       int64
+	BalanceAfter int64
+	OrderId      string `json:",omitempty"`
+	Comment      string `json:",omitempty"`
+	CreatedAt    time.Time
+}
+
+type GetWalletTransactionsRequest struct {
+	Limit  int32 `query:"limit" validate:"min=0,max=100"`
+	Offset int32 `query:"offset" validate:"min=0"`
+}
+
+type TopUpWalletRequest struct {
+	// сумма пополнения в копейках
+	Amount int32 `validate:"required,min=1"`
+}
+
+type AdjustWalletRequest struct {
+	Id string `json:",omitempty" validate:"required,uuid"`
+	// изменение баланса в копейках, отрицательное для списания
+	Amount  int64  `validate:"required"`
+	Comment string `validate:"required,min=1"`
+}
@@ -0,0 +1,11 @@
+package entity
+
+type CartItem struct {
+	DishId         int32
+	Name           string
+	RestaurantName string
+	Count          int32
+	// price of the dish when it was put in the cart
+	Price        int32
+	CurrentPrice int32
+}
@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+type Company struct {
+	Id   int32
+	Name string
+	// maximum total of the company paid orders per calendar month, zero if not limited
+	CreditLimit int32
+	CreatedAt   time.Time
+}
+
+// CompanyOrder is the order charged to the company account
+type CompanyOrder struct {
+	Id        string
+	CreatedAt time.Time
+	Status    string
+	Username  string
+	Name      string
+	Total     int32
+	Refunded  int32
+}
@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const DeliverySlotTimeFormat = "15:04"
+
+type DeliverySlot struct {
+	Id        int32
+	Date      time.Time
+	StartTime string
+	EndTime   string
+	Capacity  int32
+	Booked    int32
+}
+
+func (s DeliverySlot) StartsAt() (time.Time, error) {
+	start, err := time.Parse(DeliverySlotTimeFormat, s.StartTime)
+	if err != nil {
+		return time.Time{}, errors.WithMessage(err, "parse start time")
+	}
+	return time.Date(
+		s.Date.Year(), s.Date.Month(), s.Date.Day(),
+		start.Hour(), start.Minute(), 0, 0,
+		time.Local,
+	), nil
+}
+
+type DeliverySlotOrder struct {
+	Id           string
+	Username     string
+	Total        int32
+	DeliverySlot string
+	Items        OrderItems
+}
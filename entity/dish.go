@@ -7,7 +7,11 @@ type Dish struct {
 	Price          int32
 	ImageId        string
 	Categories     string
+	RestaurantId   int32
 	RestaurantName string
+	// average rating of the orders with the dish, zero if there are no ratings
+	Rating       float64
+	RatingsCount int64
 }
 
 type InsertDish struct {
@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"github.com/Falokut/go-kit/json"
+	"github.com/pkg/errors"
+)
+
+type DishOptionGroup struct {
+	Id       int32
+	DishId   int32
+	Name     string
+	Required bool
+	MinCount int32
+	MaxCount int32
+	Options  DishOptions
+}
+
+type DishOption struct {
+	Id         int32
+	Name       string
+	PriceDelta int32
+}
+
+type DishOptions []DishOption
+
+func (o *DishOptions) Scan(value any) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.Errorf("failed to scan DishOptions: %v", value)
+	}
+	return json.Unmarshal(bytes, o) //nolint:wrapcheck
+}
+
+// OrderItemOption is a snapshot of the option chosen at the moment of ordering
+type OrderItemOption struct {
+	OptionId   int32
+	GroupName  string
+	Name       string
+	PriceDelta int32
+}
@@ -0,0 +1,32 @@
+package entity
+
+import "time"
+
+const (
+	GroupOrderStatusOpen   = "OPEN"
+	GroupOrderStatusLocked = "LOCKED"
+)
+
+const (
+	GroupOrderPaymentModeParticipants = "participants"
+	GroupOrderPaymentModeOrganizer    = "organizer"
+)
+
+type GroupOrder struct {
+	Id                string
+	OrganizerId       string
+	OrganizerUsername string
+	PaymentMode       string
+	Status            string
+	Wishes            string
+	CreatedAt         time.Time
+}
+
+type GroupOrderItem struct {
+	UserId   string
+	Username string
+	DishId   int32
+	Name     string
+	Price    int32
+	Count    int32
+}
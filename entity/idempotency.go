@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+type IdempotencyKey struct {
+	UserId      string
+	Key         string
+	RequestHash string
+	OrderId     string
+	PaymentUrl  string
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
@@ -11,6 +11,7 @@ import (
 )
 
 const DataFormat = "2006.01.02"
+const MonthFormat = "2006.01"
 const (
 	OrderItemStatusProcess  = "PROCESS"
 	OrderItemStatusCanceled = "CANCELED"
@@ -18,23 +19,89 @@ const (
 	OrderItemStatusSuccess  = "SUCCESS"
 )
 
+// refund statuses of the canceled paid order
+const (
+	RefundStatusPending  = "PENDING"
+	RefundStatusRefunded = "REFUNDED"
+	RefundStatusFailed   = "FAILED"
+)
+
+// OrderRefund is the refund of the paid order through its payment method
+type OrderRefund struct {
+	// the payment method doesn't refund the same id twice
+	Id     string
+	Amount int32
+	// the refund of the canceled items, the rest of the order stays paid
+	Partial bool
+}
+
+// statuses of a single item of the order
+const (
+	ItemStatusActive   = "ACTIVE"
+	ItemStatusCanceled = "CANCELED"
+)
+
 const (
 	NotifyArrivalCommand = "notify_arrival"
 	CancelOrderCommand   = "cancel_order"
 	SuccessOrderCommand  = "success_order"
+	// group commands pass group order id in QueryCallbackPayload.OrderId
+	NotifyGroupArrivalCommand = "notify_group_arrival"
+	CancelGroupOrderCommand   = "cancel_group_order"
+	// rating commands pass the score in QueryCallbackPayload.Argument,
+	// restaurant rating passes it as restaurantId:score
+	RateOrderCommand      = "rate_order"
+	RateRestaurantCommand = "rate_rest"
+	// cash commands are sent for the orders paid on pickup
+	NotifyCashArrivalCommand = "notify_cash_arrival"
+	CashPaidCommand          = "cash_paid"
+	CancelCashOrderCommand   = "cancel_cash_order"
 )
 
 type PaymentPayload struct {
 	ChatId  int64
 	OrderId string
+	// set instead of OrderId for the wallet top-up invoice
+	TopUpUserId string `json:",omitempty"`
+}
+
+// InvoiceMessage is the telegram message with the order invoice
+type InvoiceMessage struct {
+	ChatId    int64
+	MessageId int
 }
 
 type OrderItem struct {
+	Id             int64
 	DishId         int32
+	RestaurantId   int32
 	RestaurantName string
 	Count          int32
 	Price          int32
 	Name           string
+	Options        []OrderItemOption
+	Status         string
+}
+
+// OptionsString returns the chosen options separated by comma
+func (i OrderItem) OptionsString() string {
+	names := make([]string, len(i.Options))
+	for j, option := range i.Options {
+		names[j] = option.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+func (i OrderItem) IsCanceled() bool {
+	return i.Status == ItemStatusCanceled
+}
+
+// FullName returns the dish name with the chosen options
+func (i OrderItem) FullName() string {
+	if len(i.Options) == 0 {
+		return i.Name
+	}
+	return fmt.Sprintf("%s (%s)", i.Name, i.OptionsString())
 }
 
 type Order struct {
@@ -46,6 +113,24 @@ type Order struct {
 	CreatedAt     time.Time
 	Status        string
 	Wishes        string
+	// zero if delivery slot isn't selected
+	DeliverySlotId int32
+	DeliverySlot   string
+	// empty if order isn't a part of group order
+	GroupOrderId string
+	// zero if promo code isn't applied, Total includes the discount
+	PromoCodeId int32
+	PromoCode   string
+	Discount    int32
+	// Total includes the delivery fee
+	DeliveryFee int32
+	// refund for the canceled items, Total doesn't include them
+	Refunded int32
+	// charge ids of the payment, empty if the payment method doesn't provide them
+	PaymentChargeId  string
+	ProviderChargeId string
+	// empty if the order isn't refunded
+	RefundStatus string
 }
 type OrderToExport struct {
 	Id            string
@@ -55,6 +140,46 @@ type OrderToExport struct {
 	Total         int32
 	CreatedAt     time.Time
 	Status        string
+	DeliverySlot  string
+	GroupOrderId  string
+	PromoCode     string
+	Discount      int32
+	DeliveryFee   int32
+	Refunded      int32
+}
+
+type AdminOrder struct {
+	Id             string
+	PaymentMethod  string
+	Items          OrderItems
+	UserId         string
+	Username       string
+	Total          int32
+	CreatedAt      time.Time
+	Status         string
+	Wishes         string
+	DeliverySlotId int32
+	DeliverySlot   string
+	GroupOrderId   string
+	PromoCode      string
+	Discount       int32
+	DeliveryFee    int32
+	Refunded       int32
+}
+
+type OrdersFilter struct {
+	Status        string
+	UserId        string
+	Username      string
+	PaymentMethod string
+	RestaurantId  int32
+	GroupOrderId  string
+	CreatedFrom   *time.Time
+	CreatedTo     *time.Time
+	SortBy        string
+	SortOrder     string
+	Limit         int32
+	Offset        int32
 }
 
 type OrderItems []OrderItem
@@ -70,19 +195,28 @@ func (o *OrderItems) Scan(value any) error {
 type QueryCallbackPayload struct {
 	Command string
 	OrderId string
+	// optional command argument
+	Argument string
 }
 
 func (q QueryCallbackPayload) String() string {
-	return fmt.Sprintf("%s;%s", q.Command, q.OrderId)
+	if q.Argument == "" {
+		return fmt.Sprintf("%s;%s", q.Command, q.OrderId)
+	}
+	return fmt.Sprintf("%s;%s;%s", q.Command, q.OrderId, q.Argument)
 }
 
 func (q *QueryCallbackPayload) FromString(str string) error {
 	parts := strings.Split(str, ";")
 	// nolint:mnd
-	if len(parts) != 2 {
+	if len(parts) != 2 && len(parts) != 3 {
 		return errors.New("invalid query payload")
 	}
 	q.Command = parts[0]
 	q.OrderId = parts[1]
+	// nolint:mnd
+	if len(parts) == 3 {
+		q.Argument = parts[2]
+	}
 	return nil
 }
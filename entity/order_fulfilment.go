@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"time"
+)
+
+const (
+	FulfilmentStatusAccepted  = "ACCEPTED"
+	FulfilmentStatusReady     = "READY"
+	FulfilmentStatusDelivered = "DELIVERED"
+	FulfilmentStatusCanceled  = "CANCELED"
+)
+
+// OrderFulfilment is the part of the order fulfilled by a single restaurant
+type OrderFulfilment struct {
+	OrderId        string
+	RestaurantId   int32
+	RestaurantName string
+	Status         string
+	UpdatedAt      time.Time
+}
+
+func (f OrderFulfilment) IsFinished() bool {
+	return f.Status == FulfilmentStatusDelivered || f.Status == FulfilmentStatusCanceled
+}
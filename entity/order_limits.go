@@ -0,0 +1,35 @@
+package entity
+
+// OrderLimits are the limits of the user orders, zero if the limit isn't set
+type OrderLimits struct {
+	OrdersPerDay int32
+	DailyTotal   int32
+	MonthlyTotal int32
+	// maximum count of a single dish in the order
+	MaxDishCount int32
+}
+
+type UserOrderLimits struct {
+	UserId       string
+	OrdersPerDay int32
+	DailyTotal   int32
+	MonthlyTotal int32
+	MaxDishCount int32
+	// true if the user limits replace the global ones
+	Overridden bool
+}
+
+func (l UserOrderLimits) Limits() OrderLimits {
+	return OrderLimits{
+		OrdersPerDay: l.OrdersPerDay,
+		DailyTotal:   l.DailyTotal,
+		MonthlyTotal: l.MonthlyTotal,
+		MaxDishCount: l.MaxDishCount,
+	}
+}
+
+// UserOrdersStats are the count and the sum of the user not canceled orders for a period
+type UserOrdersStats struct {
+	Count int32
+	Total int64
+}
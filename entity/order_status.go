@@ -0,0 +1,34 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+const OrderActorSystem = "system"
+
+type OrderStatusChange struct {
+	OrderId string
+	// if not empty, the change is applied only when the order is in this status
+	From   string
+	To     string
+	Actor  string
+	Reason string
+}
+
+type OrderStatusHistory struct {
+	OrderId   string
+	OldStatus string
+	NewStatus string
+	Actor     string
+	Reason    string
+	CreatedAt time.Time
+}
+
+func UserActor(userId string) string {
+	return "user:" + userId
+}
+
+func TelegramActor(telegramId int64) string {
+	return fmt.Sprintf("telegram:%d", telegramId)
+}
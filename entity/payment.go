@@ -1,7 +1,101 @@
 package entity
 
-type ProcessPaymentRequest struct {
-	UserId  string
+import "time"
+
+const (
+	PaymentStatusPending   = "PENDING"
+	PaymentStatusSucceeded = "SUCCEEDED"
+	PaymentStatusFailed    = "FAILED"
+)
+
+const (
+	CurrencyRub   = "RUB"
+	CurrencyStars = "XTR"
+)
+
+// Payment is the attempt to pay the order and its outcome,
+// the amount is in the minimal units of the currency
+type Payment struct {
+	Id                      string
+	OrderId                 string
+	Method                  string
+	Status                  string
+	Amount                  int64
+	Currency                string
+	TelegramPaymentChargeId string
+	ProviderPaymentChargeId string
+	// raw json reported by the provider, empty for the methods without a provider
+	Payload   string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// PaymentInvoice is the payment requested from the user by the payment method,
+// the amount is in the minimal units of the currency
+type PaymentInvoice struct {
+	// payment page of the methods paid outside of telegram, empty for the others
+	Url      string
+	Amount   int64
+	Currency string
+}
+
+// RubInvoice is the invoice for the order total in rubles
+func RubInvoice(order *Order, url string) PaymentInvoice {
+	return PaymentInvoice{
+		Url:      url,
+		Amount:   int64(order.Total),
+		Currency: CurrencyRub,
+	}
+}
+
+// PaymentResult is the outcome of the payment reported by the provider,
+// the zero amount and the empty currency keep the values of the attempt
+type PaymentResult struct {
+	Amount                  int64
+	Currency                string
+	TelegramPaymentChargeId string
+	ProviderPaymentChargeId string
+	Payload                 string
+}
+
+// OrderPayment is the payment reported together with the order status change
+type OrderPayment struct {
+	// charge ids of the payment, stored when the order becomes PAID
+	ChargeId         string
+	ProviderChargeId string
+	// outcome reported by the payment provider, stored in the payments ledger
+	Result PaymentResult
+}
+
+type PaymentsFilter struct {
 	OrderId string
-	Total   int32
+	Method  string
+	Status  string
+	Limit   int32
+	Offset  int32
+}
+
+const (
+	// the order is paid, but the payments ledger has no succeeded payment for it
+	DiscrepancyPaidWithoutPayment = "PAID_WITHOUT_PAYMENT"
+	// the payment succeeded for the order canceled before it was paid, e.g. expired
+	DiscrepancyCanceledOrderPayment = "CANCELED_ORDER_PAYMENT"
+	// the paid amount differs from the order total with the refunds of the canceled items
+	DiscrepancyAmountMismatch = "AMOUNT_MISMATCH"
+)
+
+type PaymentDiscrepancy struct {
+	Kind          string
+	OrderId       string
+	OrderStatus   string
+	PaymentMethod string
+	// order total with the refunds of the canceled items in kopecks
+	OrderAmount int64
+	// payment fields are empty for PAID_WITHOUT_PAYMENT
+	PaymentId               string
+	PaymentAmount           int64
+	Currency                string
+	TelegramPaymentChargeId string
+	ProviderPaymentChargeId string
+	CreatedAt               time.Time
 }
@@ -0,0 +1,12 @@
+package entity
+
+import "time"
+
+// PaymentSession is the payment created at the payment gateway for the order
+type PaymentSession struct {
+	Id        string
+	OrderId   string
+	Amount    int64
+	Url       string
+	CreatedAt time.Time
+}
@@ -0,0 +1,59 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/Falokut/go-kit/json"
+	"github.com/pkg/errors"
+)
+
+const (
+	PromoCodeDiscountPercent = "PERCENT"
+	PromoCodeDiscountFixed   = "FIXED"
+)
+
+const maxPercent = 100
+
+type PromoCode struct {
+	Id            int32
+	Code          string
+	DiscountType  string
+	DiscountValue int32
+	// nil if period isn't limited
+	ValidFrom *time.Time
+	ValidTo   *time.Time
+	// zero if usages aren't limited
+	TotalLimit    int32
+	PerUserLimit  int32
+	RestaurantIds Ids
+	CategoryIds   Ids
+	CreatedAt     time.Time
+}
+
+func (p PromoCode) IsActive(now time.Time) bool {
+	if p.ValidFrom != nil && now.Before(*p.ValidFrom) {
+		return false
+	}
+	if p.ValidTo != nil && now.After(*p.ValidTo) {
+		return false
+	}
+	return true
+}
+
+// Discount returns the discount for the sum of the eligible order items
+func (p PromoCode) Discount(eligibleTotal int32) int32 {
+	if p.DiscountType == PromoCodeDiscountPercent {
+		return int32(int64(eligibleTotal) * int64(p.DiscountValue) / maxPercent)
+	}
+	return min(p.DiscountValue, eligibleTotal)
+}
+
+type Ids []int32
+
+func (ids *Ids) Scan(value any) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.Errorf("failed to scan Ids: %v", value)
+	}
+	return json.Unmarshal(bytes, ids) //nolint:wrapcheck
+}
@@ -0,0 +1,49 @@
+// nolint:recvcheck
+package entity
+
+import (
+	"time"
+
+	"github.com/Falokut/go-kit/json"
+	"github.com/pkg/errors"
+)
+
+const (
+	MinRatingScore = 1
+	MaxRatingScore = 5
+)
+
+type OrderRating struct {
+	OrderId   string
+	Score     int32
+	Comment   string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type RestaurantRating struct {
+	RestaurantId   int32
+	RestaurantName string
+	Score          int32
+}
+
+type RestaurantRatings []RestaurantRating
+
+func (r *RestaurantRatings) Scan(value any) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.Errorf("failed to scan RestaurantRatings: %v", value)
+	}
+	return json.Unmarshal(bytes, r) //nolint:wrapcheck
+}
+
+// LowRating is the order rating with a low score of the order or one of its restaurants
+type LowRating struct {
+	OrderId     string
+	UserId      string
+	Username    string
+	Score       int32
+	Comment     string
+	CreatedAt   time.Time
+	Restaurants RestaurantRatings
+}
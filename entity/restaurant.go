@@ -3,4 +3,25 @@ package entity
 type Restaurant struct {
 	Id   int32
 	Name string
+	// amounts in minimal currency units, zero if the term isn't set
+	MinOrderTotal         int32
+	DeliveryFee           int32
+	FreeDeliveryThreshold int32
+	// average restaurant rating, zero if there are no ratings
+	Rating       float64
+	RatingsCount int64
+}
+
+// DeliveryFeeFor returns the delivery fee for the order subtotal of the restaurant dishes
+func (r Restaurant) DeliveryFeeFor(subtotal int32) int32 {
+	if r.FreeDeliveryThreshold > 0 && subtotal >= r.FreeDeliveryThreshold {
+		return 0
+	}
+	return r.DeliveryFee
+}
+
+type RestaurantTerms struct {
+	MinOrderTotal         int32
+	DeliveryFee           int32
+	FreeDeliveryThreshold int32
 }
@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+type StarsRate struct {
+	// price of one star in kopecks, zero if paying with stars is unavailable
+	StarPrice int32
+	UpdatedAt time.Time
+}
+
+// StarsAmount converts the amount in kopecks to stars rounding up
+func (r StarsRate) StarsAmount(amount int32) int32 {
+	return (amount + r.StarPrice - 1) / r.StarPrice
+}
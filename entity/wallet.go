@@ -0,0 +1,33 @@
+package entity
+
+import "time"
+
+// types of the wallet ledger records
+const (
+	WalletTransactionTopUp      = "TOP_UP"
+	WalletTransactionCharge     = "CHARGE"
+	WalletTransactionRefund     = "REFUND"
+	WalletTransactionAdjustment = "ADJUSTMENT"
+)
+
+type Wallet struct {
+	UserId    string
+	Balance   int64
+	UpdatedAt time.Time
+}
+
+type WalletTransaction struct {
+	Id     int64
+	UserId string
+	Type   string
+	// positive for top-ups and refunds, negative for charges
+	Amount       int64
+	BalanceAfter int64
+	// empty if the record isn't bound to an order
+	OrderId string
+	// telegram payment charge id of the top-up
+	ExternalId string
+	Actor      string
+	Comment    string
+	CreatedAt  time.Time
+}
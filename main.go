@@ -11,11 +11,11 @@ import (
 )
 
 var (
-	version = "1.0.0"
+	version = "1.1.0"
 )
 
 // @title						dishes-service-backend
-// @version					1.0.0
+// @version					1.1.0
 // @description				Сервис для заказа еды
 // @BasePath					/api/dishes-service-backend
 //
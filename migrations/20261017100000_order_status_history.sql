@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE order_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    order_id uuid NOT NULL REFERENCES orders (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    -- NULL для первой записи при создании заказа
+    old_status TEXT,
+    new_status TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX ix_order_status_history__order_id ON order_status_history (order_id, created_at);
+
+INSERT INTO
+    order_status_history (order_id, new_status, actor, reason, created_at)
+SELECT id, status, 'system', 'migration', created_at
+FROM orders;
+
+-- +goose Down
+DROP TABLE order_status_history;
@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE order_idempotency_keys (
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    key TEXT NOT NULL,
+    -- sha256 тела запроса, повтор с тем же ключом и другим телом отклоняется
+    request_hash TEXT NOT NULL,
+    order_id uuid NOT NULL REFERENCES orders (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    payment_url TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+
+-- +goose Down
+DROP TABLE order_idempotency_keys;
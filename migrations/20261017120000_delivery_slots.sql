@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE delivery_slots (
+    id SERIAL PRIMARY KEY,
+    date DATE NOT NULL,
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL CHECK (end_time > start_time),
+    -- максимальное количество неотменённых заказов в слоте
+    capacity INT NOT NULL CHECK (capacity > 0),
+    UNIQUE (date, start_time)
+);
+
+ALTER TABLE orders
+ADD COLUMN delivery_slot_id INT REFERENCES delivery_slots (id) ON DELETE SET NULL ON UPDATE CASCADE;
+
+CREATE INDEX orders_delivery_slot_id_idx ON orders (delivery_slot_id);
+
+-- +goose Down
+DROP INDEX orders_delivery_slot_id_idx;
+
+ALTER TABLE orders DROP COLUMN delivery_slot_id;
+
+DROP TABLE delivery_slots;
@@ -0,0 +1,33 @@
+-- +goose Up
+CREATE TABLE group_orders (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid (),
+    organizer_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    -- participants - каждый участник оплачивает свою часть, organizer - организатор оплачивает весь заказ
+    payment_mode TEXT NOT NULL,
+    status TEXT NOT NULL,
+    wishes TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    locked_at TIMESTAMPTZ
+);
+
+CREATE TABLE group_order_items (
+    group_order_id uuid NOT NULL REFERENCES group_orders (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    dish_id INT NOT NULL REFERENCES dish (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    count INT NOT NULL CHECK (count > 0),
+    PRIMARY KEY (group_order_id, user_id, dish_id)
+);
+
+ALTER TABLE orders
+ADD COLUMN group_order_id uuid REFERENCES group_orders (id) ON DELETE SET NULL ON UPDATE CASCADE;
+
+CREATE INDEX orders_group_order_id_idx ON orders (group_order_id);
+
+-- +goose Down
+DROP INDEX orders_group_order_id_idx;
+
+ALTER TABLE orders DROP COLUMN group_order_id;
+
+DROP TABLE group_order_items;
+
+DROP TABLE group_orders;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE carts (
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    dish_id INT NOT NULL REFERENCES dish (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    count INT NOT NULL CHECK (count > 0),
+    -- цена блюда на момент добавления в корзину, при оформлении сверяется с текущей
+    price INT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, dish_id)
+);
+
+-- +goose Down
+DROP TABLE carts;
@@ -0,0 +1,49 @@
+-- +goose Up
+CREATE TABLE promo_codes (
+    id SERIAL PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE,
+    discount_type TEXT NOT NULL CHECK (discount_type IN ('PERCENT', 'FIXED')),
+    -- процент скидки или сумма скидки в минимальных единицах валюты
+    discount_value INT NOT NULL CHECK (discount_value > 0),
+    valid_from TIMESTAMPTZ,
+    valid_to TIMESTAMPTZ CHECK (valid_to > valid_from),
+    -- NULL - без ограничения количества использований
+    total_limit INT CHECK (total_limit > 0),
+    per_user_limit INT CHECK (per_user_limit > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- если ограничения не заданы, промокод действует на все блюда
+CREATE TABLE promo_code_restaurants (
+    promo_code_id INT NOT NULL REFERENCES promo_codes (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    restaurant_id INT NOT NULL REFERENCES restaurants (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    PRIMARY KEY (promo_code_id, restaurant_id)
+);
+
+CREATE TABLE promo_code_categories (
+    promo_code_id INT NOT NULL REFERENCES promo_codes (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    category_id INT NOT NULL REFERENCES categories (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    PRIMARY KEY (promo_code_id, category_id)
+);
+
+ALTER TABLE orders
+ADD COLUMN promo_code_id INT REFERENCES promo_codes (id) ON DELETE SET NULL ON UPDATE CASCADE,
+ADD COLUMN promo_code TEXT,
+-- скидка в минимальных единицах валюты, total указан с её учётом
+ADD COLUMN discount INT NOT NULL DEFAULT 0 CHECK (discount >= 0);
+
+CREATE INDEX orders_promo_code_id_idx ON orders (promo_code_id);
+
+-- +goose Down
+DROP INDEX orders_promo_code_id_idx;
+
+ALTER TABLE orders
+DROP COLUMN discount,
+DROP COLUMN promo_code,
+DROP COLUMN promo_code_id;
+
+DROP TABLE promo_code_categories;
+
+DROP TABLE promo_code_restaurants;
+
+DROP TABLE promo_codes;
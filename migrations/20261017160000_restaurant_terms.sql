@@ -0,0 +1,18 @@
+-- +goose Up
+-- суммы в минимальных единицах валюты, 0 - условие не задано
+ALTER TABLE restaurants
+ADD COLUMN min_order_total INT NOT NULL DEFAULT 0 CHECK (min_order_total >= 0),
+ADD COLUMN delivery_fee INT NOT NULL DEFAULT 0 CHECK (delivery_fee >= 0),
+ADD COLUMN free_delivery_threshold INT NOT NULL DEFAULT 0 CHECK (free_delivery_threshold >= 0);
+
+-- стоимость доставки, total указан с её учётом
+ALTER TABLE orders
+ADD COLUMN delivery_fee INT NOT NULL DEFAULT 0 CHECK (delivery_fee >= 0);
+
+-- +goose Down
+ALTER TABLE orders DROP COLUMN delivery_fee;
+
+ALTER TABLE restaurants
+DROP COLUMN free_delivery_threshold,
+DROP COLUMN delivery_fee,
+DROP COLUMN min_order_total;
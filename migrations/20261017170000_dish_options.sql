@@ -0,0 +1,45 @@
+-- +goose Up
+CREATE TABLE dish_option_groups (
+    id SERIAL PRIMARY KEY,
+    dish_id INT NOT NULL REFERENCES dish (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    name TEXT NOT NULL,
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    -- количество опций, которое можно выбрать в группе, если группа выбрана
+    min_count INT NOT NULL CHECK (min_count >= 0),
+    max_count INT NOT NULL CHECK (max_count >= min_count AND max_count > 0)
+);
+
+CREATE INDEX dish_option_groups_dish_id_idx ON dish_option_groups (dish_id);
+
+CREATE TABLE dish_options (
+    id SERIAL PRIMARY KEY,
+    group_id INT NOT NULL REFERENCES dish_option_groups (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    name TEXT NOT NULL,
+    -- изменение цены блюда в минимальных единицах валюты
+    price_delta INT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX dish_options_group_id_idx ON dish_options (group_id);
+
+-- одно блюдо может быть заказано несколько раз с разными опциями
+ALTER TABLE order_items DROP CONSTRAINT order_items_pkey;
+
+ALTER TABLE order_items
+ADD COLUMN id BIGSERIAL PRIMARY KEY,
+-- выбранные опции на момент заказа
+ADD COLUMN options JSONB NOT NULL DEFAULT '[]';
+
+CREATE INDEX order_items_order_id_idx ON order_items (order_id);
+
+-- +goose Down
+DROP INDEX order_items_order_id_idx;
+
+ALTER TABLE order_items
+DROP COLUMN options,
+DROP COLUMN id;
+
+ALTER TABLE order_items ADD PRIMARY KEY (order_id, dish_id);
+
+DROP TABLE dish_options;
+
+DROP TABLE dish_option_groups;
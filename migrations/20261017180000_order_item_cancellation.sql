@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE order_items
+ADD COLUMN status TEXT NOT NULL DEFAULT 'ACTIVE';
+
+-- сумма возврата за отменённые блюда, total указан с её учётом
+ALTER TABLE orders
+ADD COLUMN refunded INT NOT NULL DEFAULT 0 CHECK (refunded >= 0);
+
+-- +goose Down
+ALTER TABLE orders DROP COLUMN refunded;
+
+ALTER TABLE order_items DROP COLUMN status;
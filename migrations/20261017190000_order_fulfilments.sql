@@ -0,0 +1,26 @@
+-- +goose Up
+-- часть заказа, которую выполняет один ресторан
+CREATE TABLE order_fulfilments (
+    order_id uuid NOT NULL REFERENCES orders (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    restaurant_id INT NOT NULL REFERENCES restaurants (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    status TEXT NOT NULL DEFAULT 'ACCEPTED',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (order_id, restaurant_id)
+);
+
+INSERT INTO order_fulfilments (order_id, restaurant_id, status)
+SELECT DISTINCT
+    o.id,
+    d.restaurant_id,
+    CASE o.status
+        WHEN 'SUCCESS' THEN 'DELIVERED'
+        WHEN 'CANCELED' THEN 'CANCELED'
+        ELSE 'ACCEPTED'
+    END
+FROM orders o
+JOIN order_items oi ON o.id = oi.order_id
+JOIN dish d ON oi.dish_id = d.id
+WHERE d.restaurant_id IS NOT NULL;
+
+-- +goose Down
+DROP TABLE order_fulfilments;
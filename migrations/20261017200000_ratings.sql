@@ -0,0 +1,53 @@
+-- +goose Up
+CREATE TABLE order_ratings (
+    order_id uuid PRIMARY KEY REFERENCES orders (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    score INT NOT NULL CHECK (score BETWEEN 1 AND 5),
+    comment TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE restaurant_ratings (
+    order_id uuid NOT NULL REFERENCES orders (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    restaurant_id INT NOT NULL REFERENCES restaurants (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    score INT NOT NULL CHECK (score BETWEEN 1 AND 5),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (order_id, restaurant_id)
+);
+
+CREATE INDEX restaurant_ratings_restaurant_id_idx ON restaurant_ratings (restaurant_id);
+
+CREATE INDEX order_items_dish_id_idx ON order_items (dish_id);
+
+CREATE VIEW restaurant_rating_stats AS
+SELECT
+    restaurant_id,
+    round(avg(score), 2)::float8 AS rating,
+    count(*) AS ratings_count
+FROM restaurant_ratings
+GROUP BY restaurant_id;
+
+-- оценка блюда - средняя оценка заказов, в которых оно не было отменено
+CREATE VIEW dish_rating_stats AS
+SELECT
+    oi.dish_id,
+    round(avg(orr.score), 2)::float8 AS rating,
+    count(*) AS ratings_count
+FROM order_ratings orr
+JOIN (
+    SELECT DISTINCT order_id, dish_id
+    FROM order_items
+    WHERE status = 'ACTIVE'
+) oi ON orr.order_id = oi.order_id
+GROUP BY oi.dish_id;
+
+-- +goose Down
+DROP VIEW dish_rating_stats;
+
+DROP VIEW restaurant_rating_stats;
+
+DROP INDEX order_items_dish_id_idx;
+
+DROP TABLE restaurant_ratings;
+
+DROP TABLE order_ratings;
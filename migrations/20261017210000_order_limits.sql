@@ -0,0 +1,29 @@
+-- +goose Up
+-- лимиты заказов пользователя, суммы в минимальных единицах валюты, 0 - без ограничения
+CREATE TABLE order_limits (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    orders_per_day INT NOT NULL DEFAULT 0 CHECK (orders_per_day >= 0),
+    daily_total INT NOT NULL DEFAULT 0 CHECK (daily_total >= 0),
+    monthly_total INT NOT NULL DEFAULT 0 CHECK (monthly_total >= 0),
+    max_dish_count INT NOT NULL DEFAULT 0 CHECK (max_dish_count >= 0)
+);
+
+INSERT INTO order_limits DEFAULT VALUES;
+
+-- лимиты пользователя полностью заменяют общие
+CREATE TABLE user_order_limits (
+    user_id uuid PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    orders_per_day INT NOT NULL DEFAULT 0 CHECK (orders_per_day >= 0),
+    daily_total INT NOT NULL DEFAULT 0 CHECK (daily_total >= 0),
+    monthly_total INT NOT NULL DEFAULT 0 CHECK (monthly_total >= 0),
+    max_dish_count INT NOT NULL DEFAULT 0 CHECK (max_dish_count >= 0)
+);
+
+CREATE INDEX orders_user_id_created_at_idx ON orders (user_id, created_at);
+
+-- +goose Down
+DROP INDEX orders_user_id_created_at_idx;
+
+DROP TABLE user_order_limits;
+
+DROP TABLE order_limits;
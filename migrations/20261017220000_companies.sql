@@ -0,0 +1,30 @@
+-- +goose Up
+-- credit_limit - максимальная сумма оплаченных заказов компании за календарный месяц, 0 - без ограничения
+CREATE TABLE companies (
+    id SERIAL PRIMARY KEY,
+    name TEXT UNIQUE NOT NULL,
+    credit_limit INT NOT NULL DEFAULT 0 CHECK (credit_limit >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE company_users (
+    user_id uuid PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    company_id INT NOT NULL REFERENCES companies (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX company_users_company_id_idx ON company_users (company_id);
+
+-- компания, на счёт которой оплачен заказ
+ALTER TABLE orders
+ADD COLUMN company_id INT REFERENCES companies (id) ON UPDATE CASCADE;
+
+CREATE INDEX orders_company_id_created_at_idx ON orders (company_id, created_at);
+
+-- +goose Down
+DROP INDEX orders_company_id_created_at_idx;
+
+ALTER TABLE orders DROP COLUMN company_id;
+
+DROP TABLE company_users;
+
+DROP TABLE companies;
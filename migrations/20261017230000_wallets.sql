@@ -0,0 +1,50 @@
+-- +goose Up
+CREATE TABLE wallets (
+    user_id uuid PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    balance BIGINT NOT NULL DEFAULT 0 CHECK (balance >= 0),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- журнал движений по кошельку, только добавление записей
+-- amount - изменение баланса: положительное для пополнений и возвратов, отрицательное для оплат
+-- external_id - идентификатор платежа telegram для пополнений
+CREATE TABLE wallet_transactions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    type TEXT NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount <> 0),
+    balance_after BIGINT NOT NULL CHECK (balance_after >= 0),
+    order_id uuid REFERENCES orders (id) ON DELETE SET NULL ON UPDATE CASCADE,
+    external_id TEXT UNIQUE,
+    actor TEXT NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX wallet_transactions_user_id_idx ON wallet_transactions (user_id, id DESC);
+
+-- каскадные изменения от внешних ключей пропускаются, прямые изменения запрещены
+-- +goose StatementBegin
+CREATE FUNCTION wallet_transactions_append_only() RETURNS trigger AS $$
+BEGIN
+    IF pg_trigger_depth() > 1 THEN
+        IF TG_OP = 'DELETE' THEN
+            RETURN OLD;
+        END IF;
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'wallet_transactions is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER wallet_transactions_append_only
+BEFORE UPDATE OR DELETE ON wallet_transactions
+FOR EACH ROW EXECUTE FUNCTION wallet_transactions_append_only();
+
+-- +goose Down
+DROP TABLE wallet_transactions;
+
+DROP FUNCTION wallet_transactions_append_only;
+
+DROP TABLE wallets;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- платёжные сессии шлюза, id - идентификатор платежа у провайдера
+-- сессия создаётся до фиксации заказа, поэтому внешнего ключа на orders нет
+CREATE TABLE payment_sessions (
+    id TEXT PRIMARY KEY,
+    order_id uuid NOT NULL,
+    amount BIGINT NOT NULL,
+    url TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX payment_sessions_order_id_idx ON payment_sessions (order_id);
+
+-- +goose Down
+DROP TABLE payment_sessions;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- payment_charge_id - идентификатор списания в платёжном методе, provider_charge_id - идентификатор платежа у стоящего за ним эквайера
+ALTER TABLE orders
+    ADD COLUMN payment_charge_id TEXT,
+    ADD COLUMN provider_charge_id TEXT,
+    ADD COLUMN refund_status TEXT;
+
+-- +goose Down
+ALTER TABLE orders
+    DROP COLUMN payment_charge_id,
+    DROP COLUMN provider_charge_id,
+    DROP COLUMN refund_status;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- курс Telegram Stars: стоимость одной звезды в копейках, 0 - оплата звёздами недоступна
+CREATE TABLE stars_rate (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    star_price INT NOT NULL DEFAULT 0 CHECK (star_price >= 0),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO stars_rate DEFAULT VALUES;
+
+-- +goose Down
+DROP TABLE stars_rate;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- журнал платежей: каждая попытка оплаты заказа и её результат
+CREATE TABLE payments (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid (),
+    order_id uuid NOT NULL REFERENCES orders (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    method TEXT NOT NULL,
+    status TEXT NOT NULL,
+    amount BIGINT NOT NULL,
+    currency TEXT NOT NULL,
+    telegram_payment_charge_id TEXT,
+    provider_payment_charge_id TEXT,
+    payload JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX payments_order_id_idx ON payments (order_id, created_at);
+CREATE INDEX payments_created_at_idx ON payments (created_at);
+
+-- +goose Down
+DROP TABLE payments;
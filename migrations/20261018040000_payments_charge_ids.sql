@@ -0,0 +1,10 @@
+-- +goose Up
+-- платёж провайдера записывается в журнал один раз, в том числе при повторных уведомлениях
+CREATE UNIQUE INDEX payments_telegram_payment_charge_id_uidx ON payments (telegram_payment_charge_id)
+WHERE telegram_payment_charge_id IS NOT NULL;
+CREATE UNIQUE INDEX payments_provider_payment_charge_id_uidx ON payments (provider_payment_charge_id)
+WHERE provider_payment_charge_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX payments_provider_payment_charge_id_uidx;
+DROP INDEX payments_telegram_payment_charge_id_uidx;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- сообщение со счётом в Telegram, удаляется когда заказ отменяется по истечении срока оплаты
+ALTER TABLE orders
+    ADD COLUMN invoice_chat_id BIGINT,
+    ADD COLUMN invoice_message_id INT;
+
+-- +goose Down
+ALTER TABLE orders
+    DROP COLUMN invoice_message_id,
+    DROP COLUMN invoice_chat_id;
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/Falokut/go-kit/db"
+	"github.com/pkg/errors"
+)
+
+type Cart struct {
+	cli db.DB
+}
+
+func NewCart(cli db.DB) Cart {
+	return Cart{
+		cli: cli,
+	}
+}
+
+func (r Cart) GetCartItems(ctx context.Context, userId string) ([]entity.CartItem, error) {
+	query := `
+	SELECT
+		c.dish_id,
+		d.name,
+		r.name AS restaurant_name,
+		c.count,
+		c.price,
+		d.price AS current_price
+	FROM carts c
+	JOIN dish d ON c.dish_id = d.id
+	JOIN restaurants AS r ON d.restaurant_id = r.id
+	WHERE c.user_id = $1
+	ORDER BY c.dish_id`
+	var items []entity.CartItem
+	err := r.cli.Select(ctx, &items, query, userId)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return items, nil
+}
+
+func (r Cart) AddCartItem(ctx context.Context, userId string, dishId int32, count int32, price int32) error {
+	query := `
+	INSERT INTO carts (user_id, dish_id, count, price, updated_at)
+	VALUES ($1, $2, $3, $4, now())
+	ON CONFLICT (user_id, dish_id) DO UPDATE
+	SET count = carts.count + EXCLUDED.count,
+		price = EXCLUDED.price,
+		updated_at = EXCLUDED.updated_at`
+	_, err := r.cli.Exec(ctx, query, userId, dishId, count, price)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
+func (r Cart) SetCartItemCount(ctx context.Context, userId string, dishId int32, count int32, price int32) error {
+	query := `
+	UPDATE carts
+	SET count = $3, price = $4, updated_at = now()
+	WHERE user_id = $1 AND dish_id = $2`
+	res, err := r.cli.Exec(ctx, query, userId, dishId, count, price)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	affected, _ := res.RowsAffected()
+	if affected == 0 {
+		return domain.ErrCartItemNotFound
+	}
+	return nil
+}
+
+func (r Cart) DeleteCartItem(ctx context.Context, userId string, dishId int32) error {
+	const query = "DELETE FROM carts WHERE user_id=$1 AND dish_id=$2"
+	_, err := r.cli.Exec(ctx, query, userId, dishId)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
+func (r Cart) ClearCart(ctx context.Context, userId string) error {
+	const query = "DELETE FROM carts WHERE user_id=$1"
+	_, err := r.cli.Exec(ctx, query, userId)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
+func (r Cart) RefreshCartPrices(ctx context.Context, userId string) error {
+	query := `
+	UPDATE carts c
+	SET price = d.price
+	FROM dish d
+	WHERE c.dish_id = d.id AND c.user_id = $1`
+	_, err := r.cli.Exec(ctx, query, userId)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
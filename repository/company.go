@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/Falokut/go-kit/db"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+)
+
+type Company struct {
+	cli db.DB
+}
+
+func NewCompany(cli db.DB) Company {
+	return Company{
+		cli: cli,
+	}
+}
+
+func (r Company) GetCompanies(ctx context.Context) ([]entity.Company, error) {
+	query := "SELECT id, name, credit_limit, created_at FROM companies ORDER BY name"
+	var companies []entity.Company
+	err := r.cli.Select(ctx, &companies, query)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return companies, nil
+}
+
+func (r Company) GetCompany(ctx context.Context, id int32) (entity.Company, error) {
+	return r.getCompany(ctx, "SELECT id, name, credit_limit, created_at FROM companies WHERE id=$1", id)
+}
+
+// GetCompanyForUpdate locks the company until the end of the transaction,
+// so concurrent charges can't exceed its credit limit
+func (r Company) GetCompanyForUpdate(ctx context.Context, id int32) (entity.Company, error) {
+	return r.getCompany(ctx, "SELECT id, name, credit_limit, created_at FROM companies WHERE id=$1 FOR UPDATE", id)
+}
+
+func (r Company) getCompany(ctx context.Context, query string, id int32) (entity.Company, error) {
+	var company entity.Company
+	err := r.cli.SelectRow(ctx, &company, query, id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return entity.Company{}, domain.ErrCompanyNotFound
+	case err != nil:
+		return entity.Company{}, errors.WithMessagef(err, "exec query '%s'", query)
+	default:
+		return company, nil
+	}
+}
+
+func (r Company) GetUserCompany(ctx context.Context, userId string) (entity.Company, error) {
+	query := `
+	SELECT c.id, c.name, c.credit_limit, c.created_at
+	FROM company_users cu
+	JOIN companies c ON cu.company_id = c.id
+	WHERE cu.user_id=$1`
+	var company entity.Company
+	err := r.cli.SelectRow(ctx, &company, query, userId)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return entity.Company{}, domain.ErrUserCompanyNotFound
+	case err != nil:
+		return entity.Company{}, errors.WithMessagef(err, "exec query '%s'", query)
+	default:
+		return company, nil
+	}
+}
+
+func (r Company) InsertCompany(ctx context.Context, name string, creditLimit int32) (int32, error) {
+	query := "INSERT INTO companies(name, credit_limit) VALUES($1,$2) RETURNING id"
+	var id int32
+	err := r.cli.SelectRow(ctx, &id, query, name, creditLimit)
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &pgErr) && pgErr.SQLState() == pgerrcode.UniqueViolation:
+		return 0, domain.ErrCompanyConflict
+	case err != nil:
+		return 0, errors.WithMessagef(err, "exec query '%s'", query)
+	default:
+		return id, nil
+	}
+}
+
+func (r Company) UpdateCompany(ctx context.Context, id int32, name string, creditLimit int32) error {
+	query := "UPDATE companies SET name=$1, credit_limit=$2 WHERE id=$3"
+	res, err := r.cli.Exec(ctx, query, name, creditLimit, id)
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &pgErr) && pgErr.SQLState() == pgerrcode.UniqueViolation:
+		return domain.ErrCompanyConflict
+	case err != nil:
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	affected, _ := res.RowsAffected()
+	if affected == 0 {
+		return domain.ErrCompanyNotFound
+	}
+	return nil
+}
+
+// UpsertCompanyUser binds the user to the company, the user can belong to a single company
+func (r Company) UpsertCompanyUser(ctx context.Context, companyId int32, userId string) error {
+	query := `
+	INSERT INTO company_users(user_id, company_id) VALUES($1,$2)
+	ON CONFLICT (user_id) DO UPDATE SET company_id=EXCLUDED.company_id`
+	_, err := r.cli.Exec(ctx, query, userId, companyId)
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &pgErr) && pgErr.SQLState() == pgerrcode.ForeignKeyViolation &&
+		pgErr.ConstraintName == "company_users_user_id_fkey":
+		return domain.ErrUserNotFound
+	case errors.As(err, &pgErr) && pgErr.SQLState() == pgerrcode.ForeignKeyViolation:
+		return domain.ErrCompanyNotFound
+	case err != nil:
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	default:
+		return nil
+	}
+}
+
+func (r Company) DeleteCompanyUser(ctx context.Context, userId string) error {
+	query := "DELETE FROM company_users WHERE user_id=$1"
+	_, err := r.cli.Exec(ctx, query, userId)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
+// GetCompanyOrdersTotal returns the total of the company paid orders created since from
+func (r Company) GetCompanyOrdersTotal(ctx context.Context, companyId int32, from time.Time) (int64, error) {
+	query := `
+	SELECT COALESCE(sum(total), 0)
+	FROM orders
+	WHERE company_id=$1 AND created_at>=$2 AND status IN ($3,$4)`
+	var total int64
+	err := r.cli.SelectRow(ctx, &total, query, companyId, from, entity.OrderItemStatusPaid, entity.OrderItemStatusSuccess)
+	if err != nil {
+		return 0, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return total, nil
+}
+
+// GetCompanyOrders returns the company paid orders created in [start, end)
+func (r Company) GetCompanyOrders(ctx context.Context, companyId int32, start time.Time, end time.Time) ([]entity.CompanyOrder, error) {
+	query := `
+	SELECT o.id, o.created_at, o.status, u.username, u.name, o.total, o.refunded
+	FROM orders o
+	JOIN users u ON o.user_id = u.id
+	WHERE o.company_id=$1 AND o.created_at>=$2 AND o.created_at<$3 AND o.status IN ($4,$5)
+	ORDER BY o.created_at`
+	var orders []entity.CompanyOrder
+	err := r.cli.Select(ctx, &orders, query,
+		companyId, start, end,
+		entity.OrderItemStatusPaid, entity.OrderItemStatusSuccess,
+	)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return orders, nil
+}
+
+func (r Company) SetOrderCompany(ctx context.Context, orderId string, companyId int32) error {
+	query := "UPDATE orders SET company_id=$1 WHERE id=$2"
+	res, err := r.cli.Exec(ctx, query, companyId, orderId)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	affected, _ := res.RowsAffected()
+	if affected == 0 {
+		return domain.ErrOrderNotFound
+	}
+	return nil
+}
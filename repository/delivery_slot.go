@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/Falokut/go-kit/db"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+)
+
+type DeliverySlot struct {
+	cli db.DB
+}
+
+func NewDeliverySlot(cli db.DB) DeliverySlot {
+	return DeliverySlot{
+		cli: cli,
+	}
+}
+
+func (r DeliverySlot) GetDeliverySlots(ctx context.Context, date time.Time) ([]entity.DeliverySlot, error) {
+	query := `
+	SELECT
+		ds.id,
+		ds.date,
+		to_char(ds.start_time, 'HH24:MI') AS start_time,
+		to_char(ds.end_time, 'HH24:MI') AS end_time,
+		ds.capacity,
+		count(o.id) AS booked
+	FROM delivery_slots ds
+	LEFT JOIN orders o ON o.delivery_slot_id = ds.id AND o.status != $2
+	WHERE ds.date = $1
+	GROUP BY ds.id
+	ORDER BY ds.start_time`
+	var slots []entity.DeliverySlot
+	err := r.cli.Select(ctx, &slots, query, date, entity.OrderItemStatusCanceled)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return slots, nil
+}
+
+func (r DeliverySlot) GetDeliverySlotForUpdate(ctx context.Context, id int32) (entity.DeliverySlot, error) {
+	query := `
+	SELECT
+		id,
+		date,
+		to_char(start_time, 'HH24:MI') AS start_time,
+		to_char(end_time, 'HH24:MI') AS end_time,
+		capacity
+	FROM delivery_slots
+	WHERE id = $1
+	FOR UPDATE`
+	var slot entity.DeliverySlot
+	err := r.cli.SelectRow(ctx, &slot, query, id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return entity.DeliverySlot{}, domain.ErrDeliverySlotNotFound
+	case err != nil:
+		return entity.DeliverySlot{}, errors.WithMessagef(err, "exec query '%s'", query)
+	default:
+		return slot, nil
+	}
+}
+
+func (r DeliverySlot) CountDeliverySlotOrders(ctx context.Context, id int32) (int32, error) {
+	const query = "SELECT count(*) FROM orders WHERE delivery_slot_id=$1 AND status != $2"
+	var count int32
+	err := r.cli.SelectRow(ctx, &count, query, id, entity.OrderItemStatusCanceled)
+	if err != nil {
+		return 0, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return count, nil
+}
+
+func (r DeliverySlot) InsertDeliverySlot(ctx context.Context, slot entity.DeliverySlot) (int32, error) {
+	query := `
+	INSERT INTO delivery_slots (date, start_time, end_time, capacity)
+	VALUES ($1, $2::time, $3::time, $4)
+	RETURNING id`
+	var id int32
+	err := r.cli.SelectRow(ctx, &id, query, slot.Date, slot.StartTime, slot.EndTime, slot.Capacity)
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &pgErr) && pgErr.SQLState() == pgerrcode.UniqueViolation:
+		return 0, domain.ErrDeliverySlotConflict
+	case err != nil:
+		return 0, errors.WithMessagef(err, "exec query '%s'", query)
+	default:
+		return id, nil
+	}
+}
+
+func (r DeliverySlot) UpdateDeliverySlotCapacity(ctx context.Context, id int32, capacity int32) error {
+	const query = "UPDATE delivery_slots SET capacity=$1 WHERE id=$2"
+	res, err := r.cli.Exec(ctx, query, capacity, id)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	affected, _ := res.RowsAffected()
+	if affected == 0 {
+		return domain.ErrDeliverySlotNotFound
+	}
+	return nil
+}
+
+func (r DeliverySlot) DeleteDeliverySlot(ctx context.Context, id int32) error {
+	const query = "DELETE FROM delivery_slots WHERE id=$1"
+	_, err := r.cli.Exec(ctx, query, id)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
@@ -30,12 +30,15 @@ func (r Dish) List(ctx context.Context, limit, offset int32) ([]entity.Dish, err
 	    d.price, 
 		COALESCE(d.image_id,'') AS image_id,
 		array_to_string(ARRAY_AGG(COALESCE(c.name,'')),',') AS categories,
-		r.name AS restaurant_name 
+		r.name AS restaurant_name,
+		COALESCE(drs.rating, 0) AS rating,
+		COALESCE(drs.ratings_count, 0) AS ratings_count
 	FROM dish AS d
 	JOIN restaurants AS r ON d.restaurant_id = r.id
+	LEFT JOIN dish_rating_stats AS drs ON d.id = drs.dish_id
 	LEFT JOIN dish_categories AS f_c ON d.id=f_c.dish_id
 	LEFT JOIN categories AS c ON f_c.category_id=c.id
-	GROUP BY d.id, d.name, d.description, d.price, d.image_id, r.name
+	GROUP BY d.id, d.name, d.description, d.price, d.image_id, r.name, drs.rating, drs.ratings_count
 	ORDER BY d.id
 	LIMIT $1 OFFSET $2`
 	var res []entity.Dish
@@ -80,13 +83,17 @@ func (r Dish) GetDishesByIds(ctx context.Context, ids []int32) ([]entity.Dish, e
 		d.price,
 		COALESCE(d.image_id,'') AS image_id,
 		array_to_string(ARRAY_AGG(COALESCE(c.name,'')),',') AS categories,
-		r.name AS restaurant_name 
+		r.id AS restaurant_id,
+		r.name AS restaurant_name,
+		COALESCE(drs.rating, 0) AS rating,
+		COALESCE(drs.ratings_count, 0) AS ratings_count
 	FROM dish AS d
 	JOIN restaurants AS r ON d.restaurant_id = r.id
+	LEFT JOIN dish_rating_stats AS drs ON d.id = drs.dish_id
 	LEFT JOIN dish_categories AS f_c ON d.id=f_c.dish_id
 	LEFT JOIN categories AS c ON f_c.category_id=c.id
 	WHERE d.id=ANY($1)
-	GROUP BY d.id, d.name, d.description, d.price, d.image_id, r.name
+	GROUP BY d.id, d.name, d.description, d.price, d.image_id, r.id, r.name, drs.rating, drs.ratings_count
 	ORDER BY d.id;`
 
 	var res []entity.Dish
@@ -106,12 +113,15 @@ func (r Dish) GetDishesByCategories(ctx context.Context, limit int32, offset int
 		d.price,
 		COALESCE(d.image_id,'') AS image_id,
 		array_to_string(ARRAY_AGG(COALESCE(c.name,'')),',') AS categories,
-		r.name AS restaurant_name 
+		r.name AS restaurant_name,
+		COALESCE(drs.rating, 0) AS rating,
+		COALESCE(drs.ratings_count, 0) AS ratings_count
 	FROM dish AS d
 	JOIN restaurants AS r ON d.restaurant_id = r.id
+	LEFT JOIN dish_rating_stats AS drs ON d.id = drs.dish_id
 	LEFT JOIN dish_categories AS f_c ON d.id=f_c.dish_id
 	LEFT JOIN categories AS c ON f_c.category_id=c.id
-	GROUP BY d.id, d.name, d.description, d.price, d.image_id, r.name
+	GROUP BY d.id, d.name, d.description, d.price, d.image_id, r.name, drs.rating, drs.ratings_count
 	HAVING array_agg(c.id) @> $1
 	ORDER BY d.id
 	LIMIT $2 OFFSET $3;`
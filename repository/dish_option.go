@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/Falokut/go-kit/db"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+)
+
+type DishOption struct {
+	cli db.DB
+}
+
+func NewDishOption(cli db.DB) DishOption {
+	return DishOption{
+		cli: cli,
+	}
+}
+
+func (r DishOption) GetDishOptionGroups(ctx context.Context, dishIds []int32) ([]entity.DishOptionGroup, error) {
+	query := `
+	SELECT
+		g.id,
+		g.dish_id,
+		g.name,
+		g.required,
+		g.min_count,
+		g.max_count,
+		json_agg(
+			json_build_object(
+			'id', o.id,
+			'name', o.name,
+			'priceDelta', o.price_delta
+			) ORDER BY o.id
+		) AS options
+	FROM dish_option_groups g
+	JOIN dish_options o ON o.group_id = g.id
+	WHERE g.dish_id = ANY($1)
+	GROUP BY g.id
+	ORDER BY g.dish_id, g.id`
+	var groups []entity.DishOptionGroup
+	err := r.cli.Select(ctx, &groups, query, dishIds)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return groups, nil
+}
+
+func (r DishOption) InsertDishOptionGroup(ctx context.Context, group entity.DishOptionGroup) (int32, error) {
+	query := `
+	INSERT INTO dish_option_groups (dish_id, name, required, min_count, max_count)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING id`
+	var id int32
+	err := r.cli.SelectRow(ctx, &id, query,
+		group.DishId,
+		group.Name,
+		group.Required,
+		group.MinCount,
+		group.MaxCount,
+	)
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &pgErr) && pgErr.SQLState() == pgerrcode.ForeignKeyViolation:
+		return 0, domain.ErrDishNotFound
+	case err != nil:
+		return 0, errors.WithMessagef(err, "exec query '%s'", query)
+	default:
+		return id, nil
+	}
+}
+
+//nolint:mnd
+func (r DishOption) InsertDishOptions(ctx context.Context, groupId int32, options []entity.DishOption) error {
+	args := make([]any, 0, len(options)*2+1)
+	args = append(args, groupId)
+	placeholders := make([]string, len(options))
+	for i, option := range options {
+		placeholders[i] = fmt.Sprintf("($1,$%d,$%d)", len(args)+1, len(args)+2)
+		args = append(args, option.Name, option.PriceDelta)
+	}
+
+	query := fmt.Sprintf("INSERT INTO dish_options (group_id, name, price_delta) VALUES %s",
+		strings.Join(placeholders, ","))
+	_, err := r.cli.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
+func (r DishOption) DeleteDishOptionGroup(ctx context.Context, id int32) error {
+	const query = "DELETE FROM dish_option_groups WHERE id=$1"
+	_, err := r.cli.Exec(ctx, query, id)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/Falokut/go-kit/db"
+	"github.com/pkg/errors"
+)
+
+type GroupOrder struct {
+	cli db.DB
+}
+
+func NewGroupOrder(cli db.DB) GroupOrder {
+	return GroupOrder{
+		cli: cli,
+	}
+}
+
+func (r GroupOrder) InsertGroupOrder(ctx context.Context, order entity.GroupOrder) error {
+	query := `
+	INSERT INTO group_orders (id, organizer_id, payment_mode, status, wishes, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := r.cli.Exec(ctx, query,
+		order.Id,
+		order.OrganizerId,
+		order.PaymentMode,
+		order.Status,
+		order.Wishes,
+		order.CreatedAt,
+	)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
+func (r GroupOrder) GetGroupOrder(ctx context.Context, id string) (entity.GroupOrder, error) {
+	return r.getGroupOrder(ctx, id, false)
+}
+
+func (r GroupOrder) GetGroupOrderForUpdate(ctx context.Context, id string) (entity.GroupOrder, error) {
+	return r.getGroupOrder(ctx, id, true)
+}
+
+func (r GroupOrder) getGroupOrder(ctx context.Context, id string, forUpdate bool) (entity.GroupOrder, error) {
+	query := `
+	SELECT
+		g.id,
+		g.organizer_id,
+		u.username AS organizer_username,
+		g.payment_mode,
+		g.status,
+		g.wishes,
+		g.created_at
+	FROM group_orders g
+	JOIN users u ON g.organizer_id = u.id
+	WHERE g.id = $1`
+	if forUpdate {
+		query += " FOR UPDATE OF g"
+	}
+
+	var order entity.GroupOrder
+	err := r.cli.SelectRow(ctx, &order, query, id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return entity.GroupOrder{}, domain.ErrGroupOrderNotFound
+	case err != nil:
+		return entity.GroupOrder{}, errors.WithMessagef(err, "exec query '%s'", query)
+	default:
+		return order, nil
+	}
+}
+
+func (r GroupOrder) GetGroupOrderItems(ctx context.Context, id string) ([]entity.GroupOrderItem, error) {
+	query := `
+	SELECT
+		gi.user_id,
+		u.username,
+		gi.dish_id,
+		d.name,
+		d.price,
+		gi.count
+	FROM group_order_items gi
+	JOIN users u ON gi.user_id = u.id
+	JOIN dish d ON gi.dish_id = d.id
+	WHERE gi.group_order_id = $1
+	ORDER BY u.username, gi.dish_id`
+	var items []entity.GroupOrderItem
+	err := r.cli.Select(ctx, &items, query, id)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return items, nil
+}
+
+func (r GroupOrder) DeleteGroupOrderUserItems(ctx context.Context, groupOrderId string, userId string) error {
+	const query = "DELETE FROM group_order_items WHERE group_order_id=$1 AND user_id=$2"
+	_, err := r.cli.Exec(ctx, query, groupOrderId, userId)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
+//nolint:mnd
+func (r GroupOrder) InsertGroupOrderItems(ctx context.Context, groupOrderId string, userId string, items map[int32]int32) error {
+	args := make([]any, 0, len(items)*2+2)
+	args = append(args, groupOrderId, userId)
+	placeholders := make([]string, 0, len(items))
+	for dishId, count := range items {
+		placeholders = append(placeholders, fmt.Sprintf("($1,$2,$%d,$%d)", len(args)+1, len(args)+2))
+		args = append(args, dishId, count)
+	}
+
+	query := fmt.Sprintf("INSERT INTO group_order_items(group_order_id,user_id,dish_id,count) VALUES %s",
+		strings.Join(placeholders, ","))
+	_, err := r.cli.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
+func (r GroupOrder) LockGroupOrder(ctx context.Context, id string, lockedAt time.Time) error {
+	const query = "UPDATE group_orders SET status=$1, locked_at=$2 WHERE id=$3"
+	_, err := r.cli.Exec(ctx, query, entity.GroupOrderStatusLocked, lockedAt, id)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Falokut/go-kit/db"
+	"github.com/pkg/errors"
+	"github.com/txix-open/bgjob"
+)
+
+type Job struct {
+	cli db.DB
+}
+
+func NewJob(cli db.DB) Job {
+	return Job{
+		cli: cli,
+	}
+}
+
+func (r Job) DeleteJob(ctx context.Context, queue string, jobId string) error {
+	const query = "DELETE FROM bgjob_job WHERE queue=$1 AND id=$2"
+	_, err := r.cli.Exec(ctx, query, queue, jobId)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
+// EnqueueJob enqueues the job through the repository client,
+// within a transaction the job is taken by the worker only after the commit
+func (r Job) EnqueueJob(ctx context.Context, req bgjob.EnqueueRequest) error {
+	err := bgjob.Enqueue(ctx, jobExecer{cli: r.cli}, req)
+	if err != nil {
+		return errors.WithMessage(err, "enqueue job")
+	}
+	return nil
+}
+
+// jobExecer adapts the db client to the bgjob enqueue
+type jobExecer struct {
+	cli db.DB
+}
+
+func (e jobExecer) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return e.cli.Exec(ctx, query, args...)
+}
@@ -11,9 +11,17 @@ import (
 	"dishes-service-backend/entity"
 
 	"github.com/Falokut/go-kit/db"
+	"github.com/Falokut/go-kit/json"
 	"github.com/pkg/errors"
 )
 
+// deliverySlotColumn formats the order delivery slot as "гггг.мм.дд чч:мм-чч:мм", requires ds join
+const deliverySlotColumn = `COALESCE(
+		to_char(ds.date, 'YYYY.MM.DD') || ' ' ||
+		to_char(ds.start_time, 'HH24:MI') || '-' ||
+		to_char(ds.end_time, 'HH24:MI'),
+	'') AS delivery_slot`
+
 type Order struct {
 	cli db.DB
 }
@@ -26,8 +34,9 @@ func NewOrder(cli db.DB) Order {
 
 func (r Order) InsertOrder(ctx context.Context, order *entity.Order) error {
 	query := `INSERT INTO 
-	orders(id, user_id, total, created_at, wishes, payment_method, status)
-	VALUES($1,$2,$3,$4,$5,$6,$7)`
+	orders(id, user_id, total, created_at, wishes, payment_method, status, delivery_slot_id, group_order_id,
+		promo_code_id, promo_code, discount, delivery_fee)
+	VALUES($1,$2,$3,$4,$5,$6,$7,NULLIF($8,0),NULLIF($9,'')::uuid,NULLIF($10,0),NULLIF($11,''),$12,$13)`
 	_, err := r.cli.Exec(ctx, query,
 		order.Id,
 		order.UserId,
@@ -36,6 +45,12 @@ func (r Order) InsertOrder(ctx context.Context, order *entity.Order) error {
 		order.Wishes,
 		order.PaymentMethod,
 		order.Status,
+		order.DeliverySlotId,
+		order.GroupOrderId,
+		order.PromoCodeId,
+		order.PromoCode,
+		order.Discount,
+		order.DeliveryFee,
 	)
 	if err != nil {
 		return errors.WithMessagef(err, "exec query '%s'", query)
@@ -45,19 +60,28 @@ func (r Order) InsertOrder(ctx context.Context, order *entity.Order) error {
 
 //nolint:mnd
 func (r Order) InsertOrderItems(ctx context.Context, orderId string, items entity.OrderItems) error {
-	args := make([]any, 0, len(items)*3+1)
+	args := make([]any, 0, len(items)*4+1)
 	args = append(args, orderId)
 	placeholders := make([]string, len(items))
 	for i, item := range items {
-		placeholders[i] = fmt.Sprintf("($1,$%d,$%d,$%d)",
+		options := item.Options
+		if options == nil {
+			options = []entity.OrderItemOption{}
+		}
+		optionsJson, err := json.Marshal(options)
+		if err != nil {
+			return errors.WithMessage(err, "marshal order item options")
+		}
+		placeholders[i] = fmt.Sprintf("($1,$%d,$%d,$%d,$%d::jsonb)",
 			len(args)+1,
 			len(args)+2,
 			len(args)+3,
+			len(args)+4,
 		)
-		args = append(args, item.DishId, item.Count, item.Price)
+		args = append(args, item.DishId, item.Count, item.Price, string(optionsJson))
 	}
 
-	query := fmt.Sprintf(`INSERT INTO order_items(order_id,dish_id,count,price) VALUES %s`,
+	query := fmt.Sprintf(`INSERT INTO order_items(order_id,dish_id,count,price,options) VALUES %s`,
 		strings.Join(placeholders, ","))
 	_, err := r.cli.Exec(ctx, query, args...)
 	if err != nil {
@@ -66,12 +90,18 @@ func (r Order) InsertOrderItems(ctx context.Context, orderId string, items entit
 	return nil
 }
 
-func (r Order) UpdateOrderStatus(ctx context.Context, orderId, newStatus string) error {
-	query := "UPDATE orders SET status=$1 WHERE id=$2"
-	_, err := r.cli.Exec(ctx, query, newStatus, orderId)
+// UpdateOrderStatus moves the order from the status to the new one,
+// returns domain.ErrOrderStatusConflict if the order has another status
+func (r Order) UpdateOrderStatus(ctx context.Context, orderId, fromStatus, newStatus string) error {
+	const query = "UPDATE orders SET status=$1 WHERE id=$2 AND status=$3"
+	res, err := r.cli.Exec(ctx, query, newStatus, orderId, fromStatus)
 	if err != nil {
 		return errors.WithMessagef(err, "exec query '%s'", query)
 	}
+	affected, _ := res.RowsAffected()
+	if affected == 0 {
+		return domain.ErrOrderStatusConflict
+	}
 	return nil
 }
 
@@ -85,11 +115,25 @@ func (r Order) GetOrder(ctx context.Context, orderId string) (*entity.Order, err
 		o.created_at,
 		o.wishes,
 		o.status,
+		COALESCE(o.delivery_slot_id, 0) AS delivery_slot_id,
+		COALESCE(o.group_order_id::text, '') AS group_order_id,
+		COALESCE(o.promo_code, '') AS promo_code,
+		o.discount,
+		o.delivery_fee,
+		o.refunded,
+		COALESCE(o.payment_charge_id, '') AS payment_charge_id,
+		COALESCE(o.provider_charge_id, '') AS provider_charge_id,
+		COALESCE(o.refund_status, '') AS refund_status,
+		` + deliverySlotColumn + `,
 		json_agg(
 			json_build_object(
+			'id', oi.id,
 			'dishId', oi.dish_id,
 			'count', oi.count,
 			'price', oi.price,
+			'options', oi.options,
+			'status', oi.status,
+			'restaurantId', r.id,
 			'restaurantName', r.name,
 			'name', d.name
 			)
@@ -98,8 +142,9 @@ func (r Order) GetOrder(ctx context.Context, orderId string) (*entity.Order, err
     JOIN order_items oi ON o.id = oi.order_id
 	JOIN dish d ON oi.dish_id = d.id
 	JOIN restaurants AS r ON d.restaurant_id = r.id
+	LEFT JOIN delivery_slots ds ON o.delivery_slot_id = ds.id
     WHERE o.id = $1
-	GROUP BY o.id`
+	GROUP BY o.id, ds.id`
 
 	var order entity.Order
 	err := r.cli.SelectRow(ctx, &order, query, orderId)
@@ -113,6 +158,139 @@ func (r Order) GetOrder(ctx context.Context, orderId string) (*entity.Order, err
 	}
 }
 
+func (r Order) CancelOrderItems(ctx context.Context, orderId string, itemIds []int64) error {
+	query := "UPDATE order_items SET status=$1 WHERE order_id=$2 AND id = ANY($3)"
+	_, err := r.cli.Exec(ctx, query, entity.ItemStatusCanceled, orderId, itemIds)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
+func (r Order) SetOrderPaymentCharge(ctx context.Context, orderId string, chargeId string, providerChargeId string) error {
+	query := "UPDATE orders SET payment_charge_id=NULLIF($1,''), provider_charge_id=NULLIF($2,'') WHERE id=$3"
+	_, err := r.cli.Exec(ctx, query, chargeId, providerChargeId, orderId)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
+func (r Order) SetOrderRefundStatus(ctx context.Context, orderId string, status string) error {
+	query := "UPDATE orders SET refund_status=$1 WHERE id=$2"
+	_, err := r.cli.Exec(ctx, query, status, orderId)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
+func (r Order) SetOrderInvoiceMessage(ctx context.Context, orderId string, message entity.InvoiceMessage) error {
+	query := "UPDATE orders SET invoice_chat_id=$1, invoice_message_id=$2 WHERE id=$3"
+	_, err := r.cli.Exec(ctx, query, message.ChatId, message.MessageId, orderId)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
+// GetOrderInvoiceMessage returns the zero message if the invoice wasn't sent
+func (r Order) GetOrderInvoiceMessage(ctx context.Context, orderId string) (entity.InvoiceMessage, error) {
+	query := `
+	SELECT
+		COALESCE(invoice_chat_id, 0) AS chat_id,
+		COALESCE(invoice_message_id, 0) AS message_id
+	FROM orders WHERE id=$1`
+	var message entity.InvoiceMessage
+	err := r.cli.SelectRow(ctx, &message, query, orderId)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return entity.InvoiceMessage{}, domain.ErrOrderNotFound
+	case err != nil:
+		return entity.InvoiceMessage{}, errors.WithMessagef(err, "exec query '%s'", query)
+	default:
+		return message, nil
+	}
+}
+
+func (r Order) AddOrderRefund(ctx context.Context, orderId string, refund int32) error {
+	query := "UPDATE orders SET total = total - $1, refunded = refunded + $1 WHERE id=$2"
+	_, err := r.cli.Exec(ctx, query, refund, orderId)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
+//nolint:mnd
+func (r Order) InsertOrderFulfilments(ctx context.Context, orderId string, restaurantIds []int32) error {
+	args := make([]any, 0, len(restaurantIds)+2)
+	args = append(args, orderId, entity.FulfilmentStatusAccepted)
+	placeholders := make([]string, len(restaurantIds))
+	for i, restaurantId := range restaurantIds {
+		placeholders[i] = fmt.Sprintf("($1,$%d,$2)", len(args)+1)
+		args = append(args, restaurantId)
+	}
+
+	query := fmt.Sprintf(`INSERT INTO order_fulfilments(order_id,restaurant_id,status) VALUES %s`,
+		strings.Join(placeholders, ","))
+	_, err := r.cli.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
+func (r Order) GetOrderFulfilments(ctx context.Context, orderId string) ([]entity.OrderFulfilment, error) {
+	query := `
+	SELECT f.order_id, f.restaurant_id, r.name AS restaurant_name, f.status, f.updated_at
+	FROM order_fulfilments f
+	JOIN restaurants r ON f.restaurant_id = r.id
+	WHERE f.order_id=$1
+	ORDER BY r.name`
+	var fulfilments []entity.OrderFulfilment
+	err := r.cli.Select(ctx, &fulfilments, query, orderId)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return fulfilments, nil
+}
+
+func (r Order) GetOrderFulfilmentsForUpdate(ctx context.Context, orderId string) ([]entity.OrderFulfilment, error) {
+	query := `
+	SELECT f.order_id, f.restaurant_id, r.name AS restaurant_name, f.status, f.updated_at
+	FROM order_fulfilments f
+	JOIN restaurants r ON f.restaurant_id = r.id
+	WHERE f.order_id=$1
+	ORDER BY r.name
+	FOR UPDATE OF f`
+	var fulfilments []entity.OrderFulfilment
+	err := r.cli.Select(ctx, &fulfilments, query, orderId)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return fulfilments, nil
+}
+
+func (r Order) UpdateFulfilmentStatus(ctx context.Context, orderId string, restaurantId int32, status string) error {
+	query := "UPDATE order_fulfilments SET status=$1, updated_at=now() WHERE order_id=$2 AND restaurant_id=$3"
+	_, err := r.cli.Exec(ctx, query, status, orderId, restaurantId)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
+// FinishOrderFulfilments moves the unfinished parts of the order to the status
+func (r Order) FinishOrderFulfilments(ctx context.Context, orderId string, status string) error {
+	query := "UPDATE order_fulfilments SET status=$1, updated_at=now() WHERE order_id=$2 AND status NOT IN ($3, $4)"
+	_, err := r.cli.Exec(ctx, query, status, orderId, entity.FulfilmentStatusDelivered, entity.FulfilmentStatusCanceled)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
 func (r Order) GetOrderStatus(ctx context.Context, orderId string) (string, error) {
 	query := "SELECT status FROM orders WHERE id=$1"
 	var status string
@@ -123,15 +301,52 @@ func (r Order) GetOrderStatus(ctx context.Context, orderId string) (string, erro
 	return status, nil
 }
 
-func (r Order) SetOrderStatus(ctx context.Context, orderId, oldStatus, newStatus string) error {
-	query := "UPDATE orders SET status=$1 WHERE id=$2 AND status=$3"
-	_, err := r.cli.Exec(ctx, query, newStatus, orderId, oldStatus)
+func (r Order) GetOrderStatusForUpdate(ctx context.Context, orderId string) (string, error) {
+	query := "SELECT status FROM orders WHERE id=$1 FOR UPDATE"
+	var status string
+	err := r.cli.SelectRow(ctx, &status, query, orderId)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return "", domain.ErrOrderNotFound
+	case err != nil:
+		return "", errors.WithMessagef(err, "exec query '%s'", query)
+	default:
+		return status, nil
+	}
+}
+
+func (r Order) InsertOrderStatusHistory(ctx context.Context, history entity.OrderStatusHistory) error {
+	query := `INSERT INTO 
+	order_status_history(order_id, old_status, new_status, actor, reason, created_at)
+	VALUES($1,NULLIF($2,''),$3,$4,$5,$6)`
+	_, err := r.cli.Exec(ctx, query,
+		history.OrderId,
+		history.OldStatus,
+		history.NewStatus,
+		history.Actor,
+		history.Reason,
+		history.CreatedAt,
+	)
 	if err != nil {
 		return errors.WithMessagef(err, "exec query '%s'", query)
 	}
 	return nil
 }
 
+func (r Order) GetOrderStatusHistory(ctx context.Context, orderId string) ([]entity.OrderStatusHistory, error) {
+	query := `
+	SELECT order_id, COALESCE(old_status, '') AS old_status, new_status, actor, reason, created_at
+	FROM order_status_history
+	WHERE order_id=$1
+	ORDER BY created_at, id`
+	var history []entity.OrderStatusHistory
+	err := r.cli.Select(ctx, &history, query, orderId)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return history, nil
+}
+
 func (r Order) InsertAllowOrderingAudit(ctx context.Context) error {
 	const query = "INSERT INTO allow_ordering_audit DEFAULT VALUES"
 	_, err := r.cli.Exec(ctx, query)
@@ -170,19 +385,31 @@ func (r Order) GetUserOrders(ctx context.Context, userId string, limit int32, of
 		o.created_at,
 		o.wishes,
 		o.status,
+		COALESCE(o.delivery_slot_id, 0) AS delivery_slot_id,
+		COALESCE(o.group_order_id::text, '') AS group_order_id,
+		COALESCE(o.promo_code, '') AS promo_code,
+		o.discount,
+		o.delivery_fee,
+		o.refunded,
+		COALESCE(o.refund_status, '') AS refund_status,
+		` + deliverySlotColumn + `,
 		json_agg(
 			json_build_object(
+			'id', oi.id,
 			'dishId', oi.dish_id,
 			'count', oi.count,
 			'price', oi.price,
+			'options', oi.options,
+			'status', oi.status,
 			'name', d.name
 			)
 		) AS items
     FROM orders o
     JOIN order_items oi ON o.id = oi.order_id
 	JOIN dish d ON oi.dish_id = d.id
+	LEFT JOIN delivery_slots ds ON o.delivery_slot_id = ds.id
     WHERE o.user_id = $1
-	GROUP BY o.id
+	GROUP BY o.id, ds.id
     ORDER BY o.created_at DESC
 	LIMIT $2
 	OFFSET $3`
@@ -217,11 +444,20 @@ func (r Order) GetOrdersByPeriod(ctx context.Context, start time.Time, end time.
 		o.total, 
 		o.created_at,
 		o.status,
+		` + deliverySlotColumn + `,
+		COALESCE(o.group_order_id::text, '') AS group_order_id,
+		COALESCE(o.promo_code, '') AS promo_code,
+		o.discount,
+		o.delivery_fee,
+		o.refunded,
 		json_agg(
 			json_build_object(
+			'id', oi.id,
 			'dishId', oi.dish_id,
 			'count', oi.count,
 			'price', oi.price,
+			'options', oi.options,
+			'status', oi.status,
 			'name', d.name
 			)
 		) AS items
@@ -229,9 +465,10 @@ func (r Order) GetOrdersByPeriod(ctx context.Context, start time.Time, end time.
     JOIN order_items oi ON o.id = oi.order_id
 	JOIN dish d ON oi.dish_id = d.id
 	JOIN users u ON o.user_id = u.id
+	LEFT JOIN delivery_slots ds ON o.delivery_slot_id = ds.id
     WHERE o.created_at >= $1 AND o.created_at <= $2
-	GROUP BY o.id, u.username
-    ORDER BY o.created_at DESC`
+	GROUP BY o.id, u.username, ds.id
+    ORDER BY ds.date NULLS LAST, ds.start_time NULLS LAST, o.group_order_id NULLS LAST, o.created_at DESC`
 	var orders []entity.OrderToExport
 	err := r.cli.Select(ctx, &orders, query, start, end)
 	if err != nil {
@@ -239,3 +476,237 @@ func (r Order) GetOrdersByPeriod(ctx context.Context, start time.Time, end time.
 	}
 	return orders, nil
 }
+
+func (r Order) GetPaidSlotOrders(ctx context.Context, date time.Time) ([]entity.DeliverySlotOrder, error) {
+	query := `
+	SELECT
+		o.id,
+		u.username,
+		o.total,
+		to_char(ds.start_time, 'HH24:MI') || '-' || to_char(ds.end_time, 'HH24:MI') AS delivery_slot,
+		json_agg(
+			json_build_object(
+			'id', oi.id,
+			'dishId', oi.dish_id,
+			'count', oi.count,
+			'price', oi.price,
+			'options', oi.options,
+			'status', oi.status,
+			'restaurantName', r.name,
+			'name', d.name
+			)
+		) AS items
+	FROM orders o
+	JOIN delivery_slots ds ON o.delivery_slot_id = ds.id
+	JOIN users u ON o.user_id = u.id
+	JOIN order_items oi ON o.id = oi.order_id
+	JOIN dish d ON oi.dish_id = d.id
+	JOIN restaurants AS r ON d.restaurant_id = r.id
+	WHERE ds.date = $1 AND o.status = $2 AND oi.status = $3
+	GROUP BY o.id, u.username, ds.id
+	ORDER BY ds.start_time, o.created_at`
+	var orders []entity.DeliverySlotOrder
+	err := r.cli.Select(ctx, &orders, query, date, entity.OrderItemStatusPaid, entity.ItemStatusActive)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return orders, nil
+}
+
+// nolint:gochecknoglobals
+var ordersSortColumns = map[string]string{
+	"createdAt": "o.created_at",
+	"total":     "o.total",
+	"status":    "o.status",
+}
+
+func (r Order) GetOrders(ctx context.Context, filter entity.OrdersFilter) ([]entity.AdminOrder, error) {
+	source, args := ordersSource(filter)
+	sortColumn, ok := ordersSortColumns[filter.SortBy]
+	if !ok {
+		sortColumn = ordersSortColumns["createdAt"]
+	}
+	sortOrder := "DESC"
+	if filter.SortOrder == "asc" {
+		sortOrder = "ASC"
+	}
+	args = append(args, filter.Limit, filter.Offset)
+
+	query := fmt.Sprintf(`
+	SELECT
+		o.id,
+		o.payment_method,
+		o.user_id,
+		u.username,
+		o.total,
+		o.created_at,
+		COALESCE(o.wishes, '') AS wishes,
+		o.status,
+		COALESCE(o.delivery_slot_id, 0) AS delivery_slot_id,
+		COALESCE(o.group_order_id::text, '') AS group_order_id,
+		COALESCE(o.promo_code, '') AS promo_code,
+		o.discount,
+		o.delivery_fee,
+		o.refunded,
+		%s,
+		json_agg(
+			json_build_object(
+			'id', oi.id,
+			'dishId', oi.dish_id,
+			'count', oi.count,
+			'price', oi.price,
+			'options', oi.options,
+			'status', oi.status,
+			'restaurantName', r.name,
+			'name', d.name
+			)
+		) AS items
+	%s
+	GROUP BY o.id, u.username, ds.id
+	ORDER BY %s %s, o.id
+	LIMIT $%d
+	OFFSET $%d`, deliverySlotColumn, source, sortColumn, sortOrder, len(args)-1, len(args))
+
+	var orders []entity.AdminOrder
+	err := r.cli.Select(ctx, &orders, query, args...)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return orders, nil
+}
+
+func (r Order) CountOrders(ctx context.Context, filter entity.OrdersFilter) (int64, error) {
+	source, args := ordersSource(filter)
+	query := fmt.Sprintf(`
+	SELECT count(DISTINCT o.id)
+	%s`, source)
+
+	var count int64
+	err := r.cli.SelectRow(ctx, &count, query, args...)
+	if err != nil {
+		return 0, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return count, nil
+}
+
+// GetUserOrdersStats returns the count and the sum of the user orders created since from,
+// canceled orders aren't counted
+func (r Order) GetUserOrdersStats(ctx context.Context, userId string, from time.Time) (entity.UserOrdersStats, error) {
+	query := `
+	SELECT count(*) AS count, COALESCE(sum(total), 0) AS total
+	FROM orders
+	WHERE user_id=$1 AND created_at>=$2 AND status<>$3`
+	var stats entity.UserOrdersStats
+	err := r.cli.SelectRow(ctx, &stats, query, userId, from, entity.OrderItemStatusCanceled)
+	if err != nil {
+		return entity.UserOrdersStats{}, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return stats, nil
+}
+
+// ordersSource builds the FROM and WHERE clauses shared by the orders list and its count,
+// so the count matches the listed orders
+func ordersSource(filter entity.OrdersFilter) (string, []any) {
+	condition, args := ordersFilterCondition(filter)
+	source := fmt.Sprintf(`
+	FROM orders o
+	JOIN users u ON o.user_id = u.id
+	JOIN order_items oi ON o.id = oi.order_id
+	JOIN dish d ON oi.dish_id = d.id
+	JOIN restaurants AS r ON d.restaurant_id = r.id
+	LEFT JOIN delivery_slots ds ON o.delivery_slot_id = ds.id
+	WHERE %s`, condition)
+	return source, args
+}
+
+func ordersFilterCondition(filter entity.OrdersFilter) (string, []any) {
+	conditions := []string{"TRUE"}
+	args := make([]any, 0)
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Status != "" {
+		addCondition("o.status = $%d", filter.Status)
+	}
+	if filter.UserId != "" {
+		addCondition("o.user_id = $%d", filter.UserId)
+	}
+	if filter.Username != "" {
+		addCondition("u.username = $%d", filter.Username)
+	}
+	if filter.PaymentMethod != "" {
+		addCondition("o.payment_method = $%d", filter.PaymentMethod)
+	}
+	if filter.CreatedFrom != nil {
+		addCondition("o.created_at >= $%d", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		addCondition("o.created_at <= $%d", *filter.CreatedTo)
+	}
+	if filter.GroupOrderId != "" {
+		addCondition("o.group_order_id = $%d", filter.GroupOrderId)
+	}
+	if filter.RestaurantId != 0 {
+		addCondition(`EXISTS(
+		SELECT 1 FROM order_items foi
+		JOIN dish fd ON foi.dish_id = fd.id
+		WHERE foi.order_id = o.id AND fd.restaurant_id = $%d)`, filter.RestaurantId)
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+func (r Order) LockIdempotencyKey(ctx context.Context, userId string, key string) error {
+	const query = "SELECT pg_advisory_xact_lock(hashtext($1))"
+	_, err := r.cli.Exec(ctx, query, userId+":"+key)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
+func (r Order) DeleteExpiredIdempotencyKeys(ctx context.Context, userId string) error {
+	const query = "DELETE FROM order_idempotency_keys WHERE user_id=$1 AND expires_at <= now()"
+	_, err := r.cli.Exec(ctx, query, userId)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
+func (r Order) GetIdempotencyKey(ctx context.Context, userId string, key string) (entity.IdempotencyKey, error) {
+	query := `
+	SELECT user_id, key, request_hash, order_id, payment_url, created_at, expires_at
+	FROM order_idempotency_keys
+	WHERE user_id=$1 AND key=$2`
+	var idempotencyKey entity.IdempotencyKey
+	err := r.cli.SelectRow(ctx, &idempotencyKey, query, userId, key)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return entity.IdempotencyKey{}, domain.ErrIdempotencyKeyNotFound
+	case err != nil:
+		return entity.IdempotencyKey{}, errors.WithMessagef(err, "exec query '%s'", query)
+	default:
+		return idempotencyKey, nil
+	}
+}
+
+func (r Order) InsertIdempotencyKey(ctx context.Context, key entity.IdempotencyKey) error {
+	query := `INSERT INTO 
+	order_idempotency_keys(user_id, key, request_hash, order_id, payment_url, created_at, expires_at)
+	VALUES($1,$2,$3,$4,$5,$6,$7)`
+	_, err := r.cli.Exec(ctx, query,
+		key.UserId,
+		key.Key,
+		key.RequestHash,
+		key.OrderId,
+		key.PaymentUrl,
+		key.CreatedAt,
+		key.ExpiresAt,
+	)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
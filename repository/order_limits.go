@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/Falokut/go-kit/db"
+	"github.com/pkg/errors"
+)
+
+type OrderLimits struct {
+	cli db.DB
+}
+
+func NewOrderLimits(cli db.DB) OrderLimits {
+	return OrderLimits{
+		cli: cli,
+	}
+}
+
+func (r OrderLimits) GetOrderLimits(ctx context.Context) (entity.OrderLimits, error) {
+	query := "SELECT orders_per_day, daily_total, monthly_total, max_dish_count FROM order_limits"
+	var limits entity.OrderLimits
+	err := r.cli.SelectRow(ctx, &limits, query)
+	if err != nil {
+		return entity.OrderLimits{}, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return limits, nil
+}
+
+func (r OrderLimits) SetOrderLimits(ctx context.Context, limits entity.OrderLimits) error {
+	query := "UPDATE order_limits SET orders_per_day=$1, daily_total=$2, monthly_total=$3, max_dish_count=$4"
+	_, err := r.cli.Exec(ctx, query, limits.OrdersPerDay, limits.DailyTotal, limits.MonthlyTotal, limits.MaxDishCount)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
+const userOrderLimitsQuery = `
+	SELECT
+		u.id AS user_id,
+		COALESCE(ul.orders_per_day, g.orders_per_day) AS orders_per_day,
+		COALESCE(ul.daily_total, g.daily_total) AS daily_total,
+		COALESCE(ul.monthly_total, g.monthly_total) AS monthly_total,
+		COALESCE(ul.max_dish_count, g.max_dish_count) AS max_dish_count,
+		ul.user_id IS NOT NULL AS overridden
+	FROM users u
+	CROSS JOIN order_limits g
+	LEFT JOIN user_order_limits ul ON ul.user_id = u.id
+	WHERE u.id = $1`
+
+// GetUserOrderLimits returns the limits applied to the user orders
+func (r OrderLimits) GetUserOrderLimits(ctx context.Context, userId string) (entity.UserOrderLimits, error) {
+	return r.getUserOrderLimits(ctx, userOrderLimitsQuery, userId)
+}
+
+// GetUserOrderLimitsForUpdate locks the user until the end of the transaction,
+// so concurrent orders of the user can't exceed the limits
+func (r OrderLimits) GetUserOrderLimitsForUpdate(ctx context.Context, userId string) (entity.UserOrderLimits, error) {
+	return r.getUserOrderLimits(ctx, userOrderLimitsQuery+" FOR UPDATE OF u", userId)
+}
+
+func (r OrderLimits) getUserOrderLimits(ctx context.Context, query string, userId string) (entity.UserOrderLimits, error) {
+	var limits entity.UserOrderLimits
+	err := r.cli.SelectRow(ctx, &limits, query, userId)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return entity.UserOrderLimits{}, domain.ErrUserNotFound
+	case err != nil:
+		return entity.UserOrderLimits{}, errors.WithMessagef(err, "exec query '%s'", query)
+	default:
+		return limits, nil
+	}
+}
+
+func (r OrderLimits) UpsertUserOrderLimits(ctx context.Context, userId string, limits entity.OrderLimits) error {
+	query := `
+	INSERT INTO user_order_limits(user_id, orders_per_day, daily_total, monthly_total, max_dish_count)
+	VALUES($1,$2,$3,$4,$5)
+	ON CONFLICT (user_id) DO UPDATE SET
+		orders_per_day=EXCLUDED.orders_per_day,
+		daily_total=EXCLUDED.daily_total,
+		monthly_total=EXCLUDED.monthly_total,
+		max_dish_count=EXCLUDED.max_dish_count`
+	_, err := r.cli.Exec(ctx, query, userId, limits.OrdersPerDay, limits.DailyTotal, limits.MonthlyTotal, limits.MaxDishCount)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
+func (r OrderLimits) DeleteUserOrderLimits(ctx context.Context, userId string) error {
+	query := "DELETE FROM user_order_limits WHERE user_id=$1"
+	_, err := r.cli.Exec(ctx, query, userId)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
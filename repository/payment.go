@@ -0,0 +1,253 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"dishes-service-backend/entity"
+
+	"github.com/Falokut/go-kit/db"
+	"github.com/pkg/errors"
+)
+
+type Payment struct {
+	cli db.DB
+}
+
+func NewPayment(cli db.DB) Payment {
+	return Payment{
+		cli: cli,
+	}
+}
+
+func (r Payment) InsertPayment(ctx context.Context, payment entity.Payment) error {
+	query := `
+	INSERT INTO payments(order_id, method, status, amount, currency)
+	VALUES($1,$2,$3,$4,$5)`
+	_, err := r.cli.Exec(ctx, query,
+		payment.OrderId,
+		payment.Method,
+		payment.Status,
+		payment.Amount,
+		payment.Currency,
+	)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
+// CompleteOrderPayment marks the latest pending attempt of the order as succeeded,
+// the succeeded payment is added if the order has no pending attempts
+func (r Payment) CompleteOrderPayment(ctx context.Context, orderId string, result entity.PaymentResult) error {
+	query := `
+	UPDATE payments SET
+		status=$2,
+		amount=CASE WHEN $3::bigint > 0 THEN $3::bigint ELSE amount END,
+		currency=COALESCE(NULLIF($4, ''), currency),
+		telegram_payment_charge_id=NULLIF($5, ''),
+		provider_payment_charge_id=NULLIF($6, ''),
+		payload=NULLIF($7, '')::jsonb,
+		updated_at=now()
+	WHERE id=(
+		SELECT id FROM payments
+		WHERE order_id=$1 AND status=$8
+		ORDER BY created_at DESC
+		LIMIT 1
+	)`
+	res, err := r.cli.Exec(ctx, query,
+		orderId,
+		entity.PaymentStatusSucceeded,
+		result.Amount,
+		result.Currency,
+		result.TelegramPaymentChargeId,
+		result.ProviderPaymentChargeId,
+		result.Payload,
+		entity.PaymentStatusPending,
+	)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	affected, _ := res.RowsAffected()
+	if affected > 0 {
+		return nil
+	}
+	err = r.InsertOrderPayment(ctx, orderId, entity.PaymentStatusSucceeded, result)
+	if err != nil {
+		return errors.WithMessage(err, "insert order payment")
+	}
+	return nil
+}
+
+// InsertOrderPayment adds the payment with the method and the total of the order,
+// the result overrides them if reported by the provider, the payment with the known charge id is skipped
+func (r Payment) InsertOrderPayment(ctx context.Context, orderId string, status string, result entity.PaymentResult) error {
+	query := `
+	INSERT INTO payments(
+		order_id, method, status, amount, currency,
+		telegram_payment_charge_id, provider_payment_charge_id, payload
+	)
+	SELECT
+		id,
+		payment_method,
+		$2,
+		CASE WHEN $3::bigint > 0 THEN $3::bigint ELSE total END,
+		COALESCE(NULLIF($4, ''), $8),
+		NULLIF($5, ''),
+		NULLIF($6, ''),
+		NULLIF($7, '')::jsonb
+	FROM orders WHERE id=$1
+	ON CONFLICT DO NOTHING`
+	_, err := r.cli.Exec(ctx, query,
+		orderId,
+		status,
+		result.Amount,
+		result.Currency,
+		result.TelegramPaymentChargeId,
+		result.ProviderPaymentChargeId,
+		result.Payload,
+		entity.CurrencyRub,
+	)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
+// FailOrderPayments marks the pending attempts of the order as failed
+func (r Payment) FailOrderPayments(ctx context.Context, orderId string, payload string) error {
+	query := `
+	UPDATE payments SET
+		status=$2,
+		payload=COALESCE(NULLIF($3, '')::jsonb, payload),
+		updated_at=now()
+	WHERE order_id=$1 AND status=$4`
+	_, err := r.cli.Exec(ctx, query, orderId, entity.PaymentStatusFailed, payload, entity.PaymentStatusPending)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
+func (r Payment) GetPayments(ctx context.Context, filter entity.PaymentsFilter) ([]entity.Payment, error) {
+	condition, args := paymentsFilterCondition(filter)
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`
+	SELECT
+		id,
+		order_id,
+		method,
+		status,
+		amount,
+		currency,
+		COALESCE(telegram_payment_charge_id, '') AS telegram_payment_charge_id,
+		COALESCE(provider_payment_charge_id, '') AS provider_payment_charge_id,
+		COALESCE(payload::text, '') AS payload,
+		created_at,
+		updated_at
+	FROM payments
+	WHERE %s
+	ORDER BY created_at DESC
+	LIMIT $%d OFFSET $%d`, condition, len(args)-1, len(args))
+	var payments []entity.Payment
+	err := r.cli.Select(ctx, &payments, query, args...)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return payments, nil
+}
+
+func (r Payment) CountPayments(ctx context.Context, filter entity.PaymentsFilter) (int64, error) {
+	condition, args := paymentsFilterCondition(filter)
+	query := fmt.Sprintf("SELECT count(*) FROM payments WHERE %s", condition)
+	var count int64
+	err := r.cli.SelectRow(ctx, &count, query, args...)
+	if err != nil {
+		return 0, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return count, nil
+}
+
+// GetPaymentDiscrepancies returns the orders created and the payments settled within the period
+// which don't match each other
+func (r Payment) GetPaymentDiscrepancies(ctx context.Context, from time.Time, to time.Time) ([]entity.PaymentDiscrepancy, error) {
+	query := `
+	SELECT
+		$3::text AS kind,
+		o.id AS order_id,
+		o.status AS order_status,
+		o.payment_method,
+		(o.total + o.refunded)::bigint AS order_amount,
+		'' AS payment_id,
+		0::bigint AS payment_amount,
+		'' AS currency,
+		'' AS telegram_payment_charge_id,
+		'' AS provider_payment_charge_id,
+		o.created_at
+	FROM orders o
+	WHERE o.status IN ($6, $7) AND o.created_at >= $1 AND o.created_at < $2
+		AND NOT EXISTS(SELECT 1 FROM payments p WHERE p.order_id = o.id AND p.status = $8)
+	UNION ALL
+	SELECT
+		CASE WHEN o.status = $9 THEN $4::text ELSE $5::text END,
+		o.id,
+		o.status,
+		o.payment_method,
+		(o.total + o.refunded)::bigint,
+		p.id::text,
+		p.amount,
+		p.currency,
+		COALESCE(p.telegram_payment_charge_id, ''),
+		COALESCE(p.provider_payment_charge_id, ''),
+		p.updated_at
+	FROM payments p
+	JOIN orders o ON o.id = p.order_id
+	WHERE p.status = $8 AND p.updated_at >= $1 AND p.updated_at < $2
+		AND (
+			-- the canceled orders which were never paid, the refunds of the paid ones are tracked by refund_status
+			o.status = $9 AND NOT EXISTS(
+				SELECT 1 FROM order_status_history h WHERE h.order_id = o.id AND h.new_status = $6
+			)
+			OR o.status <> $9 AND p.currency = $10 AND p.amount <> o.total + o.refunded
+		)
+	ORDER BY created_at`
+	var discrepancies []entity.PaymentDiscrepancy
+	err := r.cli.Select(ctx, &discrepancies, query,
+		from,
+		to,
+		entity.DiscrepancyPaidWithoutPayment,
+		entity.DiscrepancyCanceledOrderPayment,
+		entity.DiscrepancyAmountMismatch,
+		entity.OrderItemStatusPaid,
+		entity.OrderItemStatusSuccess,
+		entity.PaymentStatusSucceeded,
+		entity.OrderItemStatusCanceled,
+		entity.CurrencyRub,
+	)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return discrepancies, nil
+}
+
+func paymentsFilterCondition(filter entity.PaymentsFilter) (string, []any) {
+	conditions := []string{"TRUE"}
+	args := make([]any, 0)
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.OrderId != "" {
+		addCondition("order_id = $%d", filter.OrderId)
+	}
+	if filter.Method != "" {
+		addCondition("method = $%d", filter.Method)
+	}
+	if filter.Status != "" {
+		addCondition("status = $%d", filter.Status)
+	}
+	return strings.Join(conditions, " AND "), args
+}
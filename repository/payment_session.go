@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/Falokut/go-kit/db"
+	"github.com/pkg/errors"
+)
+
+type PaymentSession struct {
+	cli db.DB
+}
+
+func NewPaymentSession(cli db.DB) PaymentSession {
+	return PaymentSession{
+		cli: cli,
+	}
+}
+
+func (r PaymentSession) InsertPaymentSession(ctx context.Context, session entity.PaymentSession) error {
+	// the repeated checkout of the order returns the same gateway payment
+	query := "INSERT INTO payment_sessions(id, order_id, amount, url) VALUES($1,$2,$3,$4) ON CONFLICT (id) DO NOTHING"
+	_, err := r.cli.Exec(ctx, query, session.Id, session.OrderId, session.Amount, session.Url)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
+func (r PaymentSession) GetPaymentSession(ctx context.Context, id string) (entity.PaymentSession, error) {
+	query := "SELECT id, order_id, amount, url, created_at FROM payment_sessions WHERE id=$1"
+	var session entity.PaymentSession
+	err := r.cli.SelectRow(ctx, &session, query, id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return entity.PaymentSession{}, domain.ErrPaymentSessionNotFound
+	case err != nil:
+		return entity.PaymentSession{}, errors.WithMessagef(err, "exec query '%s'", query)
+	default:
+		return session, nil
+	}
+}
+
+// GetOrderPaymentSessions returns the order payment sessions from the newest to the oldest
+func (r PaymentSession) GetOrderPaymentSessions(ctx context.Context, orderId string) ([]entity.PaymentSession, error) {
+	query := `
+	SELECT id, order_id, amount, url, created_at
+	FROM payment_sessions
+	WHERE order_id=$1
+	ORDER BY created_at DESC`
+	var sessions []entity.PaymentSession
+	err := r.cli.Select(ctx, &sessions, query, orderId)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return sessions, nil
+}
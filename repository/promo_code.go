@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/Falokut/go-kit/db"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+)
+
+type PromoCode struct {
+	cli db.DB
+}
+
+func NewPromoCode(cli db.DB) PromoCode {
+	return PromoCode{
+		cli: cli,
+	}
+}
+
+func (r PromoCode) GetPromoCodes(ctx context.Context) ([]entity.PromoCode, error) {
+	query := `
+	SELECT
+		pc.id,
+		pc.code,
+		pc.discount_type,
+		pc.discount_value,
+		pc.valid_from,
+		pc.valid_to,
+		COALESCE(pc.total_limit, 0) AS total_limit,
+		COALESCE(pc.per_user_limit, 0) AS per_user_limit,
+		COALESCE((SELECT json_agg(restaurant_id) FROM promo_code_restaurants WHERE promo_code_id = pc.id), '[]') AS restaurant_ids,
+		COALESCE((SELECT json_agg(category_id) FROM promo_code_categories WHERE promo_code_id = pc.id), '[]') AS category_ids,
+		pc.created_at
+	FROM promo_codes pc
+	ORDER BY pc.created_at DESC`
+	var promoCodes []entity.PromoCode
+	err := r.cli.Select(ctx, &promoCodes, query)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return promoCodes, nil
+}
+
+func (r PromoCode) GetPromoCodeForUpdate(ctx context.Context, code string) (entity.PromoCode, error) {
+	query := `
+	SELECT
+		id,
+		code,
+		discount_type,
+		discount_value,
+		valid_from,
+		valid_to,
+		COALESCE(total_limit, 0) AS total_limit,
+		COALESCE(per_user_limit, 0) AS per_user_limit,
+		created_at
+	FROM promo_codes
+	WHERE code = $1
+	FOR UPDATE`
+	var promoCode entity.PromoCode
+	err := r.cli.SelectRow(ctx, &promoCode, query, code)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return entity.PromoCode{}, domain.ErrPromoCodeNotFound
+	case err != nil:
+		return entity.PromoCode{}, errors.WithMessagef(err, "exec query '%s'", query)
+	default:
+		return promoCode, nil
+	}
+}
+
+// CountPromoCodeUsages counts not canceled orders with the promo code, if userId is empty - for all users
+func (r PromoCode) CountPromoCodeUsages(ctx context.Context, promoCodeId int32, userId string) (int32, error) {
+	const query = `
+	SELECT count(*) FROM orders
+	WHERE promo_code_id = $1 AND status != $2 AND ($3 = '' OR user_id::text = $3)`
+	var count int32
+	err := r.cli.SelectRow(ctx, &count, query, promoCodeId, entity.OrderItemStatusCanceled, userId)
+	if err != nil {
+		return 0, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return count, nil
+}
+
+// GetPromoCodeEligibleDishes returns the dishes from dishIds that match the promo code restrictions
+func (r PromoCode) GetPromoCodeEligibleDishes(ctx context.Context, promoCodeId int32, dishIds []int32) ([]int32, error) {
+	query := `
+	SELECT d.id
+	FROM dish d
+	WHERE d.id = ANY($2)
+	AND (
+		NOT EXISTS(SELECT 1 FROM promo_code_restaurants WHERE promo_code_id = $1)
+		OR d.restaurant_id IN (SELECT restaurant_id FROM promo_code_restaurants WHERE promo_code_id = $1)
+	)
+	AND (
+		NOT EXISTS(SELECT 1 FROM promo_code_categories WHERE promo_code_id = $1)
+		OR EXISTS(
+			SELECT 1 FROM dish_categories dc
+			JOIN promo_code_categories pcc ON dc.category_id = pcc.category_id
+			WHERE dc.dish_id = d.id AND pcc.promo_code_id = $1
+		)
+	)`
+	var ids []int32
+	err := r.cli.Select(ctx, &ids, query, promoCodeId, dishIds)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return ids, nil
+}
+
+func (r PromoCode) InsertPromoCode(ctx context.Context, promoCode entity.PromoCode) (int32, error) {
+	query := `
+	INSERT INTO promo_codes
+	(code, discount_type, discount_value, valid_from, valid_to, total_limit, per_user_limit, created_at)
+	VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), NULLIF($7, 0), $8)
+	RETURNING id`
+	var id int32
+	err := r.cli.SelectRow(ctx, &id, query,
+		promoCode.Code,
+		promoCode.DiscountType,
+		promoCode.DiscountValue,
+		promoCode.ValidFrom,
+		promoCode.ValidTo,
+		promoCode.TotalLimit,
+		promoCode.PerUserLimit,
+		promoCode.CreatedAt,
+	)
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &pgErr) && pgErr.SQLState() == pgerrcode.UniqueViolation:
+		return 0, domain.ErrPromoCodeConflict
+	case err != nil:
+		return 0, errors.WithMessagef(err, "exec query '%s'", query)
+	default:
+		return id, nil
+	}
+}
+
+func (r PromoCode) InsertPromoCodeRestaurants(ctx context.Context, promoCodeId int32, restaurantIds []int32) error {
+	query := fmt.Sprintf(`
+	INSERT INTO promo_code_restaurants (promo_code_id, restaurant_id)
+	VALUES %s
+	ON CONFLICT DO NOTHING`, promoCodeRestrictionValues(len(restaurantIds)))
+	_, err := r.cli.Exec(ctx, query, promoCodeRestrictionArgs(promoCodeId, restaurantIds)...)
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &pgErr) && pgErr.SQLState() == pgerrcode.ForeignKeyViolation:
+		return domain.ErrRestaurantNotFound
+	case err != nil:
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	default:
+		return nil
+	}
+}
+
+func (r PromoCode) InsertPromoCodeCategories(ctx context.Context, promoCodeId int32, categoryIds []int32) error {
+	query := fmt.Sprintf(`
+	INSERT INTO promo_code_categories (promo_code_id, category_id)
+	VALUES %s
+	ON CONFLICT DO NOTHING`, promoCodeRestrictionValues(len(categoryIds)))
+	_, err := r.cli.Exec(ctx, query, promoCodeRestrictionArgs(promoCodeId, categoryIds)...)
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &pgErr) && pgErr.SQLState() == pgerrcode.ForeignKeyViolation:
+		return domain.ErrDishCategoryNotFound
+	case err != nil:
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	default:
+		return nil
+	}
+}
+
+func (r PromoCode) DeletePromoCode(ctx context.Context, id int32) error {
+	const query = "DELETE FROM promo_codes WHERE id=$1"
+	_, err := r.cli.Exec(ctx, query, id)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
+func promoCodeRestrictionValues(count int) string {
+	placeholders := make([]string, count)
+	for i := range placeholders {
+		placeholders[i] = fmt.Sprintf("($1,$%d)", i+2) // nolint:mnd
+	}
+	return strings.Join(placeholders, ",")
+}
+
+func promoCodeRestrictionArgs(promoCodeId int32, ids []int32) []any {
+	args := make([]any, 0, len(ids)+1)
+	args = append(args, promoCodeId)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	return args
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/Falokut/go-kit/db"
+	"github.com/pkg/errors"
+)
+
+type Rating struct {
+	cli db.DB
+}
+
+func NewRating(cli db.DB) Rating {
+	return Rating{
+		cli: cli,
+	}
+}
+
+func (r Rating) UpsertOrderRating(ctx context.Context, orderId string, score int32) error {
+	query := `INSERT INTO order_ratings(order_id, score) VALUES($1,$2)
+	ON CONFLICT (order_id) DO UPDATE SET score=EXCLUDED.score, updated_at=now()`
+	_, err := r.cli.Exec(ctx, query, orderId, score)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
+func (r Rating) SetOrderRatingComment(ctx context.Context, orderId string, comment string) error {
+	query := "UPDATE order_ratings SET comment=$1, updated_at=now() WHERE order_id=$2"
+	res, err := r.cli.Exec(ctx, query, comment, orderId)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	affected, _ := res.RowsAffected()
+	if affected == 0 {
+		return domain.ErrOrderRatingNotFound
+	}
+	return nil
+}
+
+//nolint:mnd
+func (r Rating) UpsertRestaurantRatings(ctx context.Context, orderId string, ratings []entity.RestaurantRating) error {
+	args := make([]any, 0, len(ratings)*2+1)
+	args = append(args, orderId)
+	placeholders := make([]string, len(ratings))
+	for i, rating := range ratings {
+		placeholders[i] = fmt.Sprintf("($1,$%d,$%d)", len(args)+1, len(args)+2)
+		args = append(args, rating.RestaurantId, rating.Score)
+	}
+
+	query := fmt.Sprintf(`INSERT INTO restaurant_ratings(order_id, restaurant_id, score) VALUES %s
+	ON CONFLICT (order_id, restaurant_id) DO UPDATE SET score=EXCLUDED.score`,
+		strings.Join(placeholders, ","))
+	_, err := r.cli.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
+
+func (r Rating) GetOrderRating(ctx context.Context, orderId string) (entity.OrderRating, error) {
+	query := `
+	SELECT order_id, score, COALESCE(comment, '') AS comment, created_at, updated_at
+	FROM order_ratings
+	WHERE order_id=$1`
+	var rating entity.OrderRating
+	err := r.cli.SelectRow(ctx, &rating, query, orderId)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return entity.OrderRating{}, domain.ErrOrderRatingNotFound
+	case err != nil:
+		return entity.OrderRating{}, errors.WithMessagef(err, "exec query '%s'", query)
+	default:
+		return rating, nil
+	}
+}
+
+func (r Rating) GetRestaurantRatings(ctx context.Context, orderId string) ([]entity.RestaurantRating, error) {
+	query := `
+	SELECT rr.restaurant_id, r.name AS restaurant_name, rr.score
+	FROM restaurant_ratings rr
+	JOIN restaurants r ON rr.restaurant_id = r.id
+	WHERE rr.order_id=$1
+	ORDER BY r.name`
+	var ratings []entity.RestaurantRating
+	err := r.cli.Select(ctx, &ratings, query, orderId)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return ratings, nil
+}
+
+func (r Rating) GetLowRatings(ctx context.Context, maxScore int32, limit int32, offset int32) ([]entity.LowRating, error) {
+	query := `
+	SELECT
+		orr.order_id,
+		o.user_id,
+		u.username,
+		orr.score,
+		COALESCE(orr.comment, '') AS comment,
+		orr.created_at,
+		COALESCE(
+			json_agg(
+				json_build_object(
+				'restaurantId', rr.restaurant_id,
+				'restaurantName', r.name,
+				'score', rr.score
+				)
+			) FILTER (WHERE rr.restaurant_id IS NOT NULL),
+		'[]') AS restaurants
+	FROM order_ratings orr
+	JOIN orders o ON orr.order_id = o.id
+	JOIN users u ON o.user_id = u.id
+	LEFT JOIN restaurant_ratings rr ON orr.order_id = rr.order_id
+	LEFT JOIN restaurants r ON rr.restaurant_id = r.id
+	WHERE orr.score <= $1 OR EXISTS(
+		SELECT 1 FROM restaurant_ratings lrr
+		WHERE lrr.order_id = orr.order_id AND lrr.score <= $1)
+	GROUP BY orr.order_id, o.user_id, u.username
+	ORDER BY orr.created_at DESC, orr.order_id
+	LIMIT $2
+	OFFSET $3`
+	var ratings []entity.LowRating
+	err := r.cli.Select(ctx, &ratings, query, maxScore, limit, offset)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return ratings, nil
+}
@@ -20,7 +20,18 @@ func NewRestaurant(cli db.DB) Restaurant {
 	return Restaurant{cli: cli}
 }
 func (r Restaurant) GetAllRestaurants(ctx context.Context) ([]entity.Restaurant, error) {
-	const query = "SELECT id, name FROM restaurants ORDER BY id"
+	const query = `
+	SELECT
+		r.id,
+		r.name,
+		r.min_order_total,
+		r.delivery_fee,
+		r.free_delivery_threshold,
+		COALESCE(rrs.rating, 0) AS rating,
+		COALESCE(rrs.ratings_count, 0) AS ratings_count
+	FROM restaurants AS r
+	LEFT JOIN restaurant_rating_stats AS rrs ON r.id = rrs.restaurant_id
+	ORDER BY r.id`
 	var restaurants []entity.Restaurant
 	err := r.cli.Select(ctx, &restaurants, query)
 	if err != nil {
@@ -30,7 +41,18 @@ func (r Restaurant) GetAllRestaurants(ctx context.Context) ([]entity.Restaurant,
 }
 
 func (r Restaurant) GetRestaurant(ctx context.Context, id int32) (entity.Restaurant, error) {
-	const query = "SELECT id, name FROM restaurants WHERE id=$1"
+	const query = `
+	SELECT
+		r.id,
+		r.name,
+		r.min_order_total,
+		r.delivery_fee,
+		r.free_delivery_threshold,
+		COALESCE(rrs.rating, 0) AS rating,
+		COALESCE(rrs.ratings_count, 0) AS ratings_count
+	FROM restaurants AS r
+	LEFT JOIN restaurant_rating_stats AS rrs ON r.id = rrs.restaurant_id
+	WHERE r.id=$1`
 	var restaurantName entity.Restaurant
 	err := r.cli.SelectRow(ctx, &restaurantName, query, id)
 	switch {
@@ -43,6 +65,28 @@ func (r Restaurant) GetRestaurant(ctx context.Context, id int32) (entity.Restaur
 	}
 }
 
+func (r Restaurant) GetRestaurantsByIds(ctx context.Context, ids []int32) ([]entity.Restaurant, error) {
+	const query = `
+	SELECT
+		r.id,
+		r.name,
+		r.min_order_total,
+		r.delivery_fee,
+		r.free_delivery_threshold,
+		COALESCE(rrs.rating, 0) AS rating,
+		COALESCE(rrs.ratings_count, 0) AS ratings_count
+	FROM restaurants AS r
+	LEFT JOIN restaurant_rating_stats AS rrs ON r.id = rrs.restaurant_id
+	WHERE r.id=ANY($1)
+	ORDER BY r.id`
+	var restaurants []entity.Restaurant
+	err := r.cli.Select(ctx, &restaurants, query, ids)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return restaurants, nil
+}
+
 func (r Restaurant) InsertRestaurant(ctx context.Context, restaurantName string) (int32, error) {
 	query := `WITH e AS(
     INSERT INTO restaurants (name) 
@@ -74,6 +118,22 @@ func (r Restaurant) RenameRestaurant(ctx context.Context, id int32, newName stri
 	}
 }
 
+func (r Restaurant) UpdateRestaurantTerms(ctx context.Context, id int32, terms entity.RestaurantTerms) error {
+	const query = `
+	UPDATE restaurants
+	SET min_order_total = $1, delivery_fee = $2, free_delivery_threshold = $3
+	WHERE id = $4`
+	res, err := r.cli.Exec(ctx, query, terms.MinOrderTotal, terms.DeliveryFee, terms.FreeDeliveryThreshold, id)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	affected, _ := res.RowsAffected()
+	if affected == 0 {
+		return domain.ErrRestaurantNotFound
+	}
+	return nil
+}
+
 func (r Restaurant) DeleteRestaurant(ctx context.Context, id int32) error {
 	const query = "DELETE FROM restaurants WHERE id=$1"
 	_, err := r.cli.Exec(ctx, query, id)
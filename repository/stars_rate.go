@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+
+	"dishes-service-backend/entity"
+
+	"github.com/Falokut/go-kit/db"
+	"github.com/pkg/errors"
+)
+
+type StarsRate struct {
+	cli db.DB
+}
+
+func NewStarsRate(cli db.DB) StarsRate {
+	return StarsRate{
+		cli: cli,
+	}
+}
+
+func (r StarsRate) GetStarsRate(ctx context.Context) (entity.StarsRate, error) {
+	query := "SELECT star_price, updated_at FROM stars_rate"
+	var rate entity.StarsRate
+	err := r.cli.SelectRow(ctx, &rate, query)
+	if err != nil {
+		return entity.StarsRate{}, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return rate, nil
+}
+
+func (r StarsRate) SetStarPrice(ctx context.Context, starPrice int32) error {
+	query := "UPDATE stars_rate SET star_price=$1, updated_at=now()"
+	_, err := r.cli.Exec(ctx, query, starPrice)
+	if err != nil {
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return nil
+}
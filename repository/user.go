@@ -97,6 +97,20 @@ func (r User) GetUserChatId(ctx context.Context, userId string) (int64, error) {
 	}
 }
 
+func (r User) GetUserTelegramId(ctx context.Context, userId string) (int64, error) {
+	query := "SELECT telegram_id FROM users_telegrams WHERE id=$1"
+	var telegramId int64
+	err := r.cli.SelectRow(ctx, &telegramId, query, userId)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, domain.ErrUserNotFound
+	case err != nil:
+		return 0, errors.WithMessagef(err, "exec query '%s'", query)
+	default:
+		return telegramId, nil
+	}
+}
+
 func (r User) GetUserByTelegramId(ctx context.Context, telegramId int64) (entity.User, error) {
 	query := `
 	SELECT u.id, u.username, u.name, u.admin
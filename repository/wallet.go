@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/Falokut/go-kit/db"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+)
+
+type Wallet struct {
+	cli db.DB
+}
+
+func NewWallet(cli db.DB) Wallet {
+	return Wallet{
+		cli: cli,
+	}
+}
+
+// GetWallet returns the user wallet, the wallet is empty until its first top-up
+func (r Wallet) GetWallet(ctx context.Context, userId string) (entity.Wallet, error) {
+	query := "SELECT user_id, balance, updated_at FROM wallets WHERE user_id=$1"
+	var wallet entity.Wallet
+	err := r.cli.SelectRow(ctx, &wallet, query, userId)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return entity.Wallet{UserId: userId}, nil
+	case err != nil:
+		return entity.Wallet{}, errors.WithMessagef(err, "exec query '%s'", query)
+	default:
+		return wallet, nil
+	}
+}
+
+// GetWalletBalanceForUpdate locks the user wallet until the end of the transaction
+func (r Wallet) GetWalletBalanceForUpdate(ctx context.Context, userId string) (int64, error) {
+	query := "SELECT balance FROM wallets WHERE user_id=$1 FOR UPDATE"
+	var balance int64
+	err := r.cli.SelectRow(ctx, &balance, query, userId)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, nil
+	case err != nil:
+		return 0, errors.WithMessagef(err, "exec query '%s'", query)
+	default:
+		return balance, nil
+	}
+}
+
+// AddWalletBalance changes the wallet balance by amount and returns the new balance
+func (r Wallet) AddWalletBalance(ctx context.Context, userId string, amount int64) (int64, error) {
+	query := `
+	INSERT INTO wallets(user_id, balance) VALUES($1,$2)
+	ON CONFLICT (user_id) DO UPDATE SET balance=wallets.balance+EXCLUDED.balance, updated_at=now()
+	RETURNING balance`
+	var balance int64
+	err := r.cli.SelectRow(ctx, &balance, query, userId, amount)
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &pgErr) && pgErr.SQLState() == pgerrcode.CheckViolation:
+		return 0, domain.ErrInsufficientFunds
+	case errors.As(err, &pgErr) && pgErr.SQLState() == pgerrcode.ForeignKeyViolation:
+		return 0, domain.ErrUserNotFound
+	case err != nil:
+		return 0, errors.WithMessagef(err, "exec query '%s'", query)
+	default:
+		return balance, nil
+	}
+}
+
+func (r Wallet) InsertWalletTransaction(ctx context.Context, transaction entity.WalletTransaction) error {
+	query := `
+	INSERT INTO wallet_transactions(user_id, type, amount, balance_after, order_id, external_id, actor, comment, created_at)
+	VALUES($1,$2,$3,$4,NULLIF($5,'')::uuid,NULLIF($6,''),$7,$8,$9)`
+	_, err := r.cli.Exec(ctx, query,
+		transaction.UserId,
+		transaction.Type,
+		transaction.Amount,
+		transaction.BalanceAfter,
+		transaction.OrderId,
+		transaction.ExternalId,
+		transaction.Actor,
+		transaction.Comment,
+		transaction.CreatedAt,
+	)
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &pgErr) && pgErr.SQLState() == pgerrcode.UniqueViolation:
+		return domain.ErrWalletTransactionExists
+	case err != nil:
+		return errors.WithMessagef(err, "exec query '%s'", query)
+	default:
+		return nil
+	}
+}
+
+func (r Wallet) GetWalletTransactions(
+	ctx context.Context,
+	userId string,
+	limit int32,
+	offset int32,
+) ([]entity.WalletTransaction, error) {
+	query := `
+	SELECT
+		id,
+		user_id,
+		type,
+		amount,
+		balance_after,
+		COALESCE(order_id::text, '') AS order_id,
+		COALESCE(external_id, '') AS external_id,
+		actor,
+		comment,
+		created_at
+	FROM wallet_transactions
+	WHERE user_id=$1
+	ORDER BY id DESC
+	LIMIT $2 OFFSET $3`
+	var transactions []entity.WalletTransaction
+	err := r.cli.Select(ctx, &transactions, query, userId, limit, offset)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "exec query '%s'", query)
+	}
+	return transactions, nil
+}
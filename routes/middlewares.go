@@ -45,7 +45,8 @@ func AuthToken(tokenSecret string, roles ...string) http2.Middleware {
 			if err != nil {
 				return err
 			}
-			r.Header.Add(domain.UserIdHeader, userInfo.UserId)
+			r.Header.Set(domain.UserIdHeader, userInfo.UserId)
+			r.Header.Set(domain.UserRoleHeader, userInfo.RoleName)
 			if len(roles) == 0 {
 				return next(ctx, w, r)
 			}
@@ -16,11 +16,23 @@ const (
 )
 
 type Router struct {
-	Auth         controller.Auth
-	Dish         controller.Dish
-	DishCategory controller.DishCategory
-	Order        controller.Order
-	Restaurant   controller.Restaurant
+	Auth           controller.Auth
+	Dish           controller.Dish
+	DishCategory   controller.DishCategory
+	Order          controller.Order
+	Restaurant     controller.Restaurant
+	DeliverySlot   controller.DeliverySlot
+	GroupOrder     controller.GroupOrder
+	Cart           controller.Cart
+	PromoCode      controller.PromoCode
+	DishOption     controller.DishOption
+	Rating         controller.Rating
+	OrderLimits    controller.OrderLimits
+	Company        controller.Company
+	Wallet         controller.Wallet
+	PaymentGateway controller.PaymentGateway
+	StarsRate      controller.StarsRate
+	PaymentLedger  controller.PaymentLedger
 }
 
 func (r Router) Handler(authMiddleware AuthMiddleware, wrapper endpoint.Wrapper) *router.Router {
@@ -64,6 +76,23 @@ func EndpointDescriptors(r Router) []cluster.EndpointDescriptor {
 			Handler:    r.Dish.DeleteDish,
 			Extra:      map[string]any{withAdminAuthKey: true},
 		},
+		{
+			HttpMethod: http.MethodGet,
+			Path:       "/dishes/options/:id",
+			Handler:    r.DishOption.GetDishOptions,
+		},
+		{
+			HttpMethod: http.MethodPost,
+			Path:       "/dishes/options/:id",
+			Handler:    r.DishOption.AddDishOptionGroup,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodDelete,
+			Path:       "/dishes/option_groups/:id",
+			Handler:    r.DishOption.DeleteDishOptionGroup,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
 		{
 			HttpMethod: http.MethodGet,
 			Path:       "/dishes/all_categories",
@@ -119,6 +148,12 @@ func EndpointDescriptors(r Router) []cluster.EndpointDescriptor {
 			Handler:    r.Restaurant.RenameRestaurant,
 			Extra:      map[string]any{withAdminAuthKey: true},
 		},
+		{
+			HttpMethod: http.MethodPost,
+			Path:       "/restaurants/:id/terms",
+			Handler:    r.Restaurant.SetRestaurantTerms,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
 		{
 			HttpMethod: http.MethodDelete,
 			Path:       "/restaurants/:id",
@@ -132,12 +167,298 @@ func EndpointDescriptors(r Router) []cluster.EndpointDescriptor {
 			Handler:    r.Order.ProcessOrder,
 			Extra:      map[string]any{withUserAuthKey: true},
 		},
+		{
+			HttpMethod: http.MethodGet,
+			Path:       "/orders",
+			Handler:    r.Order.ListOrders,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodPost,
+			Path:       "/orders/:id/status",
+			Handler:    r.Order.ChangeOrderStatus,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
 		{
 			HttpMethod: http.MethodGet,
 			Path:       "/orders/my",
 			Handler:    r.Order.GetUserOrders,
 			Extra:      map[string]any{withUserAuthKey: true},
 		},
+		{
+			HttpMethod: http.MethodGet,
+			Path:       "/orders/details/:id",
+			Handler:    r.Order.GetOrder,
+			Extra:      map[string]any{withUserAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodGet,
+			Path:       "/orders/details/:id/history",
+			Handler:    r.Order.GetOrderStatusHistory,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodPost,
+			Path:       "/orders/:id/cancel",
+			Handler:    r.Order.CancelOrder,
+			Extra:      map[string]any{withUserAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodPost,
+			Path:       "/orders/:id/items/cancel",
+			Handler:    r.Order.CancelOrderItems,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodPost,
+			Path:       "/orders/:id/fulfilments",
+			Handler:    r.Order.SetFulfilmentStatus,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodPost,
+			Path:       "/orders/:id/rating",
+			Handler:    r.Rating.RateOrder,
+			Extra:      map[string]any{withUserAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodGet,
+			Path:       "/orders/details/:id/rating",
+			Handler:    r.Rating.GetOrderRating,
+			Extra:      map[string]any{withUserAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodGet,
+			Path:       "/ratings/low",
+			Handler:    r.Rating.GetLowRatings,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodPost,
+			Path:       "/orders/:id/repeat",
+			Handler:    r.Order.RepeatOrder,
+			Extra:      map[string]any{withUserAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodPost,
+			Path:       "/group_orders",
+			Handler:    r.GroupOrder.CreateGroupOrder,
+			Extra:      map[string]any{withUserAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodGet,
+			Path:       "/group_orders/:id",
+			Handler:    r.GroupOrder.GetGroupOrder,
+			Extra:      map[string]any{withUserAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodPost,
+			Path:       "/group_orders/:id/items",
+			Handler:    r.GroupOrder.SetGroupOrderItems,
+			Extra:      map[string]any{withUserAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodPost,
+			Path:       "/group_orders/:id/lock",
+			Handler:    r.GroupOrder.LockGroupOrder,
+			Extra:      map[string]any{withUserAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodGet,
+			Path:       "/cart",
+			Handler:    r.Cart.GetCart,
+			Extra:      map[string]any{withUserAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodDelete,
+			Path:       "/cart",
+			Handler:    r.Cart.Clear,
+			Extra:      map[string]any{withUserAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodPost,
+			Path:       "/cart/items",
+			Handler:    r.Cart.AddItem,
+			Extra:      map[string]any{withUserAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodPost,
+			Path:       "/cart/items/:id",
+			Handler:    r.Cart.SetItemCount,
+			Extra:      map[string]any{withUserAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodDelete,
+			Path:       "/cart/items/:id",
+			Handler:    r.Cart.RemoveItem,
+			Extra:      map[string]any{withUserAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodPost,
+			Path:       "/cart/checkout",
+			Handler:    r.Cart.Checkout,
+			Extra:      map[string]any{withUserAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodGet,
+			Path:       "/delivery_slots",
+			Handler:    r.DeliverySlot.GetDeliverySlots,
+		},
+		{
+			HttpMethod: http.MethodPost,
+			Path:       "/delivery_slots",
+			Handler:    r.DeliverySlot.AddDeliverySlot,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodPost,
+			Path:       "/delivery_slots/:id",
+			Handler:    r.DeliverySlot.EditDeliverySlot,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodDelete,
+			Path:       "/delivery_slots/:id",
+			Handler:    r.DeliverySlot.DeleteDeliverySlot,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodGet,
+			Path:       "/promo_codes",
+			Handler:    r.PromoCode.GetPromoCodes,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodPost,
+			Path:       "/promo_codes",
+			Handler:    r.PromoCode.AddPromoCode,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodDelete,
+			Path:       "/promo_codes/:id",
+			Handler:    r.PromoCode.DeletePromoCode,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodGet,
+			Path:       "/order_limits",
+			Handler:    r.OrderLimits.GetOrderLimits,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodPost,
+			Path:       "/order_limits",
+			Handler:    r.OrderLimits.SetOrderLimits,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodGet,
+			Path:       "/order_limits/users/:id",
+			Handler:    r.OrderLimits.GetUserOrderLimits,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodPost,
+			Path:       "/order_limits/users/:id",
+			Handler:    r.OrderLimits.SetUserOrderLimits,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodDelete,
+			Path:       "/order_limits/users/:id",
+			Handler:    r.OrderLimits.DeleteUserOrderLimits,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodGet,
+			Path:       "/companies",
+			Handler:    r.Company.GetCompanies,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodPost,
+			Path:       "/companies",
+			Handler:    r.Company.AddCompany,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodPost,
+			Path:       "/companies/:id",
+			Handler:    r.Company.EditCompany,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodPost,
+			Path:       "/companies/:id/users",
+			Handler:    r.Company.AddCompanyUser,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodDelete,
+			Path:       "/companies/users/:id",
+			Handler:    r.Company.DeleteCompanyUser,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodGet,
+			Path:       "/companies/:id/statement",
+			Handler:    r.Company.GetStatement,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodGet,
+			Path:       "/wallet",
+			Handler:    r.Wallet.GetWallet,
+			Extra:      map[string]any{withUserAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodGet,
+			Path:       "/wallet/transactions",
+			Handler:    r.Wallet.GetTransactions,
+			Extra:      map[string]any{withUserAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodPost,
+			Path:       "/wallet/top_up",
+			Handler:    r.Wallet.TopUp,
+			Extra:      map[string]any{withUserAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodPost,
+			Path:       "/wallet/users/:id/adjust",
+			Handler:    r.Wallet.Adjust,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodPost,
+			Path:       "/payments/gateway/webhook",
+			Handler:    r.PaymentGateway.Webhook,
+		},
+		{
+			HttpMethod: http.MethodGet,
+			Path:       "/payments",
+			Handler:    r.PaymentLedger.ListPayments,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodGet,
+			Path:       "/payments/discrepancies",
+			Handler:    r.PaymentLedger.ListDiscrepancies,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodGet,
+			Path:       "/payments/stars_rate",
+			Handler:    r.StarsRate.GetStarsRate,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
+		{
+			HttpMethod: http.MethodPost,
+			Path:       "/payments/stars_rate",
+			Handler:    r.StarsRate.SetStarsRate,
+			Extra:      map[string]any{withAdminAuthKey: true},
+		},
 		{
 			HttpMethod: http.MethodPost,
 			Path:       "/auth/login_by_telegram",
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"strconv"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/Falokut/go-kit/http/apierrors"
+	"github.com/pkg/errors"
+)
+
+type CartRepo interface {
+	GetCartItems(ctx context.Context, userId string) ([]entity.CartItem, error)
+	AddCartItem(ctx context.Context, userId string, dishId int32, count int32, price int32) error
+	SetCartItemCount(ctx context.Context, userId string, dishId int32, count int32, price int32) error
+	DeleteCartItem(ctx context.Context, userId string, dishId int32) error
+	ClearCart(ctx context.Context, userId string) error
+	RefreshCartPrices(ctx context.Context, userId string) error
+}
+
+type CartDishRepo interface {
+	GetDishesByIds(ctx context.Context, ids []int32) ([]entity.Dish, error)
+}
+
+type CheckoutCartTx interface {
+	ProcessOrderTx
+	GetCartItems(ctx context.Context, userId string) ([]entity.CartItem, error)
+	ClearCart(ctx context.Context, userId string) error
+}
+
+type CartTxRunner interface {
+	CheckoutCartTx(ctx context.Context, tx func(ctx context.Context, tx CheckoutCartTx) error) error
+}
+
+type Cart struct {
+	orders   Order
+	repo     CartRepo
+	dishRepo CartDishRepo
+	txRunner CartTxRunner
+}
+
+func NewCart(orders Order, repo CartRepo, dishRepo CartDishRepo, txRunner CartTxRunner) Cart {
+	return Cart{
+		orders:   orders,
+		repo:     repo,
+		dishRepo: dishRepo,
+		txRunner: txRunner,
+	}
+}
+
+func (s Cart) GetCart(ctx context.Context, userId string) (*domain.Cart, error) {
+	items, err := s.repo.GetCartItems(ctx, userId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get cart items")
+	}
+
+	cart := &domain.Cart{
+		Items: make([]domain.CartItem, len(items)),
+	}
+	for i, item := range items {
+		cart.Items[i] = domain.CartItem{
+			DishId:         item.DishId,
+			Name:           item.Name,
+			RestaurantName: item.RestaurantName,
+			Price:          item.CurrentPrice,
+			Count:          item.Count,
+			TotalPrice:     item.CurrentPrice * item.Count,
+			PriceChanged:   item.Price != item.CurrentPrice,
+		}
+		cart.Total += item.CurrentPrice * item.Count
+	}
+	return cart, nil
+}
+
+func (s Cart) AddItem(ctx context.Context, userId string, req domain.AddCartItemRequest) error {
+	dish, err := s.getDish(ctx, req.DishId)
+	if err != nil {
+		return errors.WithMessage(err, "get dish")
+	}
+	err = s.repo.AddCartItem(ctx, userId, dish.Id, req.Count, dish.Price)
+	if err != nil {
+		return errors.WithMessage(err, "add cart item")
+	}
+	return nil
+}
+
+func (s Cart) SetItemCount(ctx context.Context, userId string, req domain.SetCartItemCountRequest) error {
+	dish, err := s.getDish(ctx, req.Id)
+	if err != nil {
+		return errors.WithMessage(err, "get dish")
+	}
+	err = s.repo.SetCartItemCount(ctx, userId, dish.Id, req.Count, dish.Price)
+	if err != nil {
+		return errors.WithMessage(err, "set cart item count")
+	}
+	return nil
+}
+
+func (s Cart) RemoveItem(ctx context.Context, userId string, dishId int32) error {
+	err := s.repo.DeleteCartItem(ctx, userId, dishId)
+	if err != nil {
+		return errors.WithMessage(err, "delete cart item")
+	}
+	return nil
+}
+
+func (s Cart) Clear(ctx context.Context, userId string) error {
+	err := s.repo.ClearCart(ctx, userId)
+	if err != nil {
+		return errors.WithMessage(err, "clear cart")
+	}
+	return nil
+}
+
+func (s Cart) Checkout(ctx context.Context, userId string, req domain.CheckoutCartRequest) (*domain.ProcessOrderResponse, error) {
+	if !s.orders.paymentService.IsPaymentMethodValid(req.PaymentMethod) {
+		return nil, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument, "invalid payment method", errors.New("invalid payment method"))
+	}
+
+	var resp *domain.ProcessOrderResponse
+	err := s.txRunner.CheckoutCartTx(ctx, func(ctx context.Context, tx CheckoutCartTx) error {
+		var err error
+		resp, err = s.checkout(ctx, tx, userId, req)
+		if err != nil {
+			return errors.WithMessage(err, "checkout")
+		}
+		return nil
+	})
+	if errors.Is(err, domain.ErrCartPricesChanged) {
+		// the user has been shown the error, next checkout uses actual prices
+		refreshErr := s.repo.RefreshCartPrices(ctx, userId)
+		if refreshErr != nil {
+			return nil, errors.WithMessage(refreshErr, "refresh cart prices")
+		}
+	}
+	if err != nil {
+		return nil, errors.WithMessage(err, "checkout cart tx")
+	}
+
+	url, err := s.orders.checkout(ctx, resp.OrderId, req.PaymentMethod)
+	if err != nil {
+		return nil, errors.WithMessage(err, "checkout payment")
+	}
+	if url != "" {
+		resp.PaymentUrl = url
+	}
+	return resp, nil
+}
+
+func (s Cart) checkout(
+	ctx context.Context,
+	tx CheckoutCartTx,
+	userId string,
+	req domain.CheckoutCartRequest,
+) (*domain.ProcessOrderResponse, error) {
+	cartItems, err := tx.GetCartItems(ctx, userId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get cart items")
+	}
+	if len(cartItems) == 0 {
+		return nil, domain.ErrCartEmpty
+	}
+
+	items := make(map[string]int32, len(cartItems))
+	for _, item := range cartItems {
+		if item.Price != item.CurrentPrice {
+			return nil, domain.ErrCartPricesChanged
+		}
+		items[strconv.Itoa(int(item.DishId))] = item.Count
+	}
+
+	resp, err := s.orders.processOrder(ctx, tx, userId, domain.ProcessOrderRequest{
+		Items:          items,
+		PaymentMethod:  req.PaymentMethod,
+		Wishes:         req.Wishes,
+		DeliverySlotId: req.DeliverySlotId,
+		PromoCode:      req.PromoCode,
+	}, "")
+	if err != nil {
+		return nil, errors.WithMessage(err, "process order")
+	}
+
+	err = tx.ClearCart(ctx, userId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "clear cart")
+	}
+	return resp, nil
+}
+
+func (s Cart) getDish(ctx context.Context, dishId int32) (entity.Dish, error) {
+	dishes, err := s.dishRepo.GetDishesByIds(ctx, []int32{dishId})
+	if err != nil {
+		return entity.Dish{}, errors.WithMessage(err, "get dishes by ids")
+	}
+	if len(dishes) == 0 {
+		return entity.Dish{}, domain.ErrDishNotFound
+	}
+	return dishes[0], nil
+}
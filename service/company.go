@@ -0,0 +1,172 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"time"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/Falokut/go-kit/http/apierrors"
+	"github.com/pkg/errors"
+)
+
+type CompanyRepo interface {
+	GetCompanies(ctx context.Context) ([]entity.Company, error)
+	GetCompany(ctx context.Context, id int32) (entity.Company, error)
+	InsertCompany(ctx context.Context, name string, creditLimit int32) (int32, error)
+	UpdateCompany(ctx context.Context, id int32, name string, creditLimit int32) error
+	UpsertCompanyUser(ctx context.Context, companyId int32, userId string) error
+	DeleteCompanyUser(ctx context.Context, userId string) error
+	GetCompanyOrders(ctx context.Context, companyId int32, start time.Time, end time.Time) ([]entity.CompanyOrder, error)
+}
+
+type Company struct {
+	repo CompanyRepo
+}
+
+func NewCompany(repo CompanyRepo) Company {
+	return Company{
+		repo: repo,
+	}
+}
+
+func (s Company) GetCompanies(ctx context.Context) ([]domain.Company, error) {
+	companies, err := s.repo.GetCompanies(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get companies")
+	}
+	result := make([]domain.Company, len(companies))
+	for i, company := range companies {
+		result[i] = domain.Company{
+			Id:          company.Id,
+			Name:        company.Name,
+			CreditLimit: company.CreditLimit,
+			CreatedAt:   company.CreatedAt,
+		}
+	}
+	return result, nil
+}
+
+func (s Company) AddCompany(ctx context.Context, req domain.AddCompanyRequest) (int32, error) {
+	id, err := s.repo.InsertCompany(ctx, req.Name, req.CreditLimit)
+	if err != nil {
+		return 0, errors.WithMessage(err, "insert company")
+	}
+	return id, nil
+}
+
+func (s Company) EditCompany(ctx context.Context, req domain.EditCompanyRequest) error {
+	err := s.repo.UpdateCompany(ctx, req.Id, req.Name, req.CreditLimit)
+	if err != nil {
+		return errors.WithMessage(err, "update company")
+	}
+	return nil
+}
+
+func (s Company) AddCompanyUser(ctx context.Context, req domain.AddCompanyUserRequest) error {
+	err := s.repo.UpsertCompanyUser(ctx, req.Id, req.UserId)
+	if err != nil {
+		return errors.WithMessage(err, "upsert company user")
+	}
+	return nil
+}
+
+func (s Company) DeleteCompanyUser(ctx context.Context, userId string) error {
+	err := s.repo.DeleteCompanyUser(ctx, userId)
+	if err != nil {
+		return errors.WithMessage(err, "delete company user")
+	}
+	return nil
+}
+
+// GetStatement returns the company orders paid in the month, month is in entity.MonthFormat
+func (s Company) GetStatement(ctx context.Context, companyId int32, month string) (*domain.CompanyStatement, error) {
+	start, err := time.ParseInLocation(entity.MonthFormat, month, time.Local)
+	if err != nil {
+		return nil, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument,
+			"неправильный формат месяца, должен быть: гггг.мм",
+			err,
+		)
+	}
+	company, err := s.repo.GetCompany(ctx, companyId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get company")
+	}
+	orders, err := s.repo.GetCompanyOrders(ctx, companyId, start, start.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, errors.WithMessage(err, "get company orders")
+	}
+
+	statement := &domain.CompanyStatement{
+		CompanyId:   company.Id,
+		CompanyName: company.Name,
+		Month:       month,
+		Orders:      make([]domain.CompanyStatementOrder, len(orders)),
+	}
+	for i, order := range orders {
+		statement.Orders[i] = domain.CompanyStatementOrder{
+			OrderId:   order.Id,
+			CreatedAt: order.CreatedAt,
+			Status:    order.Status,
+			Username:  order.Username,
+			Name:      order.Name,
+			Total:     order.Total,
+			Refunded:  order.Refunded,
+		}
+		statement.Total += order.Total
+	}
+	return statement, nil
+}
+
+// GetStatementFile returns the company statement in the csv or xlsx format
+func (s Company) GetStatementFile(ctx context.Context, companyId int32, month string, format string) ([]byte, error) {
+	statement, err := s.GetStatement(ctx, companyId, month)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get statement")
+	}
+
+	rows := make([][]string, 0, len(statement.Orders)+2) // nolint:mnd
+	rows = append(rows, []string{
+		"номер заказа",
+		"дата заказа",
+		"статус",
+		"имя сотрудника",
+		"telegram ник сотрудника",
+		"стоимость заказа",
+		"возврат",
+	})
+	for _, order := range statement.Orders {
+		rows = append(rows, []string{
+			order.OrderId,
+			order.CreatedAt.Local().Format(entity.DataFormat),
+			order.Status,
+			order.Name,
+			order.Username,
+			formatMoney(order.Total),
+			formatMoney(order.Refunded),
+		})
+	}
+	rows = append(rows, []string{"итого", "", "", "", "", formatMoney(statement.Total), ""})
+
+	switch format {
+	case domain.StatementFormatXlsx:
+		return writeXlsx(rows)
+	case domain.StatementFormatCsv:
+		rows[0][0] = "\uFEFF" + rows[0][0]
+		var b bytes.Buffer
+		writer := csv.NewWriter(&b)
+		err = writer.WriteAll(rows)
+		if err != nil {
+			return nil, errors.WithMessage(err, "write all")
+		}
+		return b.Bytes(), nil
+	default:
+		return nil, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument,
+			"неподдерживаемый формат выписки, должен быть: csv или xlsx",
+			errors.Errorf("unknown statement format '%s'", format),
+		)
+	}
+}
@@ -36,29 +36,43 @@ func (s CsvExporter) GetOrdersCsv(ctx context.Context, start time.Time, end time
 	toExport = append(toExport, []string{
 		"\uFEFFномер заказа",
 		"дата заказа",
+		"слот доставки",
+		"групповой заказ",
 		"статус",
 		"метод оплаты",
 		"telegram ник сотрудника",
 		"стоимость заказа",
+		"промокод",
+		"скидка",
+		"стоимость доставки",
+		"возврат",
 		"состав заказа",
 	})
 	for _, order := range orders {
 		orderItems := make([]string, 0, len(order.Items))
 		for _, item := range order.Items {
-			orderItems = append(orderItems,
-				fmt.Sprintf("блюдо: '%s' количество: %d итоговая цена: %s ресторан: %s",
-					item.Name, item.Count, formatMoney(item.Price), item.RestaurantName,
-				),
+			itemString := fmt.Sprintf("блюдо: '%s' количество: %d итоговая цена: %s ресторан: %s",
+				item.FullName(), item.Count, formatMoney(item.Price), item.RestaurantName,
 			)
+			if item.IsCanceled() {
+				itemString += " отменено"
+			}
+			orderItems = append(orderItems, itemString)
 		}
 
 		toExport = append(toExport, []string{
 			order.Id,
 			order.CreatedAt.Format(entity.DataFormat),
+			order.DeliverySlot,
+			order.GroupOrderId,
 			order.Status,
 			order.PaymentMethod,
 			order.Username,
 			formatMoney(order.Total),
+			order.PromoCode,
+			formatMoney(order.Discount),
+			formatMoney(order.DeliveryFee),
+			formatMoney(order.Refunded),
 			strings.Join(orderItems, ","),
 		})
 	}
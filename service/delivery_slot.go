@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/Falokut/go-kit/http/apierrors"
+	"github.com/pkg/errors"
+)
+
+type DeliverySlotRepo interface {
+	GetDeliverySlots(ctx context.Context, date time.Time) ([]entity.DeliverySlot, error)
+	InsertDeliverySlot(ctx context.Context, slot entity.DeliverySlot) (int32, error)
+	UpdateDeliverySlotCapacity(ctx context.Context, id int32, capacity int32) error
+	DeleteDeliverySlot(ctx context.Context, id int32) error
+}
+
+type DeliverySlot struct {
+	repo DeliverySlotRepo
+}
+
+func NewDeliverySlot(repo DeliverySlotRepo) DeliverySlot {
+	return DeliverySlot{
+		repo: repo,
+	}
+}
+
+func (s DeliverySlot) GetDeliverySlots(ctx context.Context, req domain.GetDeliverySlotsRequest) ([]domain.DeliverySlot, error) {
+	date, err := parseDeliverySlotDate(req.Date)
+	if err != nil {
+		return nil, err
+	}
+	slots, err := s.repo.GetDeliverySlots(ctx, date)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get delivery slots")
+	}
+
+	domainSlots := make([]domain.DeliverySlot, len(slots))
+	for i, slot := range slots {
+		domainSlots[i] = domain.DeliverySlot{
+			Id:        slot.Id,
+			Date:      slot.Date.Format(time.DateOnly),
+			StartTime: slot.StartTime,
+			EndTime:   slot.EndTime,
+			Capacity:  slot.Capacity,
+			Available: max(slot.Capacity-slot.Booked, 0),
+		}
+	}
+	return domainSlots, nil
+}
+
+func (s DeliverySlot) AddDeliverySlot(ctx context.Context, req domain.AddDeliverySlotRequest) (int32, error) {
+	date, err := parseDeliverySlotDate(req.Date)
+	if err != nil {
+		return 0, err
+	}
+	start, err := time.Parse(entity.DeliverySlotTimeFormat, req.StartTime)
+	if err != nil {
+		return 0, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument,
+			"неправильный формат начала слота, должен быть: чч:мм",
+			err,
+		)
+	}
+	end, err := time.Parse(entity.DeliverySlotTimeFormat, req.EndTime)
+	if err != nil {
+		return 0, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument,
+			"неправильный формат конца слота, должен быть: чч:мм",
+			err,
+		)
+	}
+	if !end.After(start) {
+		return 0, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument,
+			"конец слота должен быть позже его начала",
+			errors.New("invalid delivery slot period"),
+		)
+	}
+
+	id, err := s.repo.InsertDeliverySlot(ctx, entity.DeliverySlot{
+		Date:      date,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		Capacity:  req.Capacity,
+	})
+	if err != nil {
+		return 0, errors.WithMessage(err, "insert delivery slot")
+	}
+	return id, nil
+}
+
+func (s DeliverySlot) EditDeliverySlot(ctx context.Context, req domain.EditDeliverySlotRequest) error {
+	err := s.repo.UpdateDeliverySlotCapacity(ctx, req.Id, req.Capacity)
+	if err != nil {
+		return errors.WithMessage(err, "update delivery slot capacity")
+	}
+	return nil
+}
+
+func (s DeliverySlot) DeleteDeliverySlot(ctx context.Context, id int32) error {
+	err := s.repo.DeleteDeliverySlot(ctx, id)
+	if err != nil {
+		return errors.WithMessage(err, "delete delivery slot")
+	}
+	return nil
+}
+
+func parseDeliverySlotDate(date string) (time.Time, error) {
+	parsed, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return time.Time{}, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument,
+			"неправильный формат даты, должен быть: гггг-мм-дд",
+			err,
+		)
+	}
+	return parsed, nil
+}
@@ -272,5 +272,7 @@ func (s Dish) dishFromEntity(dish entity.Dish) domain.Dish {
 		Url:            s.fileRepo.GetFileUrl(dishImageCategory, dish.ImageId),
 		Categories:     categories,
 		RestaurantName: dish.RestaurantName,
+		Rating:         dish.Rating,
+		RatingsCount:   dish.RatingsCount,
 	}
 }
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/Falokut/go-kit/http/apierrors"
+	"github.com/pkg/errors"
+)
+
+type DishOptionRepo interface {
+	GetDishOptionGroups(ctx context.Context, dishIds []int32) ([]entity.DishOptionGroup, error)
+	DeleteDishOptionGroup(ctx context.Context, id int32) error
+}
+
+type AddDishOptionGroupTx interface {
+	InsertDishOptionGroup(ctx context.Context, group entity.DishOptionGroup) (int32, error)
+	InsertDishOptions(ctx context.Context, groupId int32, options []entity.DishOption) error
+}
+
+type DishOptionTxRunner interface {
+	AddDishOptionGroupTx(ctx context.Context, tx func(ctx context.Context, tx AddDishOptionGroupTx) error) error
+}
+
+type DishOption struct {
+	repo     DishOptionRepo
+	txRunner DishOptionTxRunner
+}
+
+func NewDishOption(repo DishOptionRepo, txRunner DishOptionTxRunner) DishOption {
+	return DishOption{
+		repo:     repo,
+		txRunner: txRunner,
+	}
+}
+
+func (s DishOption) GetDishOptions(ctx context.Context, dishId int32) ([]domain.DishOptionGroup, error) {
+	groups, err := s.repo.GetDishOptionGroups(ctx, []int32{dishId})
+	if err != nil {
+		return nil, errors.WithMessage(err, "get dish option groups")
+	}
+
+	domainGroups := make([]domain.DishOptionGroup, len(groups))
+	for i, group := range groups {
+		options := make([]domain.DishOption, len(group.Options))
+		for j, option := range group.Options {
+			options[j] = domain.DishOption{
+				Id:         option.Id,
+				Name:       option.Name,
+				PriceDelta: option.PriceDelta,
+			}
+		}
+		domainGroups[i] = domain.DishOptionGroup{
+			Id:       group.Id,
+			Name:     group.Name,
+			Required: group.Required,
+			MinCount: group.MinCount,
+			MaxCount: group.MaxCount,
+			Options:  options,
+		}
+	}
+	return domainGroups, nil
+}
+
+func (s DishOption) AddDishOptionGroup(ctx context.Context, req domain.AddDishOptionGroupRequest) (int32, error) {
+	group := entity.DishOptionGroup{
+		DishId:   req.Id,
+		Name:     req.Name,
+		Required: req.Required,
+		MinCount: req.MinCount,
+		MaxCount: req.MaxCount,
+		Options:  make(entity.DishOptions, len(req.Options)),
+	}
+	for i, option := range req.Options {
+		group.Options[i] = entity.DishOption{
+			Name:       option.Name,
+			PriceDelta: option.PriceDelta,
+		}
+	}
+	if group.Required {
+		group.MinCount = max(group.MinCount, 1)
+	}
+	if group.MaxCount == 0 {
+		group.MaxCount = int32(len(group.Options))
+	}
+	if group.MaxCount < group.MinCount || group.MaxCount > int32(len(group.Options)) {
+		return 0, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument,
+			"максимальное количество опций должно быть не меньше минимального и не больше количества опций",
+			errors.New("invalid dish option group counts"),
+		)
+	}
+
+	var id int32
+	err := s.txRunner.AddDishOptionGroupTx(ctx, func(ctx context.Context, tx AddDishOptionGroupTx) error {
+		var err error
+		id, err = tx.InsertDishOptionGroup(ctx, group)
+		if err != nil {
+			return errors.WithMessage(err, "insert dish option group")
+		}
+		err = tx.InsertDishOptions(ctx, id, group.Options)
+		if err != nil {
+			return errors.WithMessage(err, "insert dish options")
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, errors.WithMessage(err, "add dish option group tx")
+	}
+	return id, nil
+}
+
+func (s DishOption) DeleteDishOptionGroup(ctx context.Context, id int32) error {
+	err := s.repo.DeleteDishOptionGroup(ctx, id)
+	if err != nil {
+		return errors.WithMessage(err, "delete dish option group")
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/Falokut/go-kit/http/apierrors"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+type GroupOrderRepo interface {
+	InsertGroupOrder(ctx context.Context, order entity.GroupOrder) error
+	GetGroupOrder(ctx context.Context, id string) (entity.GroupOrder, error)
+	GetGroupOrderItems(ctx context.Context, id string) ([]entity.GroupOrderItem, error)
+}
+
+type GroupOrderItemsTx interface {
+	GetGroupOrderForUpdate(ctx context.Context, id string) (entity.GroupOrder, error)
+	GetDishesByIds(ctx context.Context, ids []int32) ([]entity.Dish, error)
+	DeleteGroupOrderUserItems(ctx context.Context, groupOrderId string, userId string) error
+	InsertGroupOrderItems(ctx context.Context, groupOrderId string, userId string, items map[int32]int32) error
+}
+
+type LockGroupOrderTx interface {
+	ProcessOrderTx
+	GetGroupOrderForUpdate(ctx context.Context, id string) (entity.GroupOrder, error)
+	GetGroupOrderItems(ctx context.Context, id string) ([]entity.GroupOrderItem, error)
+	LockGroupOrder(ctx context.Context, id string, lockedAt time.Time) error
+}
+
+type GroupOrderTxRunner interface {
+	GroupOrderItemsTx(ctx context.Context, tx func(ctx context.Context, tx GroupOrderItemsTx) error) error
+	LockGroupOrderTx(ctx context.Context, tx func(ctx context.Context, tx LockGroupOrderTx) error) error
+}
+
+type GroupOrder struct {
+	orders   Order
+	repo     GroupOrderRepo
+	txRunner GroupOrderTxRunner
+}
+
+func NewGroupOrder(orders Order, repo GroupOrderRepo, txRunner GroupOrderTxRunner) GroupOrder {
+	return GroupOrder{
+		orders:   orders,
+		repo:     repo,
+		txRunner: txRunner,
+	}
+}
+
+func (s GroupOrder) CreateGroupOrder(ctx context.Context, userId string, req domain.CreateGroupOrderRequest) (string, error) {
+	order := entity.GroupOrder{
+		Id:          uuid.NewString(),
+		OrganizerId: userId,
+		PaymentMode: req.PaymentMode,
+		Status:      entity.GroupOrderStatusOpen,
+		Wishes:      req.Wishes,
+		CreatedAt:   time.Now().UTC(),
+	}
+	err := s.repo.InsertGroupOrder(ctx, order)
+	if err != nil {
+		return "", errors.WithMessage(err, "insert group order")
+	}
+	return order.Id, nil
+}
+
+func (s GroupOrder) GetGroupOrder(ctx context.Context, id string) (*domain.GroupOrder, error) {
+	order, err := s.repo.GetGroupOrder(ctx, id)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get group order")
+	}
+	items, err := s.repo.GetGroupOrderItems(ctx, id)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get group order items")
+	}
+
+	groupOrder := &domain.GroupOrder{
+		Id:                order.Id,
+		OrganizerId:       order.OrganizerId,
+		OrganizerUsername: order.OrganizerUsername,
+		PaymentMode:       order.PaymentMode,
+		Status:            order.Status,
+		Wishes:            order.Wishes,
+		CreatedAt:         order.CreatedAt,
+		Participants:      make([]domain.GroupOrderParticipant, 0),
+	}
+	// items are sorted by participant
+	for _, item := range items {
+		last := len(groupOrder.Participants) - 1
+		if last < 0 || groupOrder.Participants[last].UserId != item.UserId {
+			groupOrder.Participants = append(groupOrder.Participants, domain.GroupOrderParticipant{
+				UserId:   item.UserId,
+				Username: item.Username,
+			})
+			last++
+		}
+		participant := &groupOrder.Participants[last]
+		participant.Items = append(participant.Items, domain.OrderItem{
+			DishId:     item.DishId,
+			Name:       item.Name,
+			Price:      item.Price,
+			Count:      item.Count,
+			TotalPrice: item.Price * item.Count,
+		})
+		participant.Total += item.Price * item.Count
+		groupOrder.Total += item.Price * item.Count
+	}
+	return groupOrder, nil
+}
+
+func (s GroupOrder) SetGroupOrderItems(ctx context.Context, userId string, req domain.SetGroupOrderItemsRequest) error {
+	items, err := convertMapStringToInt(req.Items)
+	if err != nil {
+		return apierrors.NewBusinessError(domain.ErrCodeInvalidArgument, "invalid order items", err)
+	}
+	for _, count := range items {
+		if count <= 0 {
+			return domain.ErrInvalidDishCount
+		}
+	}
+
+	err = s.txRunner.GroupOrderItemsTx(ctx, func(ctx context.Context, tx GroupOrderItemsTx) error {
+		err := s.setGroupOrderItems(ctx, tx, userId, req.Id, items)
+		if err != nil {
+			return errors.WithMessage(err, "set group order items")
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.WithMessage(err, "group order items tx")
+	}
+	return nil
+}
+
+func (s GroupOrder) setGroupOrderItems(
+	ctx context.Context,
+	tx GroupOrderItemsTx,
+	userId string,
+	groupOrderId string,
+	items map[int32]int32,
+) error {
+	order, err := tx.GetGroupOrderForUpdate(ctx, groupOrderId)
+	if err != nil {
+		return errors.WithMessage(err, "get group order")
+	}
+	if order.Status != entity.GroupOrderStatusOpen {
+		return domain.ErrGroupOrderLocked
+	}
+
+	err = tx.DeleteGroupOrderUserItems(ctx, groupOrderId, userId)
+	if err != nil {
+		return errors.WithMessage(err, "delete group order user items")
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	dishes, err := tx.GetDishesByIds(ctx, slices.Collect(maps.Keys(items)))
+	if err != nil {
+		return errors.WithMessage(err, "get dishes by ids")
+	}
+	if len(dishes) != len(items) {
+		return domain.ErrDishNotFound
+	}
+	err = tx.InsertGroupOrderItems(ctx, groupOrderId, userId, items)
+	if err != nil {
+		return errors.WithMessage(err, "insert group order items")
+	}
+	return nil
+}
+
+func (s GroupOrder) LockGroupOrder(
+	ctx context.Context,
+	userId string,
+	req domain.LockGroupOrderRequest,
+) (*domain.LockGroupOrderResponse, error) {
+	if !s.orders.paymentService.IsPaymentMethodValid(req.PaymentMethod) {
+		return nil, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument, "invalid payment method", errors.New("invalid payment method"))
+	}
+
+	var resp *domain.LockGroupOrderResponse
+	err := s.txRunner.LockGroupOrderTx(ctx, func(ctx context.Context, tx LockGroupOrderTx) error {
+		var err error
+		resp, err = s.lockGroupOrder(ctx, tx, userId, req)
+		if err != nil {
+			return errors.WithMessage(err, "lock group order")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "lock group order tx")
+	}
+
+	for i, payment := range resp.Orders {
+		url, err := s.orders.checkout(ctx, payment.OrderId, req.PaymentMethod)
+		if err != nil {
+			return nil, errors.WithMessage(err, "checkout")
+		}
+		if url != "" {
+			resp.Orders[i].PaymentUrl = url
+		}
+	}
+	return resp, nil
+}
+
+func (s GroupOrder) lockGroupOrder(
+	ctx context.Context,
+	tx LockGroupOrderTx,
+	userId string,
+	req domain.LockGroupOrderRequest,
+) (*domain.LockGroupOrderResponse, error) {
+	order, err := tx.GetGroupOrderForUpdate(ctx, req.Id)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get group order")
+	}
+	if order.OrganizerId != userId {
+		return nil, domain.ErrForbidden
+	}
+	if order.Status != entity.GroupOrderStatusOpen {
+		return nil, domain.ErrGroupOrderLocked
+	}
+
+	items, err := tx.GetGroupOrderItems(ctx, req.Id)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get group order items")
+	}
+	if len(items) == 0 {
+		return nil, domain.ErrGroupOrderEmpty
+	}
+
+	// participants pay for their own items, otherwise the organizer pays for everything
+	payers := make([]string, 0)
+	itemsByPayer := make(map[string]map[string]int32)
+	for _, item := range items {
+		payer := item.UserId
+		if order.PaymentMode == entity.GroupOrderPaymentModeOrganizer {
+			payer = order.OrganizerId
+		}
+		if _, ok := itemsByPayer[payer]; !ok {
+			payers = append(payers, payer)
+			itemsByPayer[payer] = make(map[string]int32)
+		}
+		itemsByPayer[payer][strconv.Itoa(int(item.DishId))] += item.Count
+	}
+
+	// all orders are created before any payment is started,
+	// so an invalid share doesn't leave invoices for the rolled back orders
+	orders := make([]*entity.Order, 0, len(payers))
+	for _, payer := range payers {
+		payerOrder, err := s.orders.createOrder(ctx, tx, payer, domain.ProcessOrderRequest{
+			Items:          itemsByPayer[payer],
+			PaymentMethod:  req.PaymentMethod,
+			Wishes:         order.Wishes,
+			DeliverySlotId: req.DeliverySlotId,
+		}, order.Id)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "create order, userId=%s", payer)
+		}
+		orders = append(orders, payerOrder)
+	}
+
+	err = tx.LockGroupOrder(ctx, order.Id, time.Now().UTC())
+	if err != nil {
+		return nil, errors.WithMessage(err, "lock group order")
+	}
+
+	resp := &domain.LockGroupOrderResponse{
+		Orders: make([]domain.GroupOrderPayment, 0, len(orders)),
+	}
+	for _, payerOrder := range orders {
+		processResp, err := s.orders.processPayment(ctx, tx, payerOrder)
+		if err != nil {
+			return nil, errors.WithMessage(err, "process payment")
+		}
+		resp.Orders = append(resp.Orders, domain.GroupOrderPayment{
+			UserId:     payerOrder.UserId,
+			OrderId:    processResp.OrderId,
+			PaymentUrl: processResp.PaymentUrl,
+		})
+	}
+	return resp, nil
+}
@@ -2,14 +2,20 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"dishes-service-backend/conf"
 	"dishes-service-backend/domain"
 	"dishes-service-backend/entity"
 
 	"github.com/Falokut/go-kit/http/apierrors"
+	"github.com/Falokut/go-kit/json"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"maps"
@@ -17,22 +23,68 @@ import (
 
 type PaymentService interface {
 	IsPaymentMethodValid(method string) bool
-	Process(ctx context.Context, order *entity.Order, method string) (string, error)
+	Process(ctx context.Context, order *entity.Order, method string) (entity.PaymentInvoice, error)
+	HasCheckout(method string) bool
+	Checkout(ctx context.Context, order *entity.Order) (string, error)
+	RemoveExpiration(ctx context.Context, orderId string) error
 }
 
 type OrderRepo interface {
 	GetOrder(ctx context.Context, orderId string) (*entity.Order, error)
-	SetOrderStatus(ctx context.Context, orderId string, oldStatus string, newStatus string) error
 	GetOrderStatus(ctx context.Context, orderId string) (string, error)
+	GetOrderStatusHistory(ctx context.Context, orderId string) ([]entity.OrderStatusHistory, error)
 	IsOrderingAllowed(ctx context.Context) (bool, error)
 	GetUserOrders(ctx context.Context, userId string, limit int32, offset int32) ([]entity.Order, error)
+	GetOrders(ctx context.Context, filter entity.OrdersFilter) ([]entity.AdminOrder, error)
+	CountOrders(ctx context.Context, filter entity.OrdersFilter) (int64, error)
+	GetOrderFulfilments(ctx context.Context, orderId string) ([]entity.OrderFulfilment, error)
 }
 
 type ProcessOrderTx interface {
 	IsOrderingAllowed(ctx context.Context) (bool, error)
 	InsertOrderItems(ctx context.Context, orderId string, items entity.OrderItems) error
+	InsertOrderFulfilments(ctx context.Context, orderId string, restaurantIds []int32) error
 	InsertOrder(ctx context.Context, order *entity.Order) error
+	InsertOrderStatusHistory(ctx context.Context, history entity.OrderStatusHistory) error
 	GetDishesByIds(ctx context.Context, ids []int32) ([]entity.Dish, error)
+	LockIdempotencyKey(ctx context.Context, userId string, key string) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context, userId string) error
+	GetIdempotencyKey(ctx context.Context, userId string, key string) (entity.IdempotencyKey, error)
+	InsertIdempotencyKey(ctx context.Context, key entity.IdempotencyKey) error
+	GetDeliverySlotForUpdate(ctx context.Context, id int32) (entity.DeliverySlot, error)
+	CountDeliverySlotOrders(ctx context.Context, id int32) (int32, error)
+	GetPromoCodeForUpdate(ctx context.Context, code string) (entity.PromoCode, error)
+	CountPromoCodeUsages(ctx context.Context, promoCodeId int32, userId string) (int32, error)
+	GetPromoCodeEligibleDishes(ctx context.Context, promoCodeId int32, dishIds []int32) ([]int32, error)
+	GetRestaurantsByIds(ctx context.Context, ids []int32) ([]entity.Restaurant, error)
+	GetDishOptionGroups(ctx context.Context, dishIds []int32) ([]entity.DishOptionGroup, error)
+	GetUserOrderLimitsForUpdate(ctx context.Context, userId string) (entity.UserOrderLimits, error)
+	GetUserOrdersStats(ctx context.Context, userId string, from time.Time) (entity.UserOrdersStats, error)
+	InsertPayment(ctx context.Context, payment entity.Payment) error
+}
+
+type OrderStatusService interface {
+	ChangeStatus(ctx context.Context, req entity.OrderStatusChange) error
+	ChangeStatusTx(ctx context.Context, tx OrderStatusTx, req entity.OrderStatusChange) error
+}
+
+type OrderPaymentService interface {
+	Pay(ctx context.Context, req entity.OrderStatusChange, payment entity.OrderPayment) error
+	MarkPaid(ctx context.Context, req entity.OrderStatusChange) error
+	Cancel(ctx context.Context, req entity.OrderStatusChange, payment entity.OrderPayment) error
+	CancelTx(ctx context.Context, tx OrderPaymentTx, req entity.OrderStatusChange, payment entity.OrderPayment) error
+	RefundItemsTx(ctx context.Context, tx OrderPaymentTx, order *entity.Order, amount int32, actor string, comment string) error
+}
+
+type CancelOrderItemsTx interface {
+	OrderPaymentTx
+	CancelOrderItems(ctx context.Context, orderId string, itemIds []int64) error
+	AddOrderRefund(ctx context.Context, orderId string, refund int32) error
+}
+
+type OrderNotifier interface {
+	NotifyOrderItemsCanceled(ctx context.Context, orderId string, items []entity.OrderItem, refund int32, reason string) error
+	RequestOrderRating(ctx context.Context, orderId string) error
 }
 
 type OrderingAllowTx interface {
@@ -44,32 +96,78 @@ type OrderingAllowTx interface {
 type OrdersTxRunner interface {
 	ProcessOrderTx(ctx context.Context, tx func(ctx context.Context, tx ProcessOrderTx) error) error
 	SetOrderingAllowedTx(ctx context.Context, tx func(ctx context.Context, tx OrderingAllowTx) error) error
+	CancelOrderItemsTx(ctx context.Context, tx func(ctx context.Context, tx CancelOrderItemsTx) error) error
+	SetFulfilmentStatusTx(ctx context.Context, tx func(ctx context.Context, tx SetFulfilmentStatusTx) error) error
 }
 
 const (
-	defaultUserOrdersLimit = 30
+	defaultUserOrdersLimit   = 30
+	defaultOrdersLimit       = 50
+	defaultIdempotencyKeyTtl = 24 * time.Hour
 )
 
 type Order struct {
-	paymentService PaymentService
-	orderRepo      OrderRepo
-	txRunner       OrdersTxRunner
+	paymentService    PaymentService
+	statusService     OrderStatusService
+	orderPayments     OrderPaymentService
+	orderRepo         OrderRepo
+	txRunner          OrdersTxRunner
+	notifier          OrderNotifier
+	idempotencyKeyTtl time.Duration
 }
 
-func NewOrder(paymentService PaymentService, orderRepo OrderRepo, txRunner OrdersTxRunner) Order {
+func NewOrder(
+	paymentService PaymentService,
+	statusService OrderStatusService,
+	orderPayments OrderPaymentService,
+	orderRepo OrderRepo,
+	txRunner OrdersTxRunner,
+	notifier OrderNotifier,
+	cfg conf.Orders,
+) Order {
+	idempotencyKeyTtl := time.Duration(cfg.IdempotencyKeyTtlHours) * time.Hour
+	if idempotencyKeyTtl == 0 {
+		idempotencyKeyTtl = defaultIdempotencyKeyTtl
+	}
 	return Order{
-		paymentService: paymentService,
-		orderRepo:      orderRepo,
-		txRunner:       txRunner,
+		paymentService:    paymentService,
+		statusService:     statusService,
+		orderPayments:     orderPayments,
+		orderRepo:         orderRepo,
+		txRunner:          txRunner,
+		notifier:          notifier,
+		idempotencyKeyTtl: idempotencyKeyTtl,
 	}
 }
 
-func (s Order) SetOrderStatus(ctx context.Context, orderId string, oldStatus string, newStatus string) error {
-	err := s.orderRepo.SetOrderStatus(ctx, orderId, oldStatus, newStatus)
+// SetOrderStatus changes the order status, the unfinished parts of the completed order are delivered with it
+func (s Order) SetOrderStatus(ctx context.Context, req entity.OrderStatusChange) error {
+	err := s.txRunner.SetFulfilmentStatusTx(ctx, func(ctx context.Context, tx SetFulfilmentStatusTx) error {
+		err := s.statusService.ChangeStatusTx(ctx, tx, req)
+		if err != nil {
+			return errors.WithMessage(err, "change order status")
+		}
+		if req.To != entity.OrderItemStatusSuccess {
+			return nil
+		}
+		err = tx.FinishOrderFulfilments(ctx, req.OrderId, entity.FulfilmentStatusDelivered)
+		if err != nil {
+			return errors.WithMessage(err, "deliver order fulfilments")
+		}
+		return nil
+	})
 	if err != nil {
-		return errors.WithMessage(err, "update order status")
+		return errors.WithMessagef(err, "set fulfilment status tx, orderId=%s", req.OrderId)
 	}
+	return nil
+}
 
+// PayOrder moves the order to PAID with the payment reported by the payment method
+func (s Order) PayOrder(ctx context.Context, req entity.OrderStatusChange, payment entity.OrderPayment) error {
+	err := s.orderPayments.Pay(ctx, req, payment)
+	if err != nil {
+		return errors.WithMessage(err, "pay order")
+	}
 	return nil
 }
 
@@ -127,56 +225,156 @@ func (s Order) IsOrderingAllowed(ctx context.Context) (bool, error) {
 	return allowed, nil
 }
 
-func (s Order) ProcessOrder(ctx context.Context, userId string, req domain.ProcessOrderRequest) (string, error) {
+func (s Order) ProcessOrder(
+	ctx context.Context,
+	userId string,
+	idempotencyKey string,
+	req domain.ProcessOrderRequest,
+) (*domain.ProcessOrderResponse, error) {
 	if !s.paymentService.IsPaymentMethodValid(req.PaymentMethod) {
-		return "", apierrors.NewBusinessError(domain.ErrCodeInvalidArgument, "invalid payment method", errors.New("invalid payment method"))
+		return nil, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument, "invalid payment method", errors.New("invalid payment method"))
 	}
 
-	url := ""
+	var resp *domain.ProcessOrderResponse
 	var err error
 	err = s.txRunner.ProcessOrderTx(ctx, func(ctx context.Context, tx ProcessOrderTx) error {
-		url, err = s.processOrder(ctx, tx, userId, req)
+		if idempotencyKey == "" {
+			resp, err = s.processOrder(ctx, tx, userId, req, "")
+		} else {
+			resp, err = s.processOrderIdempotent(ctx, tx, userId, idempotencyKey, req)
+		}
 		if err != nil {
 			return errors.WithMessage(err, "process order")
 		}
 		return nil
 	})
 	if err != nil {
-		return "", errors.WithMessage(err, "process order tx")
+		return nil, errors.WithMessage(err, "process order tx")
 	}
 
-	return url, nil
+	url, err := s.checkout(ctx, resp.OrderId, req.PaymentMethod)
+	if err != nil {
+		return nil, errors.WithMessage(err, "checkout")
+	}
+	if url != "" {
+		resp.PaymentUrl = url
+	}
+	return resp, nil
 }
 
-func (s Order) processOrder(ctx context.Context, tx ProcessOrderTx, userId string, req domain.ProcessOrderRequest) (string, error) {
-	items, err := convertMapStringToInt(req.Items)
+func (s Order) processOrderIdempotent(
+	ctx context.Context,
+	tx ProcessOrderTx,
+	userId string,
+	idempotencyKey string,
+	req domain.ProcessOrderRequest,
+) (*domain.ProcessOrderResponse, error) {
+	requestHash, err := hashOrderRequest(req)
 	if err != nil {
-		return "", apierrors.NewBusinessError(domain.ErrCodeInvalidArgument, "invalid order items", err)
+		return nil, errors.WithMessage(err, "hash order request")
 	}
-	for _, count := range items {
-		if count <= 0 {
-			return "", domain.ErrInvalidDishCount
-		}
+	err = tx.LockIdempotencyKey(ctx, userId, idempotencyKey)
+	if err != nil {
+		return nil, errors.WithMessage(err, "lock idempotency key")
+	}
+	err = tx.DeleteExpiredIdempotencyKeys(ctx, userId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "delete expired idempotency keys")
+	}
+
+	stored, err := tx.GetIdempotencyKey(ctx, userId, idempotencyKey)
+	switch {
+	case err == nil && stored.RequestHash != requestHash:
+		return nil, domain.ErrIdempotencyKeyConflict
+	case err == nil:
+		return &domain.ProcessOrderResponse{
+			OrderId:    stored.OrderId,
+			PaymentUrl: stored.PaymentUrl,
+		}, nil
+	case !errors.Is(err, domain.ErrIdempotencyKeyNotFound):
+		return nil, errors.WithMessage(err, "get idempotency key")
+	}
+
+	resp, err := s.processOrder(ctx, tx, userId, req, "")
+	if err != nil {
+		return nil, errors.WithMessage(err, "process order")
+	}
+
+	now := time.Now().UTC()
+	err = tx.InsertIdempotencyKey(ctx, entity.IdempotencyKey{
+		UserId:      userId,
+		Key:         idempotencyKey,
+		RequestHash: requestHash,
+		OrderId:     resp.OrderId,
+		PaymentUrl:  resp.PaymentUrl,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.idempotencyKeyTtl),
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "insert idempotency key")
+	}
+	return resp, nil
+}
+
+// processOrder creates the order and starts its payment,
+// groupOrderId is empty for orders outside of a group order
+func (s Order) processOrder(
+	ctx context.Context,
+	tx ProcessOrderTx,
+	userId string,
+	req domain.ProcessOrderRequest,
+	groupOrderId string,
+) (*domain.ProcessOrderResponse, error) {
+	order, err := s.createOrder(ctx, tx, userId, req, groupOrderId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "create order")
+	}
+	return s.processPayment(ctx, tx, order)
+}
+
+func (s Order) createOrder(
+	ctx context.Context,
+	tx ProcessOrderTx,
+	userId string,
+	req domain.ProcessOrderRequest,
+	groupOrderId string,
+) (*entity.Order, error) {
+	items, err := orderItemRequests(req)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get order items")
 	}
 
 	allowed, err := tx.IsOrderingAllowed(ctx)
 	if err != nil {
-		return "", errors.WithMessage(err, "is ordering allowed")
+		return nil, errors.WithMessage(err, "is ordering allowed")
 	}
 	if !allowed {
-		return "", apierrors.NewBusinessError(
+		return nil, apierrors.NewBusinessError(
 			domain.ErrCodeOrderingForbidden,
 			domain.ErrOrderingForbidden.Error(),
 			domain.ErrOrderingForbidden,
 		)
 	}
 
-	dishes, err := tx.GetDishesByIds(ctx, slices.Collect(maps.Keys(items)))
+	if req.DeliverySlotId != 0 {
+		err = s.checkDeliverySlot(ctx, tx, req.DeliverySlotId)
+		if err != nil {
+			return nil, errors.WithMessage(err, "check delivery slot")
+		}
+	}
+
+	dishIds := make([]int32, 0, len(items))
+	for _, item := range items {
+		if !slices.Contains(dishIds, item.DishId) {
+			dishIds = append(dishIds, item.DishId)
+		}
+	}
+	dishes, err := tx.GetDishesByIds(ctx, dishIds)
 	if err != nil {
-		return "", errors.WithMessage(err, "get prices")
+		return nil, errors.WithMessage(err, "get prices")
 	}
-	if len(dishes) != len(items) {
-		return "", domain.ErrDishNotFound
+	if len(dishes) != len(dishIds) {
+		return nil, domain.ErrDishNotFound
 	}
 
 	dishesMap := make(map[int32]entity.Dish)
@@ -184,45 +382,461 @@ func (s Order) processOrder(ctx context.Context, tx ProcessOrderTx, userId strin
 		dishesMap[dishes[i].Id] = dishes[i]
 	}
 
+	optionGroups, err := tx.GetDishOptionGroups(ctx, dishIds)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get dish option groups")
+	}
+	optionGroupsByDish := make(map[int32][]entity.DishOptionGroup)
+	for _, group := range optionGroups {
+		optionGroupsByDish[group.DishId] = append(optionGroupsByDish[group.DishId], group)
+	}
+
 	var total int32
-	orderItems := make([]entity.OrderItem, 0, len(dishes))
-	for id, count := range items {
+	orderItems := make([]entity.OrderItem, 0, len(items))
+	for _, item := range items {
+		dish := dishesMap[item.DishId]
+		options, err := chooseDishOptions(dish, optionGroupsByDish[dish.Id], item.OptionIds)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "choose dish options, dishId=%d", dish.Id)
+		}
+		price := dish.Price
+		for _, option := range options {
+			price += option.PriceDelta
+		}
+		if price <= 0 {
+			return nil, invalidDishOptionsError(dish.Name, "цена блюда с опциями должна быть положительной")
+		}
+
 		orderItems = append(orderItems, entity.OrderItem{
-			DishId: id,
-			Count:  count,
-			Name:   dishesMap[id].Name,
-			Price:  count * dishesMap[id].Price,
+			DishId:       item.DishId,
+			RestaurantId: dish.RestaurantId,
+			Count:        item.Count,
+			Name:         dish.Name,
+			Price:        item.Count * price,
+			Options:      options,
 		})
-		total += dishesMap[id].Price * count
+		total += price * item.Count
+	}
+
+	deliveryFee, err := s.checkRestaurantTerms(ctx, tx, orderItems, dishesMap)
+	if err != nil {
+		return nil, errors.WithMessage(err, "check restaurant terms")
 	}
+
+	var promoCode entity.PromoCode
+	var discount int32
+	if req.PromoCode != "" {
+		promoCode, discount, err = s.applyPromoCode(ctx, tx, userId, req.PromoCode, orderItems)
+		if err != nil {
+			return nil, errors.WithMessage(err, "apply promo code")
+		}
+		if discount >= total {
+			return nil, domain.ErrPromoCodeNotApplicable
+		}
+	}
+
 	order := &entity.Order{
-		Id:            uuid.NewString(),
-		PaymentMethod: req.PaymentMethod,
-		Items:         orderItems,
-		UserId:        userId,
-		Total:         total,
-		Wishes:        req.Wishes,
-		Status:        entity.OrderItemStatusProcess,
-		CreatedAt:     time.Now().UTC(),
+		Id:             uuid.NewString(),
+		PaymentMethod:  req.PaymentMethod,
+		Items:          orderItems,
+		UserId:         userId,
+		Total:          total - discount + deliveryFee,
+		Wishes:         req.Wishes,
+		Status:         entity.OrderItemStatusProcess,
+		CreatedAt:      time.Now().UTC(),
+		DeliverySlotId: req.DeliverySlotId,
+		GroupOrderId:   groupOrderId,
+		PromoCodeId:    promoCode.Id,
+		PromoCode:      promoCode.Code,
+		Discount:       discount,
+		DeliveryFee:    deliveryFee,
+	}
+
+	err = s.checkOrderLimits(ctx, tx, order)
+	if err != nil {
+		return nil, errors.WithMessage(err, "check order limits")
 	}
 
 	err = tx.InsertOrder(ctx, order)
 	if err != nil {
-		return "", errors.WithMessage(err, "insert order")
+		return nil, errors.WithMessage(err, "insert order")
 	}
 
 	err = tx.InsertOrderItems(ctx, order.Id, order.Items)
 	if err != nil {
-		return "", errors.WithMessage(err, "insert order items")
+		return nil, errors.WithMessage(err, "insert order items")
+	}
+
+	restaurantIds := make([]int32, 0)
+	for _, item := range order.Items {
+		if !slices.Contains(restaurantIds, item.RestaurantId) {
+			restaurantIds = append(restaurantIds, item.RestaurantId)
+		}
+	}
+	err = tx.InsertOrderFulfilments(ctx, order.Id, restaurantIds)
+	if err != nil {
+		return nil, errors.WithMessage(err, "insert order fulfilments")
+	}
+
+	err = tx.InsertOrderStatusHistory(ctx, entity.OrderStatusHistory{
+		OrderId:   order.Id,
+		NewStatus: order.Status,
+		Actor:     entity.UserActor(userId),
+		Reason:    "заказ оформлен",
+		CreatedAt: order.CreatedAt,
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "insert order status history")
 	}
 
-	url, err := s.paymentService.Process(ctx, order, req.PaymentMethod)
+	return order, nil
+}
+
+// checkout creates the payment of the committed order at the provider of its payment method,
+// so the provider isn't called within the order transaction, the replayed request gets the same payment url
+func (s Order) checkout(ctx context.Context, orderId string, method string) (string, error) {
+	if !s.paymentService.HasCheckout(method) {
+		return "", nil
+	}
+	order, err := s.orderRepo.GetOrder(ctx, orderId)
 	if err != nil {
-		return "", errors.WithMessagef(err, "process payment, orderId=%v", order.Id)
+		return "", errors.WithMessage(err, "get order")
+	}
+	url, err := s.paymentService.Checkout(ctx, order)
+	if err != nil {
+		return "", errors.WithMessagef(err, "checkout payment, orderId=%s", orderId)
 	}
 	return url, nil
 }
 
+func (s Order) processPayment(ctx context.Context, tx ProcessOrderTx, order *entity.Order) (*domain.ProcessOrderResponse, error) {
+	invoice, err := s.paymentService.Process(ctx, order, order.PaymentMethod)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "process payment, orderId=%v", order.Id)
+	}
+	// the pending payment keeps the amount invoiced by the method, e.g. in stars
+	err = tx.InsertPayment(ctx, entity.Payment{
+		OrderId:  order.Id,
+		Method:   order.PaymentMethod,
+		Status:   entity.PaymentStatusPending,
+		Amount:   invoice.Amount,
+		Currency: invoice.Currency,
+	})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "insert payment, orderId=%v", order.Id)
+	}
+	return &domain.ProcessOrderResponse{
+		OrderId:    order.Id,
+		PaymentUrl: invoice.Url,
+	}, nil
+}
+
+func (s Order) RepeatOrder(
+	ctx context.Context,
+	userId string,
+	req domain.RepeatOrderRequest,
+) (*domain.RepeatOrderResponse, error) {
+	order, err := s.orderRepo.GetOrder(ctx, req.Id)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get order")
+	}
+	if order.UserId != userId {
+		return nil, domain.ErrForbidden
+	}
+
+	paymentMethod := order.PaymentMethod
+	if req.PaymentMethod != "" {
+		paymentMethod = req.PaymentMethod
+	}
+	if !s.paymentService.IsPaymentMethodValid(paymentMethod) {
+		return nil, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument, "invalid payment method", errors.New("invalid payment method"))
+	}
+
+	var resp *domain.RepeatOrderResponse
+	err = s.txRunner.ProcessOrderTx(ctx, func(ctx context.Context, tx ProcessOrderTx) error {
+		resp, err = s.repeatOrder(ctx, tx, userId, order, req, paymentMethod)
+		if err != nil {
+			return errors.WithMessage(err, "repeat order")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "process order tx")
+	}
+
+	url, err := s.checkout(ctx, resp.OrderId, paymentMethod)
+	if err != nil {
+		return nil, errors.WithMessage(err, "checkout")
+	}
+	if url != "" {
+		resp.PaymentUrl = url
+	}
+	return resp, nil
+}
+
+func (s Order) repeatOrder(
+	ctx context.Context,
+	tx ProcessOrderTx,
+	userId string,
+	order *entity.Order,
+	req domain.RepeatOrderRequest,
+	paymentMethod string,
+) (*domain.RepeatOrderResponse, error) {
+	ids := make([]int32, 0, len(order.Items))
+	for _, item := range order.Items {
+		ids = append(ids, item.DishId)
+	}
+	dishes, err := tx.GetDishesByIds(ctx, ids)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get dishes by ids")
+	}
+	existingDishes := make(map[int32]struct{}, len(dishes))
+	for _, dish := range dishes {
+		existingDishes[dish.Id] = struct{}{}
+	}
+
+	items := make(map[string]int32, len(order.Items))
+	itemsWithOptions := make([]domain.OrderItemRequest, 0)
+	missingDishes := make([]domain.MissingDish, 0)
+	for _, item := range order.Items {
+		if _, ok := existingDishes[item.DishId]; !ok {
+			missingDishes = append(missingDishes, domain.MissingDish{
+				DishId: item.DishId,
+				Name:   item.Name,
+			})
+			continue
+		}
+		if len(item.Options) == 0 {
+			items[strconv.Itoa(int(item.DishId))] += item.Count
+			continue
+		}
+		optionIds := make([]int32, len(item.Options))
+		for i, option := range item.Options {
+			optionIds[i] = option.OptionId
+		}
+		itemsWithOptions = append(itemsWithOptions, domain.OrderItemRequest{
+			DishId:    item.DishId,
+			Count:     item.Count,
+			OptionIds: optionIds,
+		})
+	}
+	if len(items) == 0 && len(itemsWithOptions) == 0 {
+		return nil, domain.ErrRepeatOrderEmpty
+	}
+
+	processResp, err := s.processOrder(ctx, tx, userId, domain.ProcessOrderRequest{
+		Items:            items,
+		ItemsWithOptions: itemsWithOptions,
+		PaymentMethod:    paymentMethod,
+		Wishes:           order.Wishes,
+		DeliverySlotId:   req.DeliverySlotId,
+	}, "")
+	if err != nil {
+		return nil, errors.WithMessage(err, "process order")
+	}
+	return &domain.RepeatOrderResponse{
+		OrderId:       processResp.OrderId,
+		PaymentUrl:    processResp.PaymentUrl,
+		MissingDishes: missingDishes,
+	}, nil
+}
+
+// checkDeliverySlot locks the slot until the end of the transaction,
+// so concurrent orders can't exceed its capacity
+func (s Order) checkDeliverySlot(ctx context.Context, tx ProcessOrderTx, slotId int32) error {
+	slot, err := tx.GetDeliverySlotForUpdate(ctx, slotId)
+	if err != nil {
+		return errors.WithMessage(err, "get delivery slot")
+	}
+	startsAt, err := slot.StartsAt()
+	if err != nil {
+		return errors.WithMessage(err, "get delivery slot start")
+	}
+	if !startsAt.After(time.Now()) {
+		return domain.ErrDeliverySlotExpired
+	}
+
+	booked, err := tx.CountDeliverySlotOrders(ctx, slotId)
+	if err != nil {
+		return errors.WithMessage(err, "count delivery slot orders")
+	}
+	if booked >= slot.Capacity {
+		return domain.ErrDeliverySlotFull
+	}
+	return nil
+}
+
+// orderItemRequests merges the dishes without options and the dishes with options of the request
+func orderItemRequests(req domain.ProcessOrderRequest) ([]domain.OrderItemRequest, error) {
+	items, err := convertMapStringToInt(req.Items)
+	if err != nil {
+		return nil, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument, "invalid order items", err)
+	}
+	requests := make([]domain.OrderItemRequest, 0, len(items)+len(req.ItemsWithOptions))
+	for dishId, count := range items {
+		requests = append(requests, domain.OrderItemRequest{
+			DishId: dishId,
+			Count:  count,
+		})
+	}
+	requests = append(requests, req.ItemsWithOptions...)
+	if len(requests) == 0 {
+		return nil, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument, "order items are empty", errors.New("order items are empty"))
+	}
+	for _, item := range requests {
+		if item.Count <= 0 {
+			return nil, domain.ErrInvalidDishCount
+		}
+	}
+	return requests, nil
+}
+
+// chooseDishOptions checks the chosen options against the dish option groups
+// and returns the snapshot of the chosen options
+func chooseDishOptions(
+	dish entity.Dish,
+	groups []entity.DishOptionGroup,
+	optionIds []int32,
+) ([]entity.OrderItemOption, error) {
+	chosen := make(map[int32]struct{}, len(optionIds))
+	for _, id := range optionIds {
+		if _, ok := chosen[id]; ok {
+			return nil, invalidDishOptionsError(dish.Name, "опция выбрана несколько раз")
+		}
+		chosen[id] = struct{}{}
+	}
+
+	options := make([]entity.OrderItemOption, 0, len(optionIds))
+	for _, group := range groups {
+		var count int32
+		for _, option := range group.Options {
+			if _, ok := chosen[option.Id]; !ok {
+				continue
+			}
+			delete(chosen, option.Id)
+			count++
+			options = append(options, entity.OrderItemOption{
+				OptionId:   option.Id,
+				GroupName:  group.Name,
+				Name:       option.Name,
+				PriceDelta: option.PriceDelta,
+			})
+		}
+		if count == 0 && !group.Required {
+			continue
+		}
+		if count < group.MinCount || count > group.MaxCount {
+			return nil, invalidDishOptionsError(dish.Name,
+				fmt.Sprintf("в группе '%s' нужно выбрать от %d до %d опций", group.Name, group.MinCount, group.MaxCount),
+			)
+		}
+	}
+	if len(chosen) > 0 {
+		return nil, invalidDishOptionsError(dish.Name, "выбраны опции, которых нет у блюда")
+	}
+	return options, nil
+}
+
+func invalidDishOptionsError(dishName string, reason string) error {
+	return apierrors.NewBusinessError(
+		domain.ErrCodeInvalidDishOptions,
+		fmt.Sprintf("блюдо %s: %s", dishName, reason),
+		domain.ErrInvalidDishOptions,
+	)
+}
+
+// checkRestaurantTerms checks the minimum order total of each restaurant in the order
+// and returns the sum of their delivery fees
+func (s Order) checkRestaurantTerms(
+	ctx context.Context,
+	tx ProcessOrderTx,
+	items []entity.OrderItem,
+	dishes map[int32]entity.Dish,
+) (int32, error) {
+	subtotals := make(map[int32]int32)
+	for _, item := range items {
+		// item price is the total price of the item
+		subtotals[dishes[item.DishId].RestaurantId] += item.Price
+	}
+	restaurants, err := tx.GetRestaurantsByIds(ctx, slices.Collect(maps.Keys(subtotals)))
+	if err != nil {
+		return 0, errors.WithMessage(err, "get restaurants by ids")
+	}
+
+	var deliveryFee int32
+	for _, restaurant := range restaurants {
+		subtotal := subtotals[restaurant.Id]
+		if subtotal < restaurant.MinOrderTotal {
+			return 0, apierrors.NewBusinessError(
+				domain.ErrCodeOrderBelowMinimum,
+				fmt.Sprintf("минимальная сумма заказа в ресторане %s - %s, в заказе блюд на %s",
+					restaurant.Name, formatMoney(restaurant.MinOrderTotal), formatMoney(subtotal),
+				),
+				domain.ErrOrderBelowMinimum,
+			)
+		}
+		deliveryFee += restaurant.DeliveryFeeFor(subtotal)
+	}
+	return deliveryFee, nil
+}
+
+// applyPromoCode locks the promo code until the end of the transaction,
+// so concurrent orders can't exceed its usage limits
+func (s Order) applyPromoCode(
+	ctx context.Context,
+	tx ProcessOrderTx,
+	userId string,
+	code string,
+	items []entity.OrderItem,
+) (entity.PromoCode, int32, error) {
+	promoCode, err := tx.GetPromoCodeForUpdate(ctx, normalizePromoCode(code))
+	if err != nil {
+		return entity.PromoCode{}, 0, errors.WithMessage(err, "get promo code")
+	}
+	if !promoCode.IsActive(time.Now()) {
+		return entity.PromoCode{}, 0, domain.ErrPromoCodeInactive
+	}
+
+	if promoCode.TotalLimit > 0 {
+		used, err := tx.CountPromoCodeUsages(ctx, promoCode.Id, "")
+		if err != nil {
+			return entity.PromoCode{}, 0, errors.WithMessage(err, "count promo code usages")
+		}
+		if used >= promoCode.TotalLimit {
+			return entity.PromoCode{}, 0, domain.ErrPromoCodeLimitExceeded
+		}
+	}
+	if promoCode.PerUserLimit > 0 {
+		used, err := tx.CountPromoCodeUsages(ctx, promoCode.Id, userId)
+		if err != nil {
+			return entity.PromoCode{}, 0, errors.WithMessage(err, "count user promo code usages")
+		}
+		if used >= promoCode.PerUserLimit {
+			return entity.PromoCode{}, 0, domain.ErrPromoCodeLimitExceeded
+		}
+	}
+
+	dishIds := make([]int32, len(items))
+	for i, item := range items {
+		dishIds[i] = item.DishId
+	}
+	eligibleIds, err := tx.GetPromoCodeEligibleDishes(ctx, promoCode.Id, dishIds)
+	if err != nil {
+		return entity.PromoCode{}, 0, errors.WithMessage(err, "get promo code eligible dishes")
+	}
+	var eligibleTotal int32
+	for _, item := range items {
+		if slices.Contains(eligibleIds, item.DishId) {
+			// item price is the total price of the item
+			eligibleTotal += item.Price
+		}
+	}
+	if eligibleTotal == 0 {
+		return entity.PromoCode{}, 0, domain.ErrPromoCodeNotApplicable
+	}
+	return promoCode, promoCode.Discount(eligibleTotal), nil
+}
+
 func (s Order) GetOrderStatus(ctx context.Context, orderId string) (string, error) {
 	orderStatus, err := s.orderRepo.GetOrderStatus(ctx, orderId)
 	if err != nil {
@@ -241,28 +855,394 @@ func (s Order) GetUserOrders(ctx context.Context, userId string, req domain.GetM
 		return nil, errors.WithMessage(err, "get user orders")
 	}
 	var userOrders = make([]domain.UserOrder, len(orders))
-	for i, order := range orders {
-		items := make([]domain.OrderItem, len(order.Items))
-		for j, item := range order.Items {
-			items[j] = domain.OrderItem{
-				DishId:     item.DishId,
-				Name:       item.Name,
-				Price:      item.Price,
-				Count:      item.Count,
-				TotalPrice: item.Count * item.Price,
-			}
+	for i := range orders {
+		userOrders[i] = userOrderFromEntity(&orders[i])
+	}
+	return userOrders, nil
+}
+
+func (s Order) GetUserOrder(ctx context.Context, authInfo entity.UserAuthInfo, orderId string) (*domain.UserOrder, error) {
+	order, err := s.orderRepo.GetOrder(ctx, orderId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get order")
+	}
+	if authInfo.RoleName != domain.AdminRoleName && order.UserId != authInfo.UserId {
+		return nil, domain.ErrForbidden
+	}
+	fulfilments, err := s.orderRepo.GetOrderFulfilments(ctx, orderId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get order fulfilments")
+	}
+
+	userOrder := userOrderFromEntity(order)
+	userOrder.Fulfilments = make([]domain.OrderFulfilment, len(fulfilments))
+	for i, fulfilment := range fulfilments {
+		userOrder.Fulfilments[i] = domain.OrderFulfilment{
+			RestaurantId:   fulfilment.RestaurantId,
+			RestaurantName: fulfilment.RestaurantName,
+			Status:         fulfilment.Status,
+			UpdatedAt:      fulfilment.UpdatedAt,
 		}
-		userOrders[i] = domain.UserOrder{
+	}
+	return &userOrder, nil
+}
+
+func (s Order) CancelOrder(ctx context.Context, userId string, orderId string) error {
+	order, err := s.orderRepo.GetOrder(ctx, orderId)
+	if err != nil {
+		return errors.WithMessage(err, "get order")
+	}
+	if order.UserId != userId {
+		return domain.ErrForbidden
+	}
+	if order.Status != entity.OrderItemStatusProcess {
+		return domain.ErrOrderCancelForbidden
+	}
+
+	err = s.orderPayments.Cancel(ctx, entity.OrderStatusChange{
+		OrderId: orderId,
+		From:    entity.OrderItemStatusProcess,
+		To:      entity.OrderItemStatusCanceled,
+		Actor:   entity.UserActor(userId),
+		Reason:  "отменён пользователем",
+	}, entity.OrderPayment{})
+	switch {
+	case errors.Is(err, domain.ErrOrderStatusConflict):
+		return domain.ErrOrderCancelForbidden
+	case err != nil:
+		return errors.WithMessage(err, "change order status")
+	}
+
+	err = s.paymentService.RemoveExpiration(ctx, orderId)
+	if err != nil {
+		return errors.WithMessagef(err, "remove expiration, orderId=%v", orderId)
+	}
+	return nil
+}
+
+func (s Order) ListOrders(ctx context.Context, req domain.GetOrdersRequest) (*domain.GetOrdersResponse, error) {
+	filter := entity.OrdersFilter{
+		Status:        req.Status,
+		UserId:        req.UserId,
+		Username:      req.Username,
+		PaymentMethod: req.PaymentMethod,
+		RestaurantId:  req.RestaurantId,
+		GroupOrderId:  req.GroupOrderId,
+		SortBy:        req.SortBy,
+		SortOrder:     req.SortOrder,
+		Limit:         req.Limit,
+		Offset:        req.Offset,
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultOrdersLimit
+	}
+	var err error
+	filter.CreatedFrom, err = parseOptionalTime(req.CreatedFrom)
+	if err != nil {
+		return nil, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument, "invalid createdFrom, must be RFC3339", err)
+	}
+	filter.CreatedTo, err = parseOptionalTime(req.CreatedTo)
+	if err != nil {
+		return nil, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument, "invalid createdTo, must be RFC3339", err)
+	}
+
+	total, err := s.orderRepo.CountOrders(ctx, filter)
+	if err != nil {
+		return nil, errors.WithMessage(err, "count orders")
+	}
+	orders, err := s.orderRepo.GetOrders(ctx, filter)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get orders")
+	}
+
+	adminOrders := make([]domain.AdminOrder, len(orders))
+	for i, order := range orders {
+		adminOrders[i] = domain.AdminOrder{
 			Id:            order.Id,
-			Items:         items,
+			Items:         orderItemsFromEntity(order.Items),
 			PaymentMethod: order.PaymentMethod,
 			Total:         order.Total,
+			Status:        order.Status,
 			Wishes:        order.Wishes,
 			CreatedAt:     order.CreatedAt,
-			Status:        order.Status,
+			UserId:        order.UserId,
+			Username:      order.Username,
+			DeliverySlot:  order.DeliverySlot,
+			GroupOrderId:  order.GroupOrderId,
+			PromoCode:     order.PromoCode,
+			Discount:      order.Discount,
+			DeliveryFee:   order.DeliveryFee,
+			Refunded:      order.Refunded,
 		}
 	}
-	return userOrders, nil
+	return &domain.GetOrdersResponse{
+		Orders: adminOrders,
+		Total:  total,
+	}, nil
+}
+
+func (s Order) ChangeOrderStatus(ctx context.Context, adminId string, req domain.SetOrderStatusRequest) error {
+	order, err := s.orderRepo.GetOrder(ctx, req.Id)
+	if err != nil {
+		return errors.WithMessage(err, "get order")
+	}
+	if order.Status == req.Status {
+		return nil
+	}
+
+	change := entity.OrderStatusChange{
+		OrderId: order.Id,
+		From:    order.Status,
+		To:      req.Status,
+		Actor:   entity.UserActor(adminId),
+		Reason:  "изменён администратором",
+	}
+	switch req.Status {
+	case entity.OrderItemStatusPaid:
+		err = s.orderPayments.MarkPaid(ctx, change)
+	case entity.OrderItemStatusCanceled:
+		err = s.orderPayments.Cancel(ctx, change, entity.OrderPayment{})
+	default:
+		err = s.SetOrderStatus(ctx, change)
+	}
+	if err != nil {
+		return errors.WithMessage(err, "change order status")
+	}
+	if req.Status == entity.OrderItemStatusSuccess {
+		err = s.notifier.RequestOrderRating(ctx, order.Id)
+		if err != nil {
+			return errors.WithMessagef(err, "request order rating, orderId=%s", order.Id)
+		}
+		return nil
+	}
+	if order.Status != entity.OrderItemStatusProcess {
+		return nil
+	}
+
+	err = s.paymentService.RemoveExpiration(ctx, order.Id)
+	if err != nil {
+		return errors.WithMessagef(err, "remove expiration, orderId=%v", order.Id)
+	}
+	return nil
+}
+
+// CancelOrderItems cancels the items of the paid order and records their refund,
+// the rest of the order stays paid
+func (s Order) CancelOrderItems(
+	ctx context.Context,
+	adminId string,
+	req domain.CancelOrderItemsRequest,
+) (*domain.CancelOrderItemsResponse, error) {
+	var canceled []entity.OrderItem
+	var resp *domain.CancelOrderItemsResponse
+	err := s.txRunner.CancelOrderItemsTx(ctx, func(ctx context.Context, tx CancelOrderItemsTx) error {
+		var err error
+		canceled, resp, err = s.cancelOrderItems(ctx, tx, adminId, req)
+		if err != nil {
+			return errors.WithMessage(err, "cancel order items")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "cancel order items tx")
+	}
+
+	err = s.notifier.NotifyOrderItemsCanceled(ctx, req.Id, canceled, resp.Refund, req.Reason)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "notify order items canceled, orderId=%s", req.Id)
+	}
+	return resp, nil
+}
+
+func (s Order) cancelOrderItems(
+	ctx context.Context,
+	tx CancelOrderItemsTx,
+	adminId string,
+	req domain.CancelOrderItemsRequest,
+) ([]entity.OrderItem, *domain.CancelOrderItemsResponse, error) {
+	status, err := tx.GetOrderStatusForUpdate(ctx, req.Id)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "get order status")
+	}
+	if status != entity.OrderItemStatusPaid {
+		return nil, nil, domain.ErrOrderItemsCancelForbidden
+	}
+	order, err := tx.GetOrder(ctx, req.Id)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "get order")
+	}
+
+	var activeTotal, canceledBefore int32
+	activeItems := 0
+	itemsById := make(map[int64]entity.OrderItem, len(order.Items))
+	for _, item := range order.Items {
+		// item price is the total price of the item
+		if item.IsCanceled() {
+			canceledBefore += item.Price
+		} else {
+			activeTotal += item.Price
+			activeItems++
+		}
+		itemsById[item.Id] = item
+	}
+
+	itemIds := slices.Clone(req.ItemIds)
+	slices.Sort(itemIds)
+	itemIds = slices.Compact(itemIds)
+	var canceledTotal int32
+	canceled := make([]entity.OrderItem, 0, len(itemIds))
+	for _, id := range itemIds {
+		item, ok := itemsById[id]
+		if !ok {
+			return nil, nil, domain.ErrOrderItemNotFound
+		}
+		if item.IsCanceled() {
+			return nil, nil, domain.ErrOrderItemAlreadyCanceled
+		}
+		canceledTotal += item.Price
+		canceled = append(canceled, item)
+	}
+	if len(canceled) == activeItems {
+		return nil, nil, domain.ErrOrderItemsCancelAll
+	}
+
+	// the part of the price of the items canceled before which wasn't refunded is their discount share,
+	// the delivery fee isn't refunded since the rest of the order is delivered
+	remainingDiscount := order.Discount - (canceledBefore - order.Refunded)
+	refund := canceledTotal - itemsDiscountShare(remainingDiscount, canceledTotal, activeTotal)
+
+	err = tx.CancelOrderItems(ctx, order.Id, itemIds)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "cancel order items")
+	}
+	err = tx.AddOrderRefund(ctx, order.Id, refund)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "add order refund")
+	}
+	err = s.orderPayments.RefundItemsTx(ctx, tx, order, refund, entity.UserActor(adminId), "отмена блюд заказа")
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "refund order items")
+	}
+
+	names := make([]string, len(canceled))
+	for i, item := range canceled {
+		names[i] = fmt.Sprintf("%s x%d", item.FullName(), item.Count)
+	}
+	reason := fmt.Sprintf("отменены блюда: %s, возврат %s", strings.Join(names, ", "), formatMoney(refund))
+	if req.Reason != "" {
+		reason += ", причина: " + req.Reason
+	}
+	err = tx.InsertOrderStatusHistory(ctx, entity.OrderStatusHistory{
+		OrderId:   order.Id,
+		OldStatus: status,
+		NewStatus: status,
+		Actor:     entity.UserActor(adminId),
+		Reason:    reason,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "insert order status history")
+	}
+
+	return canceled, &domain.CancelOrderItemsResponse{
+		Total:  order.Total - refund,
+		Refund: refund,
+	}, nil
+}
+
+// itemsDiscountShare spreads the discount left on the active items proportionally to their price,
+// the last active items get the rest of it, so the shares sum up to the whole discount
+func itemsDiscountShare(remainingDiscount int32, canceledTotal int32, activeTotal int32) int32 {
+	if remainingDiscount <= 0 || activeTotal <= 0 {
+		return 0
+	}
+	if canceledTotal >= activeTotal {
+		return remainingDiscount
+	}
+	return int32(int64(remainingDiscount) * int64(canceledTotal) / int64(activeTotal))
+}
+
+func (s Order) GetOrderStatusHistory(ctx context.Context, orderId string) ([]domain.OrderStatusHistory, error) {
+	history, err := s.orderRepo.GetOrderStatusHistory(ctx, orderId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get order status history")
+	}
+	result := make([]domain.OrderStatusHistory, len(history))
+	for i, h := range history {
+		result[i] = domain.OrderStatusHistory{
+			OldStatus: h.OldStatus,
+			NewStatus: h.NewStatus,
+			Actor:     h.Actor,
+			Reason:    h.Reason,
+			CreatedAt: h.CreatedAt,
+		}
+	}
+	return result, nil
+}
+
+func userOrderFromEntity(order *entity.Order) domain.UserOrder {
+	return domain.UserOrder{
+		Id:            order.Id,
+		Items:         orderItemsFromEntity(order.Items),
+		PaymentMethod: order.PaymentMethod,
+		Total:         order.Total,
+		Wishes:        order.Wishes,
+		CreatedAt:     order.CreatedAt,
+		Status:        order.Status,
+		DeliverySlot:  order.DeliverySlot,
+		GroupOrderId:  order.GroupOrderId,
+		PromoCode:     order.PromoCode,
+		Discount:      order.Discount,
+		DeliveryFee:   order.DeliveryFee,
+		Refunded:      order.Refunded,
+		RefundStatus:  order.RefundStatus,
+	}
+}
+
+func orderItemsFromEntity(orderItems entity.OrderItems) []domain.OrderItem {
+	items := make([]domain.OrderItem, len(orderItems))
+	for i, item := range orderItems {
+		options := make([]domain.OrderItemOption, len(item.Options))
+		for j, option := range item.Options {
+			options[j] = domain.OrderItemOption{
+				Group:      option.GroupName,
+				Name:       option.Name,
+				PriceDelta: option.PriceDelta,
+			}
+		}
+		items[i] = domain.OrderItem{
+			Id:         item.Id,
+			DishId:     item.DishId,
+			Name:       item.Name,
+			Price:      item.Price,
+			Count:      item.Count,
+			TotalPrice: item.Count * item.Price,
+			Status:     item.Status,
+			Options:    options,
+		}
+	}
+	return items
+}
+
+func hashOrderRequest(req domain.ProcessOrderRequest) (string, error) {
+	// map keys are marshaled sorted, so equal requests produce equal hashes
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", errors.WithMessage(err, "marshal request")
+	}
+	hash := sha256.Sum256(body)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+func parseOptionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil // nolint:nilnil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errors.WithMessage(err, "parse time")
+	}
+	return &t, nil
 }
 
 func convertMapStringToInt(m map[string]int32) (map[int32]int32, error) {
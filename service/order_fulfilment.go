@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/pkg/errors"
+)
+
+type SetFulfilmentStatusTx interface {
+	CancelOrderItemsTx
+	GetOrderFulfilmentsForUpdate(ctx context.Context, orderId string) ([]entity.OrderFulfilment, error)
+	UpdateFulfilmentStatus(ctx context.Context, orderId string, restaurantId int32, status string) error
+}
+
+// nolint:gochecknoglobals
+var fulfilmentStatusTransitions = map[string][]string{
+	entity.FulfilmentStatusAccepted: {
+		entity.FulfilmentStatusReady,
+		entity.FulfilmentStatusDelivered,
+		entity.FulfilmentStatusCanceled,
+	},
+	entity.FulfilmentStatusReady: {
+		entity.FulfilmentStatusDelivered,
+		entity.FulfilmentStatusCanceled,
+	},
+	entity.FulfilmentStatusDelivered: {},
+	entity.FulfilmentStatusCanceled:  {},
+}
+
+// fulfilmentStatusResult holds what has to be notified after the fulfilment status change
+type fulfilmentStatusResult struct {
+	canceled []entity.OrderItem
+	refund   int32
+	// empty if the order status isn't changed
+	orderStatus string
+}
+
+// SetFulfilmentStatus changes the status of the restaurant part of the paid order,
+// the order status is changed once all of its parts are finished
+func (s Order) SetFulfilmentStatus(ctx context.Context, adminId string, req domain.SetFulfilmentStatusRequest) error {
+	var result fulfilmentStatusResult
+	err := s.txRunner.SetFulfilmentStatusTx(ctx, func(ctx context.Context, tx SetFulfilmentStatusTx) error {
+		var err error
+		result, err = s.setFulfilmentStatus(ctx, tx, adminId, req)
+		if err != nil {
+			return errors.WithMessage(err, "set fulfilment status")
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.WithMessage(err, "set fulfilment status tx")
+	}
+
+	if len(result.canceled) > 0 {
+		err = s.notifier.NotifyOrderItemsCanceled(ctx, req.Id, result.canceled, result.refund, "ресторан не сможет выполнить свою часть заказа")
+		if err != nil {
+			return errors.WithMessagef(err, "notify order items canceled, orderId=%s", req.Id)
+		}
+	}
+	if result.orderStatus == entity.OrderItemStatusSuccess {
+		err = s.notifier.RequestOrderRating(ctx, req.Id)
+		if err != nil {
+			return errors.WithMessagef(err, "request order rating, orderId=%s", req.Id)
+		}
+	}
+	return nil
+}
+
+func (s Order) setFulfilmentStatus(
+	ctx context.Context,
+	tx SetFulfilmentStatusTx,
+	adminId string,
+	req domain.SetFulfilmentStatusRequest,
+) (fulfilmentStatusResult, error) {
+	status, err := tx.GetOrderStatusForUpdate(ctx, req.Id)
+	if err != nil {
+		return fulfilmentStatusResult{}, errors.WithMessage(err, "get order status")
+	}
+	if status != entity.OrderItemStatusPaid {
+		return fulfilmentStatusResult{}, errors.WithMessagef(domain.ErrFulfilmentStatusForbidden, "order status %s", status)
+	}
+
+	fulfilments, err := tx.GetOrderFulfilmentsForUpdate(ctx, req.Id)
+	if err != nil {
+		return fulfilmentStatusResult{}, errors.WithMessage(err, "get order fulfilments")
+	}
+	idx := slices.IndexFunc(fulfilments, func(f entity.OrderFulfilment) bool {
+		return f.RestaurantId == req.RestaurantId
+	})
+	if idx < 0 {
+		return fulfilmentStatusResult{}, domain.ErrFulfilmentNotFound
+	}
+	fulfilment := &fulfilments[idx]
+	if fulfilment.Status == req.Status {
+		return fulfilmentStatusResult{}, nil
+	}
+	if !slices.Contains(fulfilmentStatusTransitions[fulfilment.Status], req.Status) {
+		return fulfilmentStatusResult{}, errors.WithMessagef(domain.ErrFulfilmentStatusForbidden, "%s -> %s", fulfilment.Status, req.Status)
+	}
+
+	err = tx.UpdateFulfilmentStatus(ctx, req.Id, req.RestaurantId, req.Status)
+	if err != nil {
+		return fulfilmentStatusResult{}, errors.WithMessage(err, "update fulfilment status")
+	}
+	fulfilment.Status = req.Status
+
+	result := fulfilmentStatusResult{
+		orderStatus: orderStatusByFulfilments(fulfilments),
+	}
+	// the canceled part is refunded unless the whole order is canceled
+	if req.Status == entity.FulfilmentStatusCanceled && result.orderStatus != entity.OrderItemStatusCanceled {
+		result.canceled, result.refund, err = s.cancelRestaurantItems(ctx, tx, adminId, fulfilment)
+		if err != nil {
+			return fulfilmentStatusResult{}, errors.WithMessage(err, "cancel restaurant items")
+		}
+	}
+	if result.orderStatus == "" {
+		return result, nil
+	}
+
+	change := entity.OrderStatusChange{
+		OrderId: req.Id,
+		From:    entity.OrderItemStatusPaid,
+		To:      result.orderStatus,
+		Actor:   entity.UserActor(adminId),
+		Reason:  "все части заказа доставлены",
+	}
+	if result.orderStatus == entity.OrderItemStatusCanceled {
+		// the paid total left after the refunds of the canceled parts is refunded
+		change.Reason = "все части заказа отменены"
+		err = s.orderPayments.CancelTx(ctx, tx, change, entity.OrderPayment{})
+	} else {
+		err = s.statusService.ChangeStatusTx(ctx, tx, change)
+	}
+	if err != nil {
+		return fulfilmentStatusResult{}, errors.WithMessage(err, "change order status")
+	}
+	return result, nil
+}
+
+func (s Order) cancelRestaurantItems(
+	ctx context.Context,
+	tx SetFulfilmentStatusTx,
+	adminId string,
+	fulfilment *entity.OrderFulfilment,
+) ([]entity.OrderItem, int32, error) {
+	order, err := tx.GetOrder(ctx, fulfilment.OrderId)
+	if err != nil {
+		return nil, 0, errors.WithMessage(err, "get order")
+	}
+	itemIds := make([]int64, 0)
+	for _, item := range order.Items {
+		if item.RestaurantId == fulfilment.RestaurantId && !item.IsCanceled() {
+			itemIds = append(itemIds, item.Id)
+		}
+	}
+	if len(itemIds) == 0 {
+		return nil, 0, nil
+	}
+
+	canceled, resp, err := s.cancelOrderItems(ctx, tx, adminId, domain.CancelOrderItemsRequest{
+		Id:      fulfilment.OrderId,
+		ItemIds: itemIds,
+		Reason:  fmt.Sprintf("ресторан %s отменил свою часть заказа", fulfilment.RestaurantName),
+	})
+	if err != nil {
+		return nil, 0, errors.WithMessage(err, "cancel order items")
+	}
+	return canceled, resp.Refund, nil
+}
+
+// orderStatusByFulfilments derives the status of the paid order from its parts,
+// empty while some of the parts are in progress
+func orderStatusByFulfilments(fulfilments []entity.OrderFulfilment) string {
+	delivered := false
+	for _, fulfilment := range fulfilments {
+		if !fulfilment.IsFinished() {
+			return ""
+		}
+		if fulfilment.Status == entity.FulfilmentStatusDelivered {
+			delivered = true
+		}
+	}
+	if delivered {
+		return entity.OrderItemStatusSuccess
+	}
+	return entity.OrderItemStatusCanceled
+}
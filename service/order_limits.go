@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/Falokut/go-kit/http/apierrors"
+	"github.com/pkg/errors"
+)
+
+type OrderLimitsRepo interface {
+	GetOrderLimits(ctx context.Context) (entity.OrderLimits, error)
+	SetOrderLimits(ctx context.Context, limits entity.OrderLimits) error
+	GetUserOrderLimits(ctx context.Context, userId string) (entity.UserOrderLimits, error)
+	UpsertUserOrderLimits(ctx context.Context, userId string, limits entity.OrderLimits) error
+	DeleteUserOrderLimits(ctx context.Context, userId string) error
+}
+
+type OrderLimits struct {
+	repo OrderLimitsRepo
+}
+
+func NewOrderLimits(repo OrderLimitsRepo) OrderLimits {
+	return OrderLimits{
+		repo: repo,
+	}
+}
+
+func (s OrderLimits) GetOrderLimits(ctx context.Context) (*domain.OrderLimits, error) {
+	limits, err := s.repo.GetOrderLimits(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get order limits")
+	}
+	return &domain.OrderLimits{
+		OrdersPerDay: limits.OrdersPerDay,
+		DailyTotal:   limits.DailyTotal,
+		MonthlyTotal: limits.MonthlyTotal,
+		MaxDishCount: limits.MaxDishCount,
+	}, nil
+}
+
+func (s OrderLimits) SetOrderLimits(ctx context.Context, req domain.OrderLimits) error {
+	err := s.repo.SetOrderLimits(ctx, entity.OrderLimits{
+		OrdersPerDay: req.OrdersPerDay,
+		DailyTotal:   req.DailyTotal,
+		MonthlyTotal: req.MonthlyTotal,
+		MaxDishCount: req.MaxDishCount,
+	})
+	if err != nil {
+		return errors.WithMessage(err, "set order limits")
+	}
+	return nil
+}
+
+func (s OrderLimits) GetUserOrderLimits(ctx context.Context, userId string) (*domain.UserOrderLimits, error) {
+	limits, err := s.repo.GetUserOrderLimits(ctx, userId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get user order limits")
+	}
+	return &domain.UserOrderLimits{
+		UserId:       limits.UserId,
+		OrdersPerDay: limits.OrdersPerDay,
+		DailyTotal:   limits.DailyTotal,
+		MonthlyTotal: limits.MonthlyTotal,
+		MaxDishCount: limits.MaxDishCount,
+		Overridden:   limits.Overridden,
+	}, nil
+}
+
+func (s OrderLimits) SetUserOrderLimits(ctx context.Context, req domain.SetUserOrderLimitsRequest) error {
+	_, err := s.repo.GetUserOrderLimits(ctx, req.Id)
+	if err != nil {
+		return errors.WithMessage(err, "get user order limits")
+	}
+	err = s.repo.UpsertUserOrderLimits(ctx, req.Id, entity.OrderLimits{
+		OrdersPerDay: req.OrdersPerDay,
+		DailyTotal:   req.DailyTotal,
+		MonthlyTotal: req.MonthlyTotal,
+		MaxDishCount: req.MaxDishCount,
+	})
+	if err != nil {
+		return errors.WithMessage(err, "upsert user order limits")
+	}
+	return nil
+}
+
+func (s OrderLimits) DeleteUserOrderLimits(ctx context.Context, userId string) error {
+	err := s.repo.DeleteUserOrderLimits(ctx, userId)
+	if err != nil {
+		return errors.WithMessage(err, "delete user order limits")
+	}
+	return nil
+}
+
+// checkOrderLimits checks the order against the user limits,
+// the user is locked until the end of the transaction
+func (s Order) checkOrderLimits(ctx context.Context, tx ProcessOrderTx, order *entity.Order) error {
+	userLimits, err := tx.GetUserOrderLimitsForUpdate(ctx, order.UserId)
+	if err != nil {
+		return errors.WithMessage(err, "get user order limits")
+	}
+	limits := userLimits.Limits()
+
+	if limits.MaxDishCount > 0 {
+		dishCounts := make(map[int32]int32)
+		for _, item := range order.Items {
+			dishCounts[item.DishId] += item.Count
+			if dishCounts[item.DishId] > limits.MaxDishCount {
+				return orderLimitExceededError(fmt.Sprintf("блюдо %s можно заказать не более %d шт.", item.Name, limits.MaxDishCount))
+			}
+		}
+	}
+
+	now := time.Now()
+	if limits.OrdersPerDay > 0 || limits.DailyTotal > 0 {
+		dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		stats, err := tx.GetUserOrdersStats(ctx, order.UserId, dayStart)
+		if err != nil {
+			return errors.WithMessage(err, "get user daily orders stats")
+		}
+		if limits.OrdersPerDay > 0 && stats.Count >= limits.OrdersPerDay {
+			return orderLimitExceededError(fmt.Sprintf("можно оформить не более %d заказов в день", limits.OrdersPerDay))
+		}
+		if limits.DailyTotal > 0 && stats.Total+int64(order.Total) > int64(limits.DailyTotal) {
+			return orderLimitExceededError(fmt.Sprintf("сумма заказов за день не может превышать %s", formatMoney(limits.DailyTotal)))
+		}
+	}
+	if limits.MonthlyTotal > 0 {
+		monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		stats, err := tx.GetUserOrdersStats(ctx, order.UserId, monthStart)
+		if err != nil {
+			return errors.WithMessage(err, "get user monthly orders stats")
+		}
+		if stats.Total+int64(order.Total) > int64(limits.MonthlyTotal) {
+			return orderLimitExceededError(fmt.Sprintf("сумма заказов за месяц не может превышать %s", formatMoney(limits.MonthlyTotal)))
+		}
+	}
+	return nil
+}
+
+func orderLimitExceededError(reason string) error {
+	return apierrors.NewBusinessError(
+		domain.ErrCodeOrderLimitExceeded,
+		fmt.Sprintf("%s: %s", domain.ErrOrderLimitExceeded.Error(), reason),
+		domain.ErrOrderLimitExceeded,
+	)
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+
+	"dishes-service-backend/entity"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/txix-open/bgjob"
+)
+
+type JobTx interface {
+	EnqueueJob(ctx context.Context, req bgjob.EnqueueRequest) error
+}
+
+type OrderPaymentTx interface {
+	OrderStatusTx
+	WalletTx
+	JobTx
+	GetOrder(ctx context.Context, orderId string) (*entity.Order, error)
+	SetOrderPaymentCharge(ctx context.Context, orderId string, chargeId string, providerChargeId string) error
+	SetOrderRefundStatus(ctx context.Context, orderId string, status string) error
+	CompleteOrderPayment(ctx context.Context, orderId string, result entity.PaymentResult) error
+	FailOrderPayments(ctx context.Context, orderId string, payload string) error
+	FinishOrderFulfilments(ctx context.Context, orderId string, status string) error
+}
+
+type OrderPaymentTxRunner interface {
+	OrderPaymentTx(ctx context.Context, tx func(ctx context.Context, tx OrderPaymentTx) error) error
+}
+
+type RefundScheduler interface {
+	// ScheduleRefund enqueues the refund through the payment method of the order within the transaction,
+	// returns false if the payment method doesn't refund by itself and the order is refunded to the wallet
+	ScheduleRefund(ctx context.Context, tx JobTx, order *entity.Order, refund entity.OrderRefund) (bool, error)
+}
+
+type InvoiceScheduler interface {
+	// ScheduleInvoiceDeletion enqueues the deletion of the sent invoice of the order within the transaction
+	ScheduleInvoiceDeletion(ctx context.Context, tx JobTx, orderId string) error
+}
+
+// OrderPayment changes the order status on the payment events
+// and applies their side effects: the payment charge, the payments ledger and the refund
+type OrderPayment struct {
+	txRunner      OrderPaymentTxRunner
+	statusService OrderStatusService
+	refunds       RefundScheduler
+	invoices      InvoiceScheduler
+}
+
+func NewOrderPayment(
+	txRunner OrderPaymentTxRunner,
+	statusService OrderStatusService,
+	refunds RefundScheduler,
+	invoices InvoiceScheduler,
+) OrderPayment {
+	return OrderPayment{
+		txRunner:      txRunner,
+		statusService: statusService,
+		refunds:       refunds,
+		invoices:      invoices,
+	}
+}
+
+// Pay moves the order to PAID and settles its payment in the payments ledger
+func (s OrderPayment) Pay(ctx context.Context, req entity.OrderStatusChange, payment entity.OrderPayment) error {
+	err := s.txRunner.OrderPaymentTx(ctx, func(ctx context.Context, tx OrderPaymentTx) error {
+		return s.PayTx(ctx, tx, req, payment)
+	})
+	if err != nil {
+		return errors.WithMessagef(err, "order payment tx, orderId=%s", req.OrderId)
+	}
+	return nil
+}
+
+// PayTx is Pay within the transaction of the caller
+func (s OrderPayment) PayTx(ctx context.Context, tx OrderPaymentTx, req entity.OrderStatusChange, payment entity.OrderPayment) error {
+	err := s.statusService.ChangeStatusTx(ctx, tx, req)
+	if err != nil {
+		return errors.WithMessage(err, "change order status")
+	}
+
+	if payment.ChargeId != "" || payment.ProviderChargeId != "" {
+		err = tx.SetOrderPaymentCharge(ctx, req.OrderId, payment.ChargeId, payment.ProviderChargeId)
+		if err != nil {
+			return errors.WithMessage(err, "set order payment charge")
+		}
+	}
+	err = tx.CompleteOrderPayment(ctx, req.OrderId, payment.Result)
+	if err != nil {
+		return errors.WithMessage(err, "complete order payment")
+	}
+	return nil
+}
+
+// MarkPaid moves the order to PAID without the payment reported by the payment method, e.g. by the admin,
+// the pending attempts are failed, so the reconciliation reports the order as paid without payment,
+// and the open invoice of the order is canceled
+func (s OrderPayment) MarkPaid(ctx context.Context, req entity.OrderStatusChange) error {
+	err := s.txRunner.OrderPaymentTx(ctx, func(ctx context.Context, tx OrderPaymentTx) error {
+		err := s.statusService.ChangeStatusTx(ctx, tx, req)
+		if err != nil {
+			return errors.WithMessage(err, "change order status")
+		}
+		err = tx.FailOrderPayments(ctx, req.OrderId, "")
+		if err != nil {
+			return errors.WithMessage(err, "fail order payments")
+		}
+		err = s.invoices.ScheduleInvoiceDeletion(ctx, tx, req.OrderId)
+		if err != nil {
+			return errors.WithMessage(err, "schedule invoice deletion")
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.WithMessagef(err, "order payment tx, orderId=%s", req.OrderId)
+	}
+	return nil
+}
+
+// Cancel moves the order to CANCELED together with its unfinished parts, the payment attempts of the order
+// waiting for payment are failed in the payments ledger and its invoice is deleted, the paid order is refunded
+func (s OrderPayment) Cancel(ctx context.Context, req entity.OrderStatusChange, payment entity.OrderPayment) error {
+	err := s.txRunner.OrderPaymentTx(ctx, func(ctx context.Context, tx OrderPaymentTx) error {
+		return s.CancelTx(ctx, tx, req, payment)
+	})
+	if err != nil {
+		return errors.WithMessagef(err, "order payment tx, orderId=%s", req.OrderId)
+	}
+	return nil
+}
+
+// CancelTx is Cancel within the transaction of the caller
+func (s OrderPayment) CancelTx(ctx context.Context, tx OrderPaymentTx, req entity.OrderStatusChange, payment entity.OrderPayment) error {
+	current, err := tx.GetOrderStatusForUpdate(ctx, req.OrderId)
+	if err != nil {
+		return errors.WithMessage(err, "get order status")
+	}
+	err = s.statusService.ChangeStatusTx(ctx, tx, req)
+	if err != nil {
+		return errors.WithMessage(err, "change order status")
+	}
+	err = tx.FinishOrderFulfilments(ctx, req.OrderId, entity.FulfilmentStatusCanceled)
+	if err != nil {
+		return errors.WithMessage(err, "cancel order fulfilments")
+	}
+
+	switch current {
+	case entity.OrderItemStatusProcess:
+		err = tx.FailOrderPayments(ctx, req.OrderId, payment.Result.Payload)
+		if err != nil {
+			return errors.WithMessage(err, "fail order payments")
+		}
+		// the invoice left in the chat could still be paid after the cancellation
+		err = s.invoices.ScheduleInvoiceDeletion(ctx, tx, req.OrderId)
+		if err != nil {
+			return errors.WithMessage(err, "schedule invoice deletion")
+		}
+	case entity.OrderItemStatusPaid:
+		err = s.refundCanceledOrder(ctx, tx, req)
+		if err != nil {
+			return errors.WithMessage(err, "refund canceled order")
+		}
+	}
+	return nil
+}
+
+// RefundItemsTx returns the refund of the canceled items of the paid order through its payment method,
+// the methods without partial refunds return it to the user wallet right away
+func (s OrderPayment) RefundItemsTx(
+	ctx context.Context,
+	tx OrderPaymentTx,
+	order *entity.Order,
+	amount int32,
+	actor string,
+	comment string,
+) error {
+	if amount <= 0 || order.PaymentMethod == corporatePaymentMethod {
+		return nil
+	}
+
+	scheduled, err := s.refunds.ScheduleRefund(ctx, tx, order, entity.OrderRefund{
+		Id:      uuid.NewString(),
+		Amount:  amount,
+		Partial: true,
+	})
+	if err != nil {
+		return errors.WithMessage(err, "schedule refund")
+	}
+	if scheduled {
+		return nil
+	}
+	err = refundOrderToWallet(ctx, tx, order, amount, actor, comment)
+	if err != nil {
+		return errors.WithMessage(err, "refund order to wallet")
+	}
+	return nil
+}
+
+// refundCanceledOrder refunds the canceled paid order through its payment method,
+// the orders paid by the methods without refunds are refunded to the user wallet right away
+func (s OrderPayment) refundCanceledOrder(ctx context.Context, tx OrderPaymentTx, req entity.OrderStatusChange) error {
+	order, err := tx.GetOrder(ctx, req.OrderId)
+	if err != nil {
+		return errors.WithMessage(err, "get order")
+	}
+	// Total doesn't include the refunds for the canceled items
+	if order.Total <= 0 || order.PaymentMethod == corporatePaymentMethod {
+		return nil
+	}
+
+	// the whole order is refunded once, so the order id is the refund id
+	scheduled, err := s.refunds.ScheduleRefund(ctx, tx, order, entity.OrderRefund{
+		Id:     order.Id,
+		Amount: order.Total,
+	})
+	if err != nil {
+		return errors.WithMessage(err, "schedule refund")
+	}
+	if scheduled {
+		err = tx.SetOrderRefundStatus(ctx, order.Id, entity.RefundStatusPending)
+		if err != nil {
+			return errors.WithMessage(err, "set order refund status")
+		}
+		return nil
+	}
+
+	comment := "отмена заказа"
+	if req.Reason != "" {
+		comment += ": " + req.Reason
+	}
+	err = refundOrderToWallet(ctx, tx, order, order.Total, req.Actor, comment)
+	if err != nil {
+		return errors.WithMessage(err, "refund order to wallet")
+	}
+	err = tx.SetOrderRefundStatus(ctx, order.Id, entity.RefundStatusRefunded)
+	if err != nil {
+		return errors.WithMessage(err, "set order refund status")
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/pkg/errors"
+)
+
+type OrderStatusTx interface {
+	GetOrderStatusForUpdate(ctx context.Context, orderId string) (string, error)
+	UpdateOrderStatus(ctx context.Context, orderId string, fromStatus string, newStatus string) error
+	InsertOrderStatusHistory(ctx context.Context, history entity.OrderStatusHistory) error
+}
+
+type OrderStatusTxRunner interface {
+	OrderStatusTx(ctx context.Context, tx func(ctx context.Context, tx OrderStatusTx) error) error
+}
+
+// OrderStatus is the single place where order status changes are allowed.
+// New statuses are added by extending the transitions map.
+// The payment side effects of the transitions are applied by OrderPayment.
+type OrderStatus struct {
+	txRunner    OrderStatusTxRunner
+	transitions map[string][]string
+}
+
+func NewOrderStatus(txRunner OrderStatusTxRunner) OrderStatus {
+	return OrderStatus{
+		txRunner:    txRunner,
+		transitions: defaultOrderStatusTransitions(),
+	}
+}
+
+func defaultOrderStatusTransitions() map[string][]string {
+	return map[string][]string{
+		entity.OrderItemStatusProcess: {
+			entity.OrderItemStatusPaid,
+			entity.OrderItemStatusCanceled,
+		},
+		entity.OrderItemStatusPaid: {
+			entity.OrderItemStatusSuccess,
+			entity.OrderItemStatusCanceled,
+		},
+		entity.OrderItemStatusCanceled: {},
+		entity.OrderItemStatusSuccess:  {},
+	}
+}
+
+func (s OrderStatus) IsTransitionAllowed(from string, to string) bool {
+	return slices.Contains(s.transitions[from], to)
+}
+
+func (s OrderStatus) ChangeStatus(ctx context.Context, req entity.OrderStatusChange) error {
+	err := s.txRunner.OrderStatusTx(ctx, func(ctx context.Context, tx OrderStatusTx) error {
+		err := s.changeStatus(ctx, tx, req)
+		if err != nil {
+			return errors.WithMessage(err, "change status")
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.WithMessagef(err, "order status tx, orderId=%s", req.OrderId)
+	}
+	return nil
+}
+
+// ChangeStatusTx changes the status within the transaction of the caller
+func (s OrderStatus) ChangeStatusTx(ctx context.Context, tx OrderStatusTx, req entity.OrderStatusChange) error {
+	err := s.changeStatus(ctx, tx, req)
+	if err != nil {
+		return errors.WithMessagef(err, "change status, orderId=%s", req.OrderId)
+	}
+	return nil
+}
+
+func (s OrderStatus) changeStatus(ctx context.Context, tx OrderStatusTx, req entity.OrderStatusChange) error {
+	current, err := tx.GetOrderStatusForUpdate(ctx, req.OrderId)
+	if err != nil {
+		return errors.WithMessage(err, "get order status")
+	}
+	if req.From != "" && current != req.From {
+		return errors.WithMessagef(domain.ErrOrderStatusConflict, "expected %s, got %s", req.From, current)
+	}
+	if !s.IsTransitionAllowed(current, req.To) {
+		return errors.WithMessagef(domain.ErrOrderStatusTransitionForbidden, "%s -> %s", current, req.To)
+	}
+
+	err = tx.UpdateOrderStatus(ctx, req.OrderId, current, req.To)
+	if err != nil {
+		return errors.WithMessage(err, "update order status")
+	}
+
+	err = tx.InsertOrderStatusHistory(ctx, entity.OrderStatusHistory{
+		OrderId:   req.OrderId,
+		OldStatus: current,
+		NewStatus: req.To,
+		Actor:     req.Actor,
+		Reason:    req.Reason,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return errors.WithMessage(err, "insert order status history")
+	}
+	return nil
+}
@@ -0,0 +1,5 @@
+package cash
+
+type PaymentPayload struct {
+	OrderId string
+}
@@ -0,0 +1,73 @@
+package cash
+
+import (
+	"context"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/Falokut/go-kit/json"
+	"github.com/pkg/errors"
+	"github.com/txix-open/bgjob"
+)
+
+type JobRepo interface {
+	DeleteJob(ctx context.Context, queue string, jobId string) error
+}
+
+type Payment struct {
+	jobRepo JobRepo
+	cli     *bgjob.Client
+}
+
+func NewPayment(jobRepo JobRepo, cli *bgjob.Client) Payment {
+	return Payment{
+		jobRepo: jobRepo,
+		cli:     cli,
+	}
+}
+
+const PaymentMethod string = "cash"
+const (
+	WorkerQueue = "cash-payment"
+	WorkerType  = "payment"
+)
+
+// Process confirms the order without an invoice, admins are notified by the worker
+// once the order is committed, the order is paid on pickup
+func (s Payment) Process(ctx context.Context, order *entity.Order) (entity.PaymentInvoice, error) {
+	// group order is notified once all its orders are paid
+	if order.GroupOrderId != "" {
+		return entity.PaymentInvoice{}, domain.ErrInvalidPaymentMethod
+	}
+
+	arg, err := json.Marshal(PaymentPayload{
+		OrderId: order.Id,
+	})
+	if err != nil {
+		return entity.PaymentInvoice{}, errors.WithMessage(err, "marshal payload")
+	}
+	err = s.cli.Enqueue(ctx, bgjob.EnqueueRequest{
+		Id:    order.Id,
+		Queue: WorkerQueue,
+		Type:  WorkerType,
+		Arg:   arg,
+	})
+	if err != nil {
+		return entity.PaymentInvoice{}, errors.WithMessage(err, "enqueue job")
+	}
+	return entity.RubInvoice(order, ""), nil
+}
+
+func (s Payment) Cancel(ctx context.Context, order *entity.Order) error {
+	err := s.jobRepo.DeleteJob(ctx, WorkerQueue, order.Id)
+	if err != nil {
+		return errors.WithMessage(err, "delete job")
+	}
+	return nil
+}
+
+// IsPaidOnReceipt is true, the order waits for payment until it's received
+func (s Payment) IsPaidOnReceipt() bool {
+	return true
+}
@@ -0,0 +1,46 @@
+package cash
+
+import (
+	"context"
+
+	"dishes-service-backend/entity"
+
+	"github.com/pkg/errors"
+)
+
+type OrderRepo interface {
+	GetOrder(ctx context.Context, orderId string) (*entity.Order, error)
+}
+
+type Notifier interface {
+	NotifyCashOrder(ctx context.Context, order *entity.Order) error
+}
+
+type Worker struct {
+	orderRepo OrderRepo
+	notifier  Notifier
+}
+
+func NewWorker(orderRepo OrderRepo, notifier Notifier) Worker {
+	return Worker{
+		orderRepo: orderRepo,
+		notifier:  notifier,
+	}
+}
+
+func (w Worker) ProcessPayment(ctx context.Context, req *PaymentPayload) error {
+	order, err := w.orderRepo.GetOrder(ctx, req.OrderId)
+	if err != nil {
+		return errors.WithMessage(err, "get order")
+	}
+	if order.Status != entity.OrderItemStatusProcess {
+		// order was canceled before the admins were notified
+		return nil
+	}
+
+	err = w.notifier.NotifyCashOrder(ctx, order)
+	if err != nil {
+		return errors.WithMessage(err, "notify cash order")
+	}
+	return nil
+}
@@ -0,0 +1,6 @@
+package corporate
+
+type PaymentPayload struct {
+	OrderId   string
+	CompanyId int32
+}
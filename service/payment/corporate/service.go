@@ -0,0 +1,92 @@
+package corporate
+
+import (
+	"context"
+	"time"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/Falokut/go-kit/json"
+	"github.com/pkg/errors"
+	"github.com/txix-open/bgjob"
+)
+
+type CompanyRepo interface {
+	GetUserCompany(ctx context.Context, userId string) (entity.Company, error)
+	GetCompanyOrdersTotal(ctx context.Context, companyId int32, from time.Time) (int64, error)
+}
+
+type JobRepo interface {
+	DeleteJob(ctx context.Context, queue string, jobId string) error
+}
+
+type Payment struct {
+	companyRepo CompanyRepo
+	jobRepo     JobRepo
+	cli         *bgjob.Client
+}
+
+func NewPayment(companyRepo CompanyRepo, jobRepo JobRepo, cli *bgjob.Client) Payment {
+	return Payment{
+		companyRepo: companyRepo,
+		jobRepo:     jobRepo,
+		cli:         cli,
+	}
+}
+
+const PaymentMethod string = "corporate"
+const (
+	WorkerQueue = "corporate-payment"
+	WorkerType  = "payment"
+)
+
+// Process checks the company credit limit and enqueues the charge,
+// the limit is checked again by the worker under the company lock
+func (s Payment) Process(ctx context.Context, order *entity.Order) (entity.PaymentInvoice, error) {
+	company, err := s.companyRepo.GetUserCompany(ctx, order.UserId)
+	if err != nil {
+		return entity.PaymentInvoice{}, errors.WithMessage(err, "get user company")
+	}
+	if company.CreditLimit > 0 {
+		used, err := s.companyRepo.GetCompanyOrdersTotal(ctx, company.Id, monthStart(order.CreatedAt))
+		if err != nil {
+			return entity.PaymentInvoice{}, errors.WithMessage(err, "get company orders total")
+		}
+		if used+int64(order.Total) > int64(company.CreditLimit) {
+			return entity.PaymentInvoice{}, domain.ErrCreditLimitExceeded
+		}
+	}
+
+	arg, err := json.Marshal(PaymentPayload{
+		OrderId:   order.Id,
+		CompanyId: company.Id,
+	})
+	if err != nil {
+		return entity.PaymentInvoice{}, errors.WithMessage(err, "marshal payload")
+	}
+	err = s.cli.Enqueue(ctx, bgjob.EnqueueRequest{
+		Id:    order.Id,
+		Queue: WorkerQueue,
+		Type:  WorkerType,
+		Arg:   arg,
+	})
+	if err != nil {
+		return entity.PaymentInvoice{}, errors.WithMessage(err, "enqueue job")
+	}
+	return entity.RubInvoice(order, ""), nil
+}
+
+func (s Payment) Cancel(ctx context.Context, order *entity.Order) error {
+	err := s.jobRepo.DeleteJob(ctx, WorkerQueue, order.Id)
+	if err != nil {
+		return errors.WithMessage(err, "delete job")
+	}
+	return nil
+}
+
+// monthStart returns the beginning of the credit limit period
+func monthStart(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
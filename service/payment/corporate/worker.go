@@ -0,0 +1,140 @@
+package corporate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"dishes-service-backend/entity"
+	"dishes-service-backend/service"
+
+	"github.com/pkg/errors"
+)
+
+type ChargeTx interface {
+	service.OrderPaymentTx
+	GetCompanyForUpdate(ctx context.Context, id int32) (entity.Company, error)
+	GetCompanyOrdersTotal(ctx context.Context, companyId int32, from time.Time) (int64, error)
+	SetOrderCompany(ctx context.Context, orderId string, companyId int32) error
+}
+
+type TxRunner interface {
+	ChargeCompanyTx(ctx context.Context, tx func(ctx context.Context, tx ChargeTx) error) error
+}
+
+type OrderPaymentService interface {
+	PayTx(ctx context.Context, tx service.OrderPaymentTx, req entity.OrderStatusChange, payment entity.OrderPayment) error
+	CancelTx(ctx context.Context, tx service.OrderPaymentTx, req entity.OrderStatusChange, payment entity.OrderPayment) error
+}
+
+type Notifier interface {
+	NotifySuccessPayment(ctx context.Context, order *entity.Order) error
+	NotifyPaymentRejected(ctx context.Context, orderId string, reason string) error
+}
+
+const creditLimitExceededReason = "превышен кредитный лимит компании"
+
+type Worker struct {
+	txRunner      TxRunner
+	orderPayments OrderPaymentService
+	notifier      Notifier
+}
+
+func NewWorker(txRunner TxRunner, orderPayments OrderPaymentService, notifier Notifier) Worker {
+	return Worker{
+		txRunner:      txRunner,
+		orderPayments: orderPayments,
+		notifier:      notifier,
+	}
+}
+
+func (w Worker) ProcessPayment(ctx context.Context, req *PaymentPayload) error {
+	var order *entity.Order
+	var paid bool
+	err := w.txRunner.ChargeCompanyTx(ctx, func(ctx context.Context, tx ChargeTx) error {
+		var err error
+		order, paid, err = w.charge(ctx, tx, req)
+		if err != nil {
+			return errors.WithMessage(err, "charge company")
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.WithMessage(err, "charge company tx")
+	}
+
+	switch {
+	case order == nil:
+		// order was already paid, canceled or expired
+		return nil
+	case paid:
+		err = w.notifier.NotifySuccessPayment(ctx, order)
+		if err != nil {
+			return errors.WithMessage(err, "notify success payment")
+		}
+	default:
+		err = w.notifier.NotifyPaymentRejected(ctx, order.Id, creditLimitExceededReason)
+		if err != nil {
+			return errors.WithMessage(err, "notify payment rejected")
+		}
+	}
+	return nil
+}
+
+// charge moves the order to PAID if the company credit limit allows it, otherwise cancels it,
+// the returned order is nil if it doesn't wait for payment anymore
+func (w Worker) charge(ctx context.Context, tx ChargeTx, req *PaymentPayload) (*entity.Order, bool, error) {
+	company, err := tx.GetCompanyForUpdate(ctx, req.CompanyId)
+	if err != nil {
+		return nil, false, errors.WithMessage(err, "get company")
+	}
+	status, err := tx.GetOrderStatusForUpdate(ctx, req.OrderId)
+	if err != nil {
+		return nil, false, errors.WithMessage(err, "get order status")
+	}
+	if status != entity.OrderItemStatusProcess {
+		return nil, false, nil
+	}
+	order, err := tx.GetOrder(ctx, req.OrderId)
+	if err != nil {
+		return nil, false, errors.WithMessage(err, "get order")
+	}
+
+	if company.CreditLimit > 0 {
+		used, err := tx.GetCompanyOrdersTotal(ctx, company.Id, monthStart(order.CreatedAt))
+		if err != nil {
+			return nil, false, errors.WithMessage(err, "get company orders total")
+		}
+		if used+int64(order.Total) > int64(company.CreditLimit) {
+			err = w.orderPayments.CancelTx(ctx, tx, entity.OrderStatusChange{
+				OrderId: order.Id,
+				From:    entity.OrderItemStatusProcess,
+				To:      entity.OrderItemStatusCanceled,
+				Actor:   entity.OrderActorSystem,
+				Reason:  creditLimitExceededReason,
+			}, entity.OrderPayment{})
+			if err != nil {
+				return nil, false, errors.WithMessage(err, "cancel order")
+			}
+			order.Status = entity.OrderItemStatusCanceled
+			return order, false, nil
+		}
+	}
+
+	err = tx.SetOrderCompany(ctx, order.Id, company.Id)
+	if err != nil {
+		return nil, false, errors.WithMessage(err, "set order company")
+	}
+	err = w.orderPayments.PayTx(ctx, tx, entity.OrderStatusChange{
+		OrderId: order.Id,
+		From:    entity.OrderItemStatusProcess,
+		To:      entity.OrderItemStatusPaid,
+		Actor:   entity.OrderActorSystem,
+		Reason:  fmt.Sprintf("оплата со счёта компании %s", company.Name),
+	}, entity.OrderPayment{})
+	if err != nil {
+		return nil, false, errors.WithMessage(err, "pay order")
+	}
+	order.Status = entity.OrderItemStatusPaid
+	return order, true, nil
+}
@@ -11,6 +11,8 @@ import (
 
 type PaymentWorker interface {
 	ProcessPayment(ctx context.Context, req *PaymentPayload) error
+	RemindPayment(ctx context.Context, req *ReminderPayload) error
+	NotifyExpired(ctx context.Context, req *ExpiredPayload) error
 }
 
 type WorkerController struct {
@@ -23,10 +25,22 @@ func NewWorkerController(worker PaymentWorker) WorkerController {
 	}
 }
 
-const defaultRetryTime = time.Minute * 5
+const (
+	defaultRetryTime   = time.Minute * 5
+	reminderRetryTime  = time.Minute
+	expiredRetryTime   = time.Minute
+	maxExpiredAttempts = 10
+)
 
 //nolint:gocritic
 func (c WorkerController) Handle(ctx context.Context, job bgjob.Job) bgjob.Result {
+	switch job.Type {
+	case ReminderType:
+		return c.handleReminder(ctx, job)
+	case ExpiredType:
+		return c.handleExpired(ctx, job)
+	}
+
 	var payload PaymentPayload
 	err := json.Unmarshal(job.Arg, &payload)
 	if err != nil {
@@ -40,3 +54,38 @@ func (c WorkerController) Handle(ctx context.Context, job bgjob.Job) bgjob.Resul
 
 	return bgjob.Complete()
 }
+
+//nolint:gocritic
+func (c WorkerController) handleReminder(ctx context.Context, job bgjob.Job) bgjob.Result {
+	var payload ReminderPayload
+	err := json.Unmarshal(job.Arg, &payload)
+	if err != nil {
+		return bgjob.MoveToDlq(errors.WithMessage(err, "unmarshal reminder payload"))
+	}
+
+	err = c.worker.RemindPayment(ctx, &payload)
+	if err != nil {
+		// the retries stop once the order is expired or paid
+		return bgjob.Reschedule(reminderRetryTime)
+	}
+	return bgjob.Complete()
+}
+
+//nolint:gocritic
+func (c WorkerController) handleExpired(ctx context.Context, job bgjob.Job) bgjob.Result {
+	var payload ExpiredPayload
+	err := json.Unmarshal(job.Arg, &payload)
+	if err != nil {
+		return bgjob.MoveToDlq(errors.WithMessage(err, "unmarshal expired payload"))
+	}
+
+	err = c.worker.NotifyExpired(ctx, &payload)
+	if err != nil {
+		// the user may have blocked the bot, so the notification isn't retried forever
+		if job.Attempt >= maxExpiredAttempts {
+			return bgjob.MoveToDlq(err)
+		}
+		return bgjob.Retry(expiredRetryTime, err)
+	}
+	return bgjob.Complete()
+}
@@ -3,3 +3,12 @@ package expiration
 type PaymentPayload struct {
 	OrderId string
 }
+
+type ReminderPayload struct {
+	OrderId     string
+	MinutesLeft int
+}
+
+type ExpiredPayload struct {
+	OrderId string
+}
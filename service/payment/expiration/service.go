@@ -9,22 +9,39 @@ import (
 	"github.com/txix-open/bgjob"
 )
 
+type JobRepo interface {
+	DeleteJob(ctx context.Context, queue string, jobId string) error
+}
+
 type Expiration struct {
 	cli             *bgjob.Client
+	jobRepo         JobRepo
 	expirationDelay time.Duration
+	reminderBefore  time.Duration
 }
 
-func NewExpiration(cli *bgjob.Client, expirationDelay time.Duration) Expiration {
+// NewExpiration creates the expiration service,
+// the reminder is disabled if reminderBefore is zero or not less than expirationDelay
+func NewExpiration(
+	cli *bgjob.Client,
+	jobRepo JobRepo,
+	expirationDelay time.Duration,
+	reminderBefore time.Duration,
+) Expiration {
 	return Expiration{
 		cli:             cli,
+		jobRepo:         jobRepo,
 		expirationDelay: expirationDelay,
+		reminderBefore:  reminderBefore,
 	}
 }
 
 const PaymentMethod string = "telegram"
 const (
-	WorkerQueue = "payment-expiration"
-	WorkerType  = "payment"
+	WorkerQueue  = "payment-expiration"
+	WorkerType   = "payment"
+	ReminderType = "reminder"
+	ExpiredType  = "expired"
 )
 
 func (s Expiration) AddOrder(ctx context.Context, orderId string) error {
@@ -38,6 +55,7 @@ func (s Expiration) AddOrder(ctx context.Context, orderId string) error {
 	}
 
 	err = s.cli.Enqueue(ctx, bgjob.EnqueueRequest{
+		Id:    jobId(orderId),
 		Queue: WorkerQueue,
 		Type:  WorkerType,
 		Arg:   arg,
@@ -48,5 +66,51 @@ func (s Expiration) AddOrder(ctx context.Context, orderId string) error {
 	if err != nil {
 		return errors.WithMessage(err, "enqueue job")
 	}
+
+	if s.reminderBefore <= 0 || s.reminderBefore >= s.expirationDelay {
+		return nil
+	}
+	arg, err = json.Marshal(ReminderPayload{
+		OrderId:     orderId,
+		MinutesLeft: int(s.reminderBefore.Minutes()),
+	})
+	if err != nil {
+		return errors.WithMessage(err, "marshal reminder payload")
+	}
+	err = s.cli.Enqueue(ctx, bgjob.EnqueueRequest{
+		Id:    reminderJobId(orderId),
+		Queue: WorkerQueue,
+		Type:  ReminderType,
+		Arg:   arg,
+		Delay: s.expirationDelay - s.reminderBefore,
+	})
+	if err != nil {
+		return errors.WithMessage(err, "enqueue reminder job")
+	}
+	return nil
+}
+
+func (s Expiration) RemoveOrder(ctx context.Context, orderId string) error {
+	err := s.jobRepo.DeleteJob(ctx, WorkerQueue, jobId(orderId))
+	if err != nil {
+		return errors.WithMessage(err, "delete job")
+	}
+	err = s.jobRepo.DeleteJob(ctx, WorkerQueue, reminderJobId(orderId))
+	if err != nil {
+		return errors.WithMessage(err, "delete reminder job")
+	}
 	return nil
 }
+
+// job id must differ from the telegram-payment job id, which is the order id itself
+func jobId(orderId string) string {
+	return WorkerQueue + "_" + orderId
+}
+
+func reminderJobId(orderId string) string {
+	return WorkerQueue + "_" + ReminderType + "_" + orderId
+}
+
+func expiredJobId(orderId string) string {
+	return WorkerQueue + "_" + ExpiredType + "_" + orderId
+}
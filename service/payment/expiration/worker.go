@@ -3,28 +3,127 @@ package expiration
 import (
 	"context"
 
+	"dishes-service-backend/domain"
 	"dishes-service-backend/entity"
+	"dishes-service-backend/service"
+
+	"github.com/Falokut/go-kit/json"
 	"github.com/pkg/errors"
+	"github.com/txix-open/bgjob"
 )
 
+type TxRunner interface {
+	OrderPaymentTx(ctx context.Context, tx func(ctx context.Context, tx service.OrderPaymentTx) error) error
+}
+
+type OrderPaymentService interface {
+	CancelTx(ctx context.Context, tx service.OrderPaymentTx, req entity.OrderStatusChange, payment entity.OrderPayment) error
+}
+
 type OrderRepo interface {
-	SetOrderStatus(ctx context.Context, orderId, oldStatus, newStatus string) error
+	GetOrder(ctx context.Context, orderId string) (*entity.Order, error)
+}
+
+type Notifier interface {
+	OrderExpired(ctx context.Context, orderId string) error
+	RemindOrderPayment(ctx context.Context, order *entity.Order, minutesLeft int) error
 }
 
 type Worker struct {
-	repo OrderRepo
+	txRunner      TxRunner
+	orderPayments OrderPaymentService
+	orderRepo     OrderRepo
+	notifier      Notifier
 }
 
-func NewWorker(repo OrderRepo) Worker {
+func NewWorker(
+	txRunner TxRunner,
+	orderPayments OrderPaymentService,
+	orderRepo OrderRepo,
+	notifier Notifier,
+) Worker {
 	return Worker{
-		repo: repo,
+		txRunner:      txRunner,
+		orderPayments: orderPayments,
+		orderRepo:     orderRepo,
+		notifier:      notifier,
 	}
 }
 
+// ProcessPayment cancels the unpaid order, the notification is enqueued
+// within the same transaction, so it is retried on its own,
+// the invoice and the open payment of the order are canceled by the job enqueued with the cancellation
 func (w Worker) ProcessPayment(ctx context.Context, req *PaymentPayload) error {
-	err := w.repo.SetOrderStatus(ctx, req.OrderId, entity.OrderItemStatusProcess, entity.OrderItemStatusCanceled)
+	err := w.txRunner.OrderPaymentTx(ctx, func(ctx context.Context, tx service.OrderPaymentTx) error {
+		err := w.orderPayments.CancelTx(ctx, tx, entity.OrderStatusChange{
+			OrderId: req.OrderId,
+			From:    entity.OrderItemStatusProcess,
+			To:      entity.OrderItemStatusCanceled,
+			Actor:   entity.OrderActorSystem,
+			Reason:  "истёк срок оплаты",
+		}, entity.OrderPayment{})
+		if err != nil {
+			return errors.WithMessage(err, "cancel order")
+		}
+		err = enqueueExpiredNotification(ctx, tx, req.OrderId)
+		if err != nil {
+			return errors.WithMessage(err, "enqueue expired notification")
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, domain.ErrOrderStatusConflict), errors.Is(err, domain.ErrOrderNotFound):
+		// order was already paid or canceled
+		return nil
+	case err != nil:
+		return errors.WithMessage(err, "order payment tx")
+	}
+	return nil
+}
+
+func (w Worker) NotifyExpired(ctx context.Context, req *ExpiredPayload) error {
+	err := w.notifier.OrderExpired(ctx, req.OrderId)
+	if err != nil {
+		return errors.WithMessage(err, "notify order expired")
+	}
+	return nil
+}
+
+func (w Worker) RemindPayment(ctx context.Context, req *ReminderPayload) error {
+	order, err := w.orderRepo.GetOrder(ctx, req.OrderId)
+	switch {
+	case errors.Is(err, domain.ErrOrderNotFound):
+		return nil
+	case err != nil:
+		return errors.WithMessage(err, "get order")
+	}
+	if order.Status != entity.OrderItemStatusProcess {
+		// order was already paid or canceled
+		return nil
+	}
+
+	err = w.notifier.RemindOrderPayment(ctx, order, req.MinutesLeft)
+	if err != nil {
+		return errors.WithMessage(err, "remind order payment")
+	}
+	return nil
+}
+
+func enqueueExpiredNotification(ctx context.Context, tx service.JobTx, orderId string) error {
+	arg, err := json.Marshal(ExpiredPayload{
+		OrderId: orderId,
+	})
+	if err != nil {
+		return errors.WithMessage(err, "marshal payload")
+	}
+	err = tx.EnqueueJob(ctx, bgjob.EnqueueRequest{
+		Id:    expiredJobId(orderId),
+		Queue: WorkerQueue,
+		Type:  ExpiredType,
+		Arg:   arg,
+	})
 	if err != nil {
-		return errors.WithMessage(err, "update order status")
+		return errors.WithMessage(err, "enqueue job")
 	}
 	return nil
 }
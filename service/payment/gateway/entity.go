@@ -0,0 +1,66 @@
+package gateway
+
+// the provider API follows the redirect-based acquirers (YooKassa, CloudPayments):
+// the payment is created by the shop, the user pays on the confirmation page
+// and the provider reports the result to the signed webhook
+
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusCanceled  = "canceled"
+)
+
+const (
+	EventPaymentSucceeded = "payment.succeeded"
+	EventPaymentCanceled  = "payment.canceled"
+)
+
+const (
+	AuthorizationHeader  = "Authorization"
+	IdempotenceKeyHeader = "Idempotence-Key"
+	SignatureHeader      = "X-Signature"
+)
+
+const currencyRub = "RUB"
+
+// Amount is specified in minor units of the currency
+type Amount struct {
+	Value    int64  `json:"value"`
+	Currency string `json:"currency"`
+}
+
+type Metadata struct {
+	OrderId string `json:"order_id"`
+}
+
+type CreatePaymentRequest struct {
+	Amount      Amount   `json:"amount"`
+	Description string   `json:"description"`
+	ReturnUrl   string   `json:"return_url"`
+	Metadata    Metadata `json:"metadata"`
+}
+
+type PaymentObject struct {
+	Id              string   `json:"id"`
+	Status          string   `json:"status"`
+	Amount          Amount   `json:"amount"`
+	ConfirmationUrl string   `json:"confirmation_url"`
+	Metadata        Metadata `json:"metadata"`
+}
+
+type CreateRefundRequest struct {
+	PaymentId string `json:"payment_id"`
+	Amount    Amount `json:"amount"`
+}
+
+type RefundObject struct {
+	Id        string `json:"id"`
+	PaymentId string `json:"payment_id"`
+	Status    string `json:"status"`
+	Amount    Amount `json:"amount"`
+}
+
+type WebhookEvent struct {
+	Event  string        `json:"event"`
+	Object PaymentObject `json:"object"`
+}
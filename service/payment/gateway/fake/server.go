@@ -0,0 +1,294 @@
+package fake
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"dishes-service-backend/service/payment/gateway"
+
+	"github.com/Falokut/go-kit/json"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// Server is the local payment gateway for development and tests,
+// the payment is completed by opening its confirmation url or by calling Complete
+type Server struct {
+	secret     string
+	webhookUrl string
+	cli        *http.Client
+	mux        *http.ServeMux
+
+	lock        sync.Mutex
+	payments    map[string]*session
+	refunds     map[string]gateway.RefundObject
+	idempotence map[string]string
+}
+
+type session struct {
+	gateway.PaymentObject
+	returnUrl string
+	refunded  int64
+}
+
+func NewServer(secret string, webhookUrl string) *Server {
+	s := &Server{
+		secret:      secret,
+		webhookUrl:  webhookUrl,
+		cli:         &http.Client{},
+		payments:    make(map[string]*session),
+		refunds:     make(map[string]gateway.RefundObject),
+		idempotence: make(map[string]string),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /payments", s.createPayment)
+	mux.HandleFunc("POST /payments/{id}/cancel", s.cancelPayment)
+	mux.HandleFunc("POST /refunds", s.createRefund)
+	mux.HandleFunc("GET /checkout/{id}", s.checkout)
+	s.mux = mux
+	return s
+}
+
+// SetWebhookUrl changes the address the payment events are sent to
+func (s *Server) SetWebhookUrl(webhookUrl string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.webhookUrl = webhookUrl
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) Payment(id string) (gateway.PaymentObject, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	payment, ok := s.payments[id]
+	if !ok {
+		return gateway.PaymentObject{}, false
+	}
+	return payment.PaymentObject, true
+}
+
+// Refunded returns the refunded amount of the payment
+func (s *Server) Refunded(paymentId string) int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	payment, ok := s.payments[paymentId]
+	if !ok {
+		return 0
+	}
+	return payment.refunded
+}
+
+// Complete finishes the pending payment and sends the signed event to the webhook
+func (s *Server) Complete(ctx context.Context, paymentId string, succeeded bool) error {
+	return s.complete(ctx, paymentId, succeeded, 0)
+}
+
+// CompleteWithAmount finishes the pending payment as succeeded with the charged amount
+// differing from the requested one
+func (s *Server) CompleteWithAmount(ctx context.Context, paymentId string, amount int64) error {
+	return s.complete(ctx, paymentId, true, amount)
+}
+
+// complete keeps the requested amount if the charged one is zero
+func (s *Server) complete(ctx context.Context, paymentId string, succeeded bool, amount int64) error {
+	s.lock.Lock()
+	payment, ok := s.payments[paymentId]
+	if !ok {
+		s.lock.Unlock()
+		return errors.Errorf("payment %s not found", paymentId)
+	}
+	if payment.Status != gateway.StatusPending {
+		s.lock.Unlock()
+		return errors.Errorf("payment %s is %s", paymentId, payment.Status)
+	}
+	event := gateway.WebhookEvent{
+		Event:  gateway.EventPaymentCanceled,
+		Object: payment.PaymentObject,
+	}
+	event.Object.Status = gateway.StatusCanceled
+	if succeeded {
+		event.Event = gateway.EventPaymentSucceeded
+		event.Object.Status = gateway.StatusSucceeded
+	}
+	if amount > 0 {
+		event.Object.Amount.Value = amount
+	}
+	webhookUrl := s.webhookUrl
+	s.lock.Unlock()
+
+	err := s.sendEvent(ctx, webhookUrl, event)
+	if err != nil {
+		return errors.WithMessage(err, "send event")
+	}
+
+	s.lock.Lock()
+	payment.Status = event.Object.Status
+	s.lock.Unlock()
+	return nil
+}
+
+func (s *Server) sendEvent(ctx context.Context, webhookUrl string, event gateway.WebhookEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return errors.WithMessage(err, "marshal event")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookUrl, bytes.NewReader(body))
+	if err != nil {
+		return errors.WithMessage(err, "new request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(gateway.SignatureHeader, gateway.Sign(s.secret, body))
+	resp, err := s.cli.Do(req)
+	if err != nil {
+		return errors.WithMessagef(err, "call webhook %s", webhookUrl)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return errors.Errorf("call webhook %s, unexpected status: %d", webhookUrl, resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *Server) createPayment(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get(gateway.AuthorizationHeader) == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "read body", http.StatusBadRequest)
+		return
+	}
+	var req gateway.CreatePaymentRequest
+	err = json.Unmarshal(body, &req)
+	if err != nil || req.Amount.Value <= 0 {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	key := r.Header.Get(gateway.IdempotenceKeyHeader)
+	if id, ok := s.idempotence[key]; ok && key != "" {
+		writeJson(w, s.payments[id].PaymentObject)
+		return
+	}
+	id := uuid.NewString()
+	payment := &session{
+		PaymentObject: gateway.PaymentObject{
+			Id:              id,
+			Status:          gateway.StatusPending,
+			Amount:          req.Amount,
+			ConfirmationUrl: fmt.Sprintf("http://%s/checkout/%s", r.Host, id),
+			Metadata:        req.Metadata,
+		},
+		returnUrl: req.ReturnUrl,
+	}
+	s.payments[id] = payment
+	if key != "" {
+		s.idempotence[key] = id
+	}
+	writeJson(w, payment.PaymentObject)
+}
+
+func (s *Server) cancelPayment(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	payment, ok := s.payments[r.PathValue("id")]
+	switch {
+	case !ok:
+		http.Error(w, "payment not found", http.StatusNotFound)
+		return
+	case payment.Status == gateway.StatusSucceeded:
+		http.Error(w, "payment already succeeded", http.StatusConflict)
+		return
+	}
+	payment.Status = gateway.StatusCanceled
+	writeJson(w, payment.PaymentObject)
+}
+
+func (s *Server) createRefund(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get(gateway.AuthorizationHeader) == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "read body", http.StatusBadRequest)
+		return
+	}
+	var req gateway.CreateRefundRequest
+	err = json.Unmarshal(body, &req)
+	if err != nil || req.Amount.Value <= 0 {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	key := r.Header.Get(gateway.IdempotenceKeyHeader)
+	if refund, ok := s.refunds[key]; ok && key != "" {
+		writeJson(w, refund)
+		return
+	}
+	payment, ok := s.payments[req.PaymentId]
+	switch {
+	case !ok:
+		http.Error(w, "payment not found", http.StatusNotFound)
+		return
+	case payment.Status != gateway.StatusSucceeded:
+		http.Error(w, "payment isn't succeeded", http.StatusConflict)
+		return
+	case payment.refunded+req.Amount.Value > payment.Amount.Value:
+		http.Error(w, "refund exceeds the payment amount", http.StatusBadRequest)
+		return
+	}
+	payment.refunded += req.Amount.Value
+	refund := gateway.RefundObject{
+		Id:        uuid.NewString(),
+		PaymentId: payment.Id,
+		Status:    gateway.StatusSucceeded,
+		Amount:    req.Amount,
+	}
+	if key != "" {
+		s.refunds[key] = refund
+	}
+	writeJson(w, refund)
+}
+
+// checkout imitates the confirmation page, ?result=canceled declines the payment
+func (s *Server) checkout(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	succeeded := r.URL.Query().Get("result") != gateway.StatusCanceled
+	err := s.Complete(r.Context(), id, succeeded)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	payment, _ := s.Payment(id)
+	s.lock.Lock()
+	returnUrl := s.payments[id].returnUrl
+	s.lock.Unlock()
+	if returnUrl != "" {
+		http.Redirect(w, r, returnUrl, http.StatusFound)
+		return
+	}
+	_, _ = fmt.Fprintf(w, "payment %s %s\n", payment.Id, payment.Status)
+}
+
+func writeJson(w http.ResponseWriter, value any) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
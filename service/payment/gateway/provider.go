@@ -0,0 +1,75 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Falokut/go-kit/http/client"
+	"github.com/pkg/errors"
+)
+
+// HttpProvider calls the payment gateway API, cli base url is the gateway API address
+type HttpProvider struct {
+	cli    *client.Client
+	apiKey string
+}
+
+func NewHttpProvider(cli *client.Client, apiKey string) HttpProvider {
+	return HttpProvider{
+		cli:    cli,
+		apiKey: apiKey,
+	}
+}
+
+// CreatePayment creates the payment, the repeated call with the same idempotence key returns the same payment
+func (p HttpProvider) CreatePayment(
+	ctx context.Context,
+	idempotenceKey string,
+	req CreatePaymentRequest,
+) (*PaymentObject, error) {
+	var payment PaymentObject
+	_, err := p.cli.Post("/payments").
+		Header(AuthorizationHeader, "Bearer "+p.apiKey).
+		Header(IdempotenceKeyHeader, idempotenceKey).
+		JsonRequestBody(req).
+		StatusCodeToError().
+		JsonResponseBody(&payment).
+		Do(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, "call payment gateway /payments")
+	}
+	return &payment, nil
+}
+
+func (p HttpProvider) CancelPayment(ctx context.Context, paymentId string) error {
+	endpoint := fmt.Sprintf("/payments/%s/cancel", paymentId)
+	_, err := p.cli.Post(endpoint).
+		Header(AuthorizationHeader, "Bearer "+p.apiKey).
+		Header(IdempotenceKeyHeader, paymentId).
+		StatusCodeToError().
+		Do(ctx)
+	if err != nil {
+		return errors.WithMessagef(err, "call payment gateway %s", endpoint)
+	}
+	return nil
+}
+
+// CreateRefund returns the amount to the payer, the repeated call with the same idempotence key returns the same refund
+func (p HttpProvider) CreateRefund(
+	ctx context.Context,
+	idempotenceKey string,
+	req CreateRefundRequest,
+) (*RefundObject, error) {
+	var refund RefundObject
+	_, err := p.cli.Post("/refunds").
+		Header(AuthorizationHeader, "Bearer "+p.apiKey).
+		Header(IdempotenceKeyHeader, idempotenceKey).
+		JsonRequestBody(req).
+		StatusCodeToError().
+		JsonResponseBody(&refund).
+		Do(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, "call payment gateway /refunds")
+	}
+	return &refund, nil
+}
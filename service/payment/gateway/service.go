@@ -0,0 +1,111 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+
+	"dishes-service-backend/entity"
+
+	"github.com/pkg/errors"
+)
+
+type Provider interface {
+	CreatePayment(ctx context.Context, idempotenceKey string, req CreatePaymentRequest) (*PaymentObject, error)
+	CancelPayment(ctx context.Context, paymentId string) error
+	CreateRefund(ctx context.Context, idempotenceKey string, req CreateRefundRequest) (*RefundObject, error)
+}
+
+type SessionRepo interface {
+	InsertPaymentSession(ctx context.Context, session entity.PaymentSession) error
+	GetOrderPaymentSessions(ctx context.Context, orderId string) ([]entity.PaymentSession, error)
+}
+
+type Payment struct {
+	provider    Provider
+	sessionRepo SessionRepo
+	returnUrl   string
+}
+
+func NewPayment(provider Provider, sessionRepo SessionRepo, returnUrl string) Payment {
+	return Payment{
+		provider:    provider,
+		sessionRepo: sessionRepo,
+		returnUrl:   returnUrl,
+	}
+}
+
+const PaymentMethod string = "gateway"
+
+// Process returns the invoice of the order total, the payment is created at the gateway
+// by Checkout once the order is committed, so the provider isn't called within the order transaction
+func (s Payment) Process(ctx context.Context, order *entity.Order) (entity.PaymentInvoice, error) {
+	return entity.RubInvoice(order, ""), nil
+}
+
+// Checkout creates the payment at the gateway and returns its confirmation url,
+// the order id is the idempotence key, so the repeated call returns the same payment,
+// the order is paid or canceled by the gateway webhook
+func (s Payment) Checkout(ctx context.Context, order *entity.Order) (string, error) {
+	payment, err := s.provider.CreatePayment(ctx, order.Id, CreatePaymentRequest{
+		Amount: Amount{
+			Value:    int64(order.Total),
+			Currency: currencyRub,
+		},
+		Description: fmt.Sprintf("Оплата заказа %s", order.Id),
+		ReturnUrl:   s.returnUrl,
+		Metadata:    Metadata{OrderId: order.Id},
+	})
+	if err != nil {
+		return "", errors.WithMessage(err, "create payment")
+	}
+	err = s.sessionRepo.InsertPaymentSession(ctx, entity.PaymentSession{
+		Id:      payment.Id,
+		OrderId: order.Id,
+		Amount:  int64(order.Total),
+		Url:     payment.ConfirmationUrl,
+	})
+	if err != nil {
+		return "", errors.WithMessage(err, "insert payment session")
+	}
+	return payment.ConfirmationUrl, nil
+}
+
+func (s Payment) Cancel(ctx context.Context, order *entity.Order) error {
+	sessions, err := s.sessionRepo.GetOrderPaymentSessions(ctx, order.Id)
+	if err != nil {
+		return errors.WithMessage(err, "get order payment sessions")
+	}
+	for _, session := range sessions {
+		err = s.provider.CancelPayment(ctx, session.Id)
+		if err != nil {
+			return errors.WithMessagef(err, "cancel payment %s", session.Id)
+		}
+	}
+	return nil
+}
+
+// CanRefund always returns true, the gateway refunds any part of the payment
+func (s Payment) CanRefund(order *entity.Order, refund entity.OrderRefund) bool {
+	return true
+}
+
+// Refund returns the refund amount to the payer through the gateway
+func (s Payment) Refund(ctx context.Context, order *entity.Order, refund entity.OrderRefund) error {
+	if order.PaymentChargeId == "" {
+		return errors.Errorf("order %s has no payment id", order.Id)
+	}
+	created, err := s.provider.CreateRefund(ctx, "refund-"+refund.Id, CreateRefundRequest{
+		PaymentId: order.PaymentChargeId,
+		Amount: Amount{
+			Value:    int64(refund.Amount),
+			Currency: currencyRub,
+		},
+	})
+	if err != nil {
+		return errors.WithMessage(err, "create refund")
+	}
+	if created.Status != StatusSucceeded {
+		return errors.Errorf("refund %s is %s", created.Id, created.Status)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package gateway
+
+import (
+	"context"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/Falokut/go-kit/json"
+	"github.com/Falokut/go-kit/log"
+	"github.com/pkg/errors"
+)
+
+type WebhookSessionRepo interface {
+	GetPaymentSession(ctx context.Context, id string) (entity.PaymentSession, error)
+}
+
+type OrderRepo interface {
+	GetOrder(ctx context.Context, orderId string) (*entity.Order, error)
+}
+
+type OrderPaymentService interface {
+	Pay(ctx context.Context, req entity.OrderStatusChange, payment entity.OrderPayment) error
+	Cancel(ctx context.Context, req entity.OrderStatusChange, payment entity.OrderPayment) error
+}
+
+type Notifier interface {
+	NotifySuccessPayment(ctx context.Context, order *entity.Order) error
+	NotifyPaymentRejected(ctx context.Context, orderId string, reason string) error
+}
+
+const (
+	paidReason     = "оплата через платёжный шлюз"
+	canceledReason = "платёж отменён платёжным шлюзом"
+)
+
+type PaymentRecorder interface {
+	RecordUnappliedPayment(ctx context.Context, orderId string, result entity.PaymentResult) error
+}
+
+type Webhook struct {
+	secret          string
+	sessionRepo     WebhookSessionRepo
+	orderRepo       OrderRepo
+	orderPayments   OrderPaymentService
+	paymentRecorder PaymentRecorder
+	notifier        Notifier
+	logger          log.Logger
+}
+
+func NewWebhook(
+	secret string,
+	sessionRepo WebhookSessionRepo,
+	orderRepo OrderRepo,
+	orderPayments OrderPaymentService,
+	paymentRecorder PaymentRecorder,
+	notifier Notifier,
+	logger log.Logger,
+) Webhook {
+	return Webhook{
+		secret:          secret,
+		sessionRepo:     sessionRepo,
+		orderRepo:       orderRepo,
+		orderPayments:   orderPayments,
+		paymentRecorder: paymentRecorder,
+		notifier:        notifier,
+		logger:          logger,
+	}
+}
+
+// Handle applies the gateway event to the order,
+// the repeated events and the events of the orders not waiting for payment are acknowledged without changes
+func (s Webhook) Handle(ctx context.Context, body []byte, signature string) error {
+	// the webhook is rejected while the gateway secret isn't configured
+	if s.secret == "" || !VerifySignature(s.secret, body, signature) {
+		return domain.ErrInvalidWebhookSignature
+	}
+	var event WebhookEvent
+	err := json.Unmarshal(body, &event)
+	if err != nil {
+		return errors.WithMessage(err, "unmarshal webhook event")
+	}
+	session, err := s.sessionRepo.GetPaymentSession(ctx, event.Object.Id)
+	if err != nil {
+		return errors.WithMessage(err, "get payment session")
+	}
+
+	result := &entity.PaymentResult{
+		Amount:                  event.Object.Amount.Value,
+		Currency:                event.Object.Amount.Currency,
+		ProviderPaymentChargeId: session.Id,
+		Payload:                 string(body),
+	}
+	switch event.Event {
+	case EventPaymentSucceeded:
+		if event.Object.Amount.Value != session.Amount {
+			s.logger.Error(ctx, "payment amount mismatch",
+				log.String("paymentId", session.Id),
+				log.String("orderId", session.OrderId),
+				log.Any("expected", session.Amount),
+				log.Any("actual", event.Object.Amount.Value),
+			)
+			// the charge doesn't pay the order, the reconciliation reports it to be refunded
+			err = s.paymentRecorder.RecordUnappliedPayment(ctx, session.OrderId, *result)
+			if err != nil {
+				return errors.WithMessage(err, "record unapplied payment")
+			}
+			return nil
+		}
+		return s.changeStatus(ctx, session, result, entity.OrderItemStatusPaid, paidReason)
+	case EventPaymentCanceled:
+		return s.changeStatus(ctx, session, result, entity.OrderItemStatusCanceled, canceledReason)
+	default:
+		return nil
+	}
+}
+
+func (s Webhook) changeStatus(
+	ctx context.Context,
+	session entity.PaymentSession,
+	result *entity.PaymentResult,
+	to string,
+	reason string,
+) error {
+	change := entity.OrderStatusChange{
+		OrderId: session.OrderId,
+		From:    entity.OrderItemStatusProcess,
+		To:      to,
+		Actor:   entity.OrderActorSystem,
+		Reason:  reason,
+	}
+	payment := entity.OrderPayment{
+		ChargeId: session.Id,
+		Result:   *result,
+	}
+	var err error
+	if to == entity.OrderItemStatusPaid {
+		err = s.orderPayments.Pay(ctx, change, payment)
+	} else {
+		err = s.orderPayments.Cancel(ctx, change, payment)
+	}
+	switch {
+	case errors.Is(err, domain.ErrOrderNotFound):
+		// the order transaction was rolled back after the payment had been created
+		return nil
+	case errors.Is(err, domain.ErrOrderStatusConflict):
+		if to != entity.OrderItemStatusPaid {
+			return nil
+		}
+		s.logger.Warn(ctx, "payment succeeded for the order not waiting for payment",
+			log.String("paymentId", session.Id),
+			log.String("orderId", session.OrderId),
+		)
+		// the repeated event of the paid order is skipped by the payment id
+		err = s.paymentRecorder.RecordUnappliedPayment(ctx, session.OrderId, *result)
+		if err != nil {
+			return errors.WithMessage(err, "record unapplied payment")
+		}
+		return nil
+	case err != nil:
+		return errors.WithMessage(err, "change order status")
+	}
+
+	// the status is already committed, the repeated event wouldn't deliver the notification anyway
+	err = s.notify(ctx, session.OrderId, to)
+	if err != nil {
+		s.logger.Warn(ctx, "notify order payment result",
+			log.String("orderId", session.OrderId),
+			log.Error(err),
+		)
+	}
+	return nil
+}
+
+func (s Webhook) notify(ctx context.Context, orderId string, status string) error {
+	if status == entity.OrderItemStatusCanceled {
+		err := s.notifier.NotifyPaymentRejected(ctx, orderId, canceledReason)
+		if err != nil {
+			return errors.WithMessage(err, "notify payment rejected")
+		}
+		return nil
+	}
+	order, err := s.orderRepo.GetOrder(ctx, orderId)
+	if err != nil {
+		return errors.WithMessage(err, "get order")
+	}
+	err = s.notifier.NotifySuccessPayment(ctx, order)
+	if err != nil {
+		return errors.WithMessage(err, "notify success payment")
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package invoice
+
+import (
+	"context"
+	"time"
+
+	"github.com/Falokut/go-kit/json"
+	"github.com/pkg/errors"
+	"github.com/txix-open/bgjob"
+)
+
+type InvoiceWorker interface {
+	DeleteInvoice(ctx context.Context, req *DeletionPayload) error
+}
+
+type WorkerController struct {
+	worker InvoiceWorker
+}
+
+func NewWorkerController(worker InvoiceWorker) WorkerController {
+	return WorkerController{
+		worker: worker,
+	}
+}
+
+const (
+	retryTime   = time.Minute
+	maxAttempts = 10
+)
+
+//nolint:gocritic
+func (c WorkerController) Handle(ctx context.Context, job bgjob.Job) bgjob.Result {
+	var payload DeletionPayload
+	err := json.Unmarshal(job.Arg, &payload)
+	if err != nil {
+		return bgjob.MoveToDlq(errors.WithMessage(err, "unmarshal payload"))
+	}
+
+	err = c.worker.DeleteInvoice(ctx, &payload)
+	if err != nil {
+		// the invoice message can't be deleted after 48 hours, there is no use in retrying forever
+		if job.Attempt >= maxAttempts {
+			return bgjob.MoveToDlq(err)
+		}
+		return bgjob.Retry(retryTime, err)
+	}
+
+	return bgjob.Complete()
+}
@@ -0,0 +1,5 @@
+package invoice
+
+type DeletionPayload struct {
+	OrderId string
+}
@@ -0,0 +1,48 @@
+package invoice
+
+import (
+	"context"
+
+	"dishes-service-backend/service"
+
+	"github.com/Falokut/go-kit/json"
+	"github.com/pkg/errors"
+	"github.com/txix-open/bgjob"
+)
+
+const (
+	WorkerQueue = "invoice-cleanup"
+	WorkerType  = "delete"
+)
+
+type Scheduler struct{}
+
+func NewScheduler() Scheduler {
+	return Scheduler{}
+}
+
+// ScheduleInvoiceDeletion enqueues the deletion of the order invoice message and the cancellation
+// of the open payment within the transaction of the order cancellation
+func (s Scheduler) ScheduleInvoiceDeletion(ctx context.Context, tx service.JobTx, orderId string) error {
+	arg, err := json.Marshal(DeletionPayload{
+		OrderId: orderId,
+	})
+	if err != nil {
+		return errors.WithMessage(err, "marshal payload")
+	}
+	err = tx.EnqueueJob(ctx, bgjob.EnqueueRequest{
+		Id:    jobId(orderId),
+		Queue: WorkerQueue,
+		Type:  WorkerType,
+		Arg:   arg,
+	})
+	if err != nil {
+		return errors.WithMessage(err, "enqueue job")
+	}
+	return nil
+}
+
+// job id must differ from the telegram-payment job id, which is the order id itself
+func jobId(orderId string) string {
+	return WorkerQueue + "_" + orderId
+}
@@ -0,0 +1,58 @@
+package invoice
+
+import (
+	"context"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/pkg/errors"
+)
+
+type InvoiceCleaner interface {
+	DeleteOrderInvoice(ctx context.Context, orderId string) error
+}
+
+type OrderRepo interface {
+	GetOrder(ctx context.Context, orderId string) (*entity.Order, error)
+}
+
+type PaymentCanceler interface {
+	CancelPayment(ctx context.Context, order *entity.Order) error
+}
+
+type Worker struct {
+	orderRepo OrderRepo
+	invoices  InvoiceCleaner
+	payments  PaymentCanceler
+}
+
+func NewWorker(orderRepo OrderRepo, invoices InvoiceCleaner, payments PaymentCanceler) Worker {
+	return Worker{
+		orderRepo: orderRepo,
+		invoices:  invoices,
+		payments:  payments,
+	}
+}
+
+// DeleteInvoice makes the order which doesn't wait for payment anymore unpayable:
+// removes its invoice from the chat and cancels the open payment at the payment method, e.g. the gateway session
+func (w Worker) DeleteInvoice(ctx context.Context, req *DeletionPayload) error {
+	err := w.invoices.DeleteOrderInvoice(ctx, req.OrderId)
+	switch {
+	case errors.Is(err, domain.ErrOrderNotFound):
+		return nil
+	case err != nil:
+		return errors.WithMessage(err, "delete order invoice")
+	}
+
+	order, err := w.orderRepo.GetOrder(ctx, req.OrderId)
+	if err != nil {
+		return errors.WithMessage(err, "get order")
+	}
+	err = w.payments.CancelPayment(ctx, order)
+	if err != nil {
+		return errors.WithMessage(err, "cancel payment")
+	}
+	return nil
+}
@@ -3,12 +3,24 @@ package payment
 import (
 	"dishes-service-backend/repository"
 
+	"dishes-service-backend/service/payment/cash"
+	"dishes-service-backend/service/payment/corporate"
 	telegram_payment "dishes-service-backend/service/payment/telegram"
+	"dishes-service-backend/service/payment/wallet"
 	"github.com/txix-open/bgjob"
 )
 
-func NewPaymentMethods(userRepo repository.User, bgJobCli *bgjob.Client) map[string]PaymentService {
+func NewPaymentMethods(
+	userRepo repository.User,
+	companyRepo repository.Company,
+	walletRepo repository.Wallet,
+	jobRepo repository.Job,
+	bgJobCli *bgjob.Client,
+) map[string]PaymentService {
 	return map[string]PaymentService{
-		telegram_payment.PaymentMethod: telegram_payment.NewPayment(userRepo, bgJobCli),
+		telegram_payment.PaymentMethod: telegram_payment.NewPayment(userRepo, jobRepo, bgJobCli),
+		corporate.PaymentMethod:        corporate.NewPayment(companyRepo, jobRepo, bgJobCli),
+		cash.PaymentMethod:             cash.NewPayment(jobRepo, bgJobCli),
+		wallet.PaymentMethod:           wallet.NewPayment(walletRepo, jobRepo, bgJobCli),
 	}
 }
@@ -12,11 +12,25 @@ import (
 )
 
 type PaymentService interface {
-	Process(ctx context.Context, order *entity.Order) (string, error)
+	Process(ctx context.Context, order *entity.Order) (entity.PaymentInvoice, error)
+	Cancel(ctx context.Context, order *entity.Order) error
+}
+
+// PaidOnReceiptService is implemented by the payment methods paid when the order is received,
+// their orders don't expire while waiting for payment
+type PaidOnReceiptService interface {
+	IsPaidOnReceipt() bool
+}
+
+// CheckoutService is implemented by the payment methods paid on the page of the provider,
+// the payment is created there after the order transaction is committed
+type CheckoutService interface {
+	Checkout(ctx context.Context, order *entity.Order) (string, error)
 }
 
 type ExpirationService interface {
 	AddOrder(ctx context.Context, orderId string) error
+	RemoveOrder(ctx context.Context, orderId string) error
 }
 
 type Payment struct {
@@ -35,21 +49,68 @@ func NewPayment(
 	}
 }
 
-func (s Payment) Process(ctx context.Context, order *entity.Order, method string) (string, error) {
+// Process requests the payment of the order with the method,
+// the returned invoice is recorded as the pending payment
+func (s Payment) Process(ctx context.Context, order *entity.Order, method string) (entity.PaymentInvoice, error) {
 	paymentService, ok := s.paymentMethods[method]
 	if !ok {
-		return "", domain.ErrInvalidPaymentMethod
+		return entity.PaymentInvoice{}, domain.ErrInvalidPaymentMethod
+	}
+	if !isPaidOnReceipt(paymentService) {
+		err := s.expiration.AddOrder(ctx, order.Id)
+		if err != nil {
+			return entity.PaymentInvoice{}, errors.WithMessage(err, "add order to expiration")
+		}
+	}
+
+	invoice, err := paymentService.Process(ctx, order)
+	if err != nil {
+		return entity.PaymentInvoice{}, errors.WithMessage(err, "process payment")
 	}
-	err := s.expiration.AddOrder(ctx, order.Id)
+
+	return invoice, nil
+}
+
+// RemoveExpiration drops the expiration of the order which doesn't wait for payment anymore,
+// its open payment is canceled by the invoice cleanup job
+func (s Payment) RemoveExpiration(ctx context.Context, orderId string) error {
+	err := s.expiration.RemoveOrder(ctx, orderId)
 	if err != nil {
-		return "", errors.WithMessage(err, "add order to expiration")
+		return errors.WithMessage(err, "remove order from expiration")
 	}
+	return nil
+}
 
-	url, err := paymentService.Process(ctx, order)
+// CancelPayment cancels the open payment of the order at its payment method
+func (s Payment) CancelPayment(ctx context.Context, order *entity.Order) error {
+	paymentService, ok := s.paymentMethods[order.PaymentMethod]
+	if !ok {
+		return domain.ErrInvalidPaymentMethod
+	}
+	err := paymentService.Cancel(ctx, order)
 	if err != nil {
-		return "", errors.WithMessage(err, "process payment")
+		return errors.WithMessage(err, "cancel payment")
 	}
+	return nil
+}
+
+// HasCheckout reports whether the payment of the method is created by Checkout
+func (s Payment) HasCheckout(method string) bool {
+	_, ok := s.paymentMethods[method].(CheckoutService)
+	return ok
+}
 
+// Checkout creates the payment of the committed order at the provider and returns its url,
+// the url is empty for the methods without the checkout
+func (s Payment) Checkout(ctx context.Context, order *entity.Order) (string, error) {
+	checkout, ok := s.paymentMethods[order.PaymentMethod].(CheckoutService)
+	if !ok {
+		return "", nil
+	}
+	url, err := checkout.Checkout(ctx, order)
+	if err != nil {
+		return "", errors.WithMessage(err, "checkout")
+	}
 	return url, nil
 }
 
@@ -57,3 +118,8 @@ func (s Payment) IsPaymentMethodValid(method string) bool {
 	_, ok := s.paymentMethods[method]
 	return ok
 }
+
+func isPaidOnReceipt(paymentService PaymentService) bool {
+	service, ok := paymentService.(PaidOnReceiptService)
+	return ok && service.IsPaidOnReceipt()
+}
@@ -0,0 +1,36 @@
+package reconciliation
+
+import (
+	"context"
+	"time"
+
+	"github.com/txix-open/bgjob"
+)
+
+type ReportWorker interface {
+	SendDailyReport(ctx context.Context, now time.Time) error
+}
+
+type WorkerController struct {
+	worker    ReportWorker
+	scheduler Scheduler
+}
+
+func NewWorkerController(worker ReportWorker, scheduler Scheduler) WorkerController {
+	return WorkerController{
+		worker:    worker,
+		scheduler: scheduler,
+	}
+}
+
+const defaultRetryTime = time.Minute * 5
+
+//nolint:gocritic
+func (c WorkerController) Handle(ctx context.Context, job bgjob.Job) bgjob.Result {
+	now := time.Now().UTC()
+	err := c.worker.SendDailyReport(ctx, now)
+	if err != nil {
+		return bgjob.Retry(defaultRetryTime, err)
+	}
+	return bgjob.Reschedule(c.scheduler.NextReportDelay(now))
+}
@@ -0,0 +1,8 @@
+package reconciliation
+
+const (
+	WorkerQueue = "payment-reconciliation"
+	WorkerType  = "daily-report"
+	// the single job reschedules itself after each report
+	jobId = "daily-report"
+)
@@ -0,0 +1,47 @@
+package reconciliation
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/txix-open/bgjob"
+)
+
+type Scheduler struct {
+	cli        *bgjob.Client
+	reportHour int
+}
+
+func NewScheduler(cli *bgjob.Client, reportHourUtc int) Scheduler {
+	return Scheduler{
+		cli:        cli,
+		reportHour: reportHourUtc,
+	}
+}
+
+// Schedule enqueues the daily report job if it isn't enqueued yet
+func (s Scheduler) Schedule(ctx context.Context) error {
+	err := s.cli.Enqueue(ctx, bgjob.EnqueueRequest{
+		Id:    jobId,
+		Queue: WorkerQueue,
+		Type:  WorkerType,
+		Delay: s.NextReportDelay(time.Now().UTC()),
+	})
+	if errors.Is(err, bgjob.ErrJobAlreadyExist) {
+		return nil
+	}
+	if err != nil {
+		return errors.WithMessage(err, "enqueue job")
+	}
+	return nil
+}
+
+// NextReportDelay returns the time left until the next report hour
+func (s Scheduler) NextReportDelay(now time.Time) time.Duration {
+	next := time.Date(now.Year(), now.Month(), now.Day(), s.reportHour, 0, 0, 0, time.UTC)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next.Sub(now)
+}
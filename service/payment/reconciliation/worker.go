@@ -0,0 +1,51 @@
+package reconciliation
+
+import (
+	"context"
+	"time"
+
+	"dishes-service-backend/entity"
+
+	"github.com/pkg/errors"
+)
+
+type Repo interface {
+	GetPaymentDiscrepancies(ctx context.Context, from time.Time, to time.Time) ([]entity.PaymentDiscrepancy, error)
+}
+
+type Notifier interface {
+	NotifyPaymentDiscrepancies(
+		ctx context.Context,
+		from time.Time,
+		to time.Time,
+		discrepancies []entity.PaymentDiscrepancy,
+	) error
+}
+
+const reportPeriod = 24 * time.Hour
+
+type Worker struct {
+	repo     Repo
+	notifier Notifier
+}
+
+func NewWorker(repo Repo, notifier Notifier) Worker {
+	return Worker{
+		repo:     repo,
+		notifier: notifier,
+	}
+}
+
+// SendDailyReport sends the admins the discrepancies found for the last day
+func (w Worker) SendDailyReport(ctx context.Context, now time.Time) error {
+	from := now.Add(-reportPeriod)
+	discrepancies, err := w.repo.GetPaymentDiscrepancies(ctx, from, now)
+	if err != nil {
+		return errors.WithMessage(err, "get payment discrepancies")
+	}
+	err = w.notifier.NotifyPaymentDiscrepancies(ctx, from, now, discrepancies)
+	if err != nil {
+		return errors.WithMessage(err, "notify payment discrepancies")
+	}
+	return nil
+}
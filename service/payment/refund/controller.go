@@ -0,0 +1,43 @@
+package refund
+
+import (
+	"context"
+	"time"
+
+	"github.com/Falokut/go-kit/json"
+	"github.com/pkg/errors"
+	"github.com/txix-open/bgjob"
+)
+
+type RefundWorker interface {
+	ProcessRefund(ctx context.Context, req *RefundPayload, attempt int32) error
+}
+
+type WorkerController struct {
+	worker RefundWorker
+}
+
+func NewWorkerController(worker RefundWorker) WorkerController {
+	return WorkerController{
+		worker: worker,
+	}
+}
+
+// the delay grows with every attempt to ride out the provider outages
+const retryTimeStep = time.Minute
+
+//nolint:gocritic
+func (c WorkerController) Handle(ctx context.Context, job bgjob.Job) bgjob.Result {
+	var payload RefundPayload
+	err := json.Unmarshal(job.Arg, &payload)
+	if err != nil {
+		return bgjob.MoveToDlq(errors.WithMessage(err, "unmarshal payload"))
+	}
+
+	err = c.worker.ProcessRefund(ctx, &payload, job.Attempt)
+	if err != nil {
+		return bgjob.Retry(retryTimeStep*time.Duration(job.Attempt), err)
+	}
+
+	return bgjob.Complete()
+}
@@ -0,0 +1,8 @@
+package refund
+
+type RefundPayload struct {
+	OrderId  string
+	RefundId string
+	Amount   int32
+	Partial  bool
+}
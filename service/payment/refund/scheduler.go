@@ -0,0 +1,66 @@
+package refund
+
+import (
+	"context"
+
+	"dishes-service-backend/entity"
+	"dishes-service-backend/service"
+
+	"github.com/Falokut/go-kit/json"
+	"github.com/pkg/errors"
+	"github.com/txix-open/bgjob"
+)
+
+const (
+	WorkerQueue = "payment-refund"
+	WorkerType  = "refund"
+)
+
+type Scheduler struct {
+	refunders map[string]Refunder
+}
+
+func NewScheduler(refunders map[string]Refunder) Scheduler {
+	return Scheduler{
+		refunders: refunders,
+	}
+}
+
+// ScheduleRefund enqueues the refund within the transaction of the order cancellation
+// if the payment method of the order refunds by itself
+func (s Scheduler) ScheduleRefund(
+	ctx context.Context,
+	tx service.JobTx,
+	order *entity.Order,
+	refund entity.OrderRefund,
+) (bool, error) {
+	refunder, ok := s.refunders[order.PaymentMethod]
+	if !ok || !refunder.CanRefund(order, refund) {
+		return false, nil
+	}
+	arg, err := json.Marshal(RefundPayload{
+		OrderId:  order.Id,
+		RefundId: refund.Id,
+		Amount:   refund.Amount,
+		Partial:  refund.Partial,
+	})
+	if err != nil {
+		return false, errors.WithMessage(err, "marshal payload")
+	}
+	err = tx.EnqueueJob(ctx, bgjob.EnqueueRequest{
+		Id:    jobId(refund.Id),
+		Queue: WorkerQueue,
+		Type:  WorkerType,
+		Arg:   arg,
+	})
+	if err != nil {
+		return false, errors.WithMessage(err, "enqueue job")
+	}
+	return true, nil
+}
+
+// job id must differ from the job ids of the other queues,
+// which use the order id as the refund id of the whole order does
+func jobId(refundId string) string {
+	return WorkerQueue + "_" + refundId
+}
@@ -0,0 +1,116 @@
+package refund
+
+import (
+	"context"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/Falokut/go-kit/log"
+	"github.com/pkg/errors"
+)
+
+type Refunder interface {
+	// CanRefund reports whether the method can return the refund,
+	// otherwise it is returned to the user wallet
+	CanRefund(order *entity.Order, refund entity.OrderRefund) bool
+	// Refund returns the refund amount to the payer, the repeated call with the same refund mustn't refund twice
+	Refund(ctx context.Context, order *entity.Order, refund entity.OrderRefund) error
+}
+
+type OrderRepo interface {
+	GetOrder(ctx context.Context, orderId string) (*entity.Order, error)
+	SetOrderRefundStatus(ctx context.Context, orderId string, status string) error
+}
+
+type Notifier interface {
+	NotifyOrderRefunded(ctx context.Context, order *entity.Order, amount int32) error
+	NotifyRefundFailed(ctx context.Context, order *entity.Order, amount int32) error
+}
+
+// the refund is marked as failed after this number of attempts and left to the admins
+const maxRefundAttempts = 10
+
+type Worker struct {
+	orderRepo OrderRepo
+	refunders map[string]Refunder
+	notifier  Notifier
+	logger    log.Logger
+}
+
+func NewWorker(orderRepo OrderRepo, refunders map[string]Refunder, notifier Notifier, logger log.Logger) Worker {
+	return Worker{
+		orderRepo: orderRepo,
+		refunders: refunders,
+		notifier:  notifier,
+		logger:    logger,
+	}
+}
+
+func (w Worker) ProcessRefund(ctx context.Context, req *RefundPayload, attempt int32) error {
+	order, err := w.orderRepo.GetOrder(ctx, req.OrderId)
+	switch {
+	case errors.Is(err, domain.ErrOrderNotFound):
+		return nil
+	case err != nil:
+		return errors.WithMessage(err, "get order")
+	}
+	// the job is enqueued with the cancellation, so the refund is pending unless it's already done,
+	// the refunds of the canceled items don't change the refund status of the order
+	if !req.Partial && order.RefundStatus != entity.RefundStatusPending {
+		return nil
+	}
+
+	refund := entity.OrderRefund{
+		Id:      req.RefundId,
+		Amount:  req.Amount,
+		Partial: req.Partial,
+	}
+	refundErr := w.refund(ctx, order, refund)
+	if refundErr != nil && attempt < maxRefundAttempts {
+		return errors.WithMessage(refundErr, "refund")
+	}
+	if refundErr != nil {
+		w.logger.Error(ctx, "refund failed",
+			log.String("orderId", order.Id),
+			log.String("refundId", refund.Id),
+			log.Error(refundErr),
+		)
+	}
+
+	if !refund.Partial {
+		status := entity.RefundStatusRefunded
+		if refundErr != nil {
+			status = entity.RefundStatusFailed
+		}
+		err = w.orderRepo.SetOrderRefundStatus(ctx, order.Id, status)
+		if err != nil {
+			return errors.WithMessage(err, "set order refund status")
+		}
+	}
+
+	// the refund is already done, the retry wouldn't deliver the notification anyway
+	err = w.notify(ctx, order, refund, refundErr)
+	if err != nil {
+		w.logger.Warn(ctx, "notify refund result",
+			log.String("orderId", order.Id),
+			log.Error(err),
+		)
+	}
+	return nil
+}
+
+func (w Worker) refund(ctx context.Context, order *entity.Order, refund entity.OrderRefund) error {
+	refunder, ok := w.refunders[order.PaymentMethod]
+	if !ok {
+		return errors.Errorf("payment method %s doesn't support refunds", order.PaymentMethod)
+	}
+	return refunder.Refund(ctx, order, refund)
+}
+
+func (w Worker) notify(ctx context.Context, order *entity.Order, refund entity.OrderRefund, refundErr error) error {
+	if refundErr != nil {
+		return w.notifier.NotifyRefundFailed(ctx, order, refund.Amount)
+	}
+	return w.notifier.NotifyOrderRefunded(ctx, order, refund.Amount)
+}
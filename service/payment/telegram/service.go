@@ -13,14 +13,20 @@ type UserRepo interface {
 	GetUserChatId(ctx context.Context, userId string) (int64, error)
 }
 
+type JobRepo interface {
+	DeleteJob(ctx context.Context, queue string, jobId string) error
+}
+
 type Payment struct {
 	userRepo UserRepo
+	jobRepo  JobRepo
 	cli      *bgjob.Client
 }
 
-func NewPayment(userRepo UserRepo, cli *bgjob.Client) Payment {
+func NewPayment(userRepo UserRepo, jobRepo JobRepo, cli *bgjob.Client) Payment {
 	return Payment{
 		userRepo: userRepo,
+		jobRepo:  jobRepo,
 		cli:      cli,
 	}
 }
@@ -31,10 +37,10 @@ const (
 	WorkerType  = "payment"
 )
 
-func (s Payment) Process(ctx context.Context, order *entity.Order) (string, error) {
+func (s Payment) Process(ctx context.Context, order *entity.Order) (entity.PaymentInvoice, error) {
 	chatId, err := s.userRepo.GetUserChatId(ctx, order.UserId)
 	if err != nil {
-		return "", errors.WithMessage(err, "get user chat id")
+		return entity.PaymentInvoice{}, errors.WithMessage(err, "get user chat id")
 	}
 
 	payload := PaymentPayload{
@@ -44,7 +50,7 @@ func (s Payment) Process(ctx context.Context, order *entity.Order) (string, erro
 
 	arg, err := json.Marshal(payload)
 	if err != nil {
-		return "", errors.WithMessage(err, "marshal payload")
+		return entity.PaymentInvoice{}, errors.WithMessage(err, "marshal payload")
 	}
 
 	err = s.cli.Enqueue(ctx, bgjob.EnqueueRequest{
@@ -56,7 +62,15 @@ func (s Payment) Process(ctx context.Context, order *entity.Order) (string, erro
 	)
 
 	if err != nil {
-		return "", errors.WithMessage(err, "enqueue job")
+		return entity.PaymentInvoice{}, errors.WithMessage(err, "enqueue job")
+	}
+	return entity.RubInvoice(order, ""), nil
+}
+
+func (s Payment) Cancel(ctx context.Context, order *entity.Order) error {
+	err := s.jobRepo.DeleteJob(ctx, WorkerQueue, order.Id)
+	if err != nil {
+		return errors.WithMessage(err, "delete job")
 	}
-	return "", nil
+	return nil
 }
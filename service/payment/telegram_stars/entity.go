@@ -0,0 +1,12 @@
+package telegram_stars
+
+import (
+	"dishes-service-backend/entity"
+)
+
+type PaymentPayload struct {
+	Order  entity.Order
+	ChatId int64
+	// rate of the invoiced amount, zero for the jobs enqueued without it
+	Rate entity.StarsRate
+}
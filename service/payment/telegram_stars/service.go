@@ -0,0 +1,126 @@
+package telegram_stars
+
+import (
+	"context"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/Falokut/go-kit/json"
+	"github.com/pkg/errors"
+	"github.com/txix-open/bgjob"
+)
+
+type UserRepo interface {
+	GetUserChatId(ctx context.Context, userId string) (int64, error)
+	GetUserTelegramId(ctx context.Context, userId string) (int64, error)
+}
+
+type JobRepo interface {
+	DeleteJob(ctx context.Context, queue string, jobId string) error
+}
+
+type StarsRateRepo interface {
+	GetStarsRate(ctx context.Context) (entity.StarsRate, error)
+}
+
+type StarsRefunder interface {
+	RefundStarPayment(ctx context.Context, telegramId int64, chargeId string) error
+}
+
+type Payment struct {
+	userRepo UserRepo
+	jobRepo  JobRepo
+	rateRepo StarsRateRepo
+	refunder StarsRefunder
+	cli      *bgjob.Client
+}
+
+func NewPayment(
+	userRepo UserRepo,
+	jobRepo JobRepo,
+	rateRepo StarsRateRepo,
+	refunder StarsRefunder,
+	cli *bgjob.Client,
+) Payment {
+	return Payment{
+		userRepo: userRepo,
+		jobRepo:  jobRepo,
+		rateRepo: rateRepo,
+		refunder: refunder,
+		cli:      cli,
+	}
+}
+
+const PaymentMethod string = "telegram_stars"
+const (
+	WorkerQueue = "telegram-stars-payment"
+	WorkerType  = "payment"
+)
+
+// Process enqueues the invoice in stars at the current rate,
+// the rate is passed to the worker, so the invoice matches the pending payment
+func (s Payment) Process(ctx context.Context, order *entity.Order) (entity.PaymentInvoice, error) {
+	rate, err := s.rateRepo.GetStarsRate(ctx)
+	if err != nil {
+		return entity.PaymentInvoice{}, errors.WithMessage(err, "get stars rate")
+	}
+	if rate.StarPrice <= 0 {
+		return entity.PaymentInvoice{}, domain.ErrStarsRateNotSet
+	}
+
+	chatId, err := s.userRepo.GetUserChatId(ctx, order.UserId)
+	if err != nil {
+		return entity.PaymentInvoice{}, errors.WithMessage(err, "get user chat id")
+	}
+
+	arg, err := json.Marshal(PaymentPayload{
+		Order:  *order,
+		ChatId: chatId,
+		Rate:   rate,
+	})
+	if err != nil {
+		return entity.PaymentInvoice{}, errors.WithMessage(err, "marshal payload")
+	}
+
+	err = s.cli.Enqueue(ctx, bgjob.EnqueueRequest{
+		Id:    order.Id,
+		Queue: WorkerQueue,
+		Type:  WorkerType,
+		Arg:   arg,
+	})
+	if err != nil {
+		return entity.PaymentInvoice{}, errors.WithMessage(err, "enqueue job")
+	}
+	return entity.PaymentInvoice{
+		Amount:   int64(rate.StarsAmount(order.Total)),
+		Currency: entity.CurrencyStars,
+	}, nil
+}
+
+func (s Payment) Cancel(ctx context.Context, order *entity.Order) error {
+	err := s.jobRepo.DeleteJob(ctx, WorkerQueue, order.Id)
+	if err != nil {
+		return errors.WithMessage(err, "delete job")
+	}
+	return nil
+}
+
+// CanRefund reports whether the whole payment can be returned,
+// telegram refunds the star payment only in full, so the canceled items and the partly refunded orders go to the wallet
+func (s Payment) CanRefund(order *entity.Order, refund entity.OrderRefund) bool {
+	return !refund.Partial && order.Refunded == 0 && order.PaymentChargeId != ""
+}
+
+// Refund returns the paid stars to the user
+func (s Payment) Refund(ctx context.Context, order *entity.Order, refund entity.OrderRefund) error {
+	telegramId, err := s.userRepo.GetUserTelegramId(ctx, order.UserId)
+	if err != nil {
+		return errors.WithMessage(err, "get user telegram id")
+	}
+	err = s.refunder.RefundStarPayment(ctx, telegramId, order.PaymentChargeId)
+	if err != nil {
+		return errors.WithMessage(err, "refund star payment")
+	}
+	return nil
+}
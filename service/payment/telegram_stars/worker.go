@@ -0,0 +1,42 @@
+package telegram_stars
+
+import (
+	"context"
+
+	"dishes-service-backend/entity"
+
+	"github.com/pkg/errors"
+)
+
+type PaymentBot interface {
+	ProcessStarsPayment(ctx context.Context, order *entity.Order, chatId int64, rate entity.StarsRate) error
+}
+
+type Worker struct {
+	bot      PaymentBot
+	rateRepo StarsRateRepo
+}
+
+func NewWorker(bot PaymentBot, rateRepo StarsRateRepo) Worker {
+	return Worker{
+		bot:      bot,
+		rateRepo: rateRepo,
+	}
+}
+
+func (w Worker) ProcessPayment(ctx context.Context, req *PaymentPayload) error {
+	// the invoice uses the rate of the pending payment, the jobs enqueued without it use the current one
+	rate := req.Rate
+	if rate.StarPrice <= 0 {
+		var err error
+		rate, err = w.rateRepo.GetStarsRate(ctx)
+		if err != nil {
+			return errors.WithMessage(err, "get stars rate")
+		}
+	}
+	err := w.bot.ProcessStarsPayment(ctx, &req.Order, req.ChatId, rate)
+	if err != nil {
+		return errors.WithMessage(err, "process stars payment")
+	}
+	return nil
+}
@@ -0,0 +1,5 @@
+package wallet
+
+type PaymentPayload struct {
+	OrderId string
+}
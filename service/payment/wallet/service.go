@@ -0,0 +1,77 @@
+package wallet
+
+import (
+	"context"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/Falokut/go-kit/json"
+	"github.com/pkg/errors"
+	"github.com/txix-open/bgjob"
+)
+
+type WalletRepo interface {
+	GetWallet(ctx context.Context, userId string) (entity.Wallet, error)
+}
+
+type JobRepo interface {
+	DeleteJob(ctx context.Context, queue string, jobId string) error
+}
+
+type Payment struct {
+	walletRepo WalletRepo
+	jobRepo    JobRepo
+	cli        *bgjob.Client
+}
+
+func NewPayment(walletRepo WalletRepo, jobRepo JobRepo, cli *bgjob.Client) Payment {
+	return Payment{
+		walletRepo: walletRepo,
+		jobRepo:    jobRepo,
+		cli:        cli,
+	}
+}
+
+const PaymentMethod string = "wallet"
+const (
+	WorkerQueue = "wallet-payment"
+	WorkerType  = "payment"
+)
+
+// Process checks the wallet balance and enqueues the charge,
+// the balance is checked again by the worker under the wallet lock
+func (s Payment) Process(ctx context.Context, order *entity.Order) (entity.PaymentInvoice, error) {
+	wallet, err := s.walletRepo.GetWallet(ctx, order.UserId)
+	if err != nil {
+		return entity.PaymentInvoice{}, errors.WithMessage(err, "get wallet")
+	}
+	if wallet.Balance < int64(order.Total) {
+		return entity.PaymentInvoice{}, domain.ErrInsufficientFunds
+	}
+
+	arg, err := json.Marshal(PaymentPayload{
+		OrderId: order.Id,
+	})
+	if err != nil {
+		return entity.PaymentInvoice{}, errors.WithMessage(err, "marshal payload")
+	}
+	err = s.cli.Enqueue(ctx, bgjob.EnqueueRequest{
+		Id:    order.Id,
+		Queue: WorkerQueue,
+		Type:  WorkerType,
+		Arg:   arg,
+	})
+	if err != nil {
+		return entity.PaymentInvoice{}, errors.WithMessage(err, "enqueue job")
+	}
+	return entity.RubInvoice(order, ""), nil
+}
+
+func (s Payment) Cancel(ctx context.Context, order *entity.Order) error {
+	err := s.jobRepo.DeleteJob(ctx, WorkerQueue, order.Id)
+	if err != nil {
+		return errors.WithMessage(err, "delete job")
+	}
+	return nil
+}
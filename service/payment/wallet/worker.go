@@ -0,0 +1,131 @@
+package wallet
+
+import (
+	"context"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+	"dishes-service-backend/service"
+
+	"github.com/pkg/errors"
+)
+
+type TxRunner interface {
+	OrderPaymentTx(ctx context.Context, tx func(ctx context.Context, tx service.OrderPaymentTx) error) error
+}
+
+type OrderPaymentService interface {
+	PayTx(ctx context.Context, tx service.OrderPaymentTx, req entity.OrderStatusChange, payment entity.OrderPayment) error
+	CancelTx(ctx context.Context, tx service.OrderPaymentTx, req entity.OrderStatusChange, payment entity.OrderPayment) error
+}
+
+type WalletService interface {
+	ChargeOrderTx(ctx context.Context, tx service.WalletTx, order *entity.Order, actor string) error
+}
+
+type Notifier interface {
+	NotifySuccessPayment(ctx context.Context, order *entity.Order) error
+	NotifyPaymentRejected(ctx context.Context, orderId string, reason string) error
+}
+
+type Worker struct {
+	txRunner      TxRunner
+	orderPayments OrderPaymentService
+	walletService WalletService
+	notifier      Notifier
+}
+
+func NewWorker(
+	txRunner TxRunner,
+	orderPayments OrderPaymentService,
+	walletService WalletService,
+	notifier Notifier,
+) Worker {
+	return Worker{
+		txRunner:      txRunner,
+		orderPayments: orderPayments,
+		walletService: walletService,
+		notifier:      notifier,
+	}
+}
+
+func (w Worker) ProcessPayment(ctx context.Context, req *PaymentPayload) error {
+	var order *entity.Order
+	var paid bool
+	err := w.txRunner.OrderPaymentTx(ctx, func(ctx context.Context, tx service.OrderPaymentTx) error {
+		var err error
+		order, paid, err = w.charge(ctx, tx, req)
+		if err != nil {
+			return errors.WithMessage(err, "charge wallet")
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.WithMessage(err, "order payment tx")
+	}
+
+	switch {
+	case order == nil:
+		// order was already paid, canceled or expired
+		return nil
+	case paid:
+		err = w.notifier.NotifySuccessPayment(ctx, order)
+		if err != nil {
+			return errors.WithMessage(err, "notify success payment")
+		}
+	default:
+		err = w.notifier.NotifyPaymentRejected(ctx, order.Id, domain.ErrInsufficientFunds.Error())
+		if err != nil {
+			return errors.WithMessage(err, "notify payment rejected")
+		}
+	}
+	return nil
+}
+
+// charge moves the order to PAID if the wallet balance allows it, otherwise cancels it,
+// the returned order is nil if it doesn't wait for payment anymore
+func (w Worker) charge(ctx context.Context, tx service.OrderPaymentTx, req *PaymentPayload) (*entity.Order, bool, error) {
+	status, err := tx.GetOrderStatusForUpdate(ctx, req.OrderId)
+	if err != nil {
+		return nil, false, errors.WithMessage(err, "get order status")
+	}
+	if status != entity.OrderItemStatusProcess {
+		return nil, false, nil
+	}
+	order, err := tx.GetOrder(ctx, req.OrderId)
+	if err != nil {
+		return nil, false, errors.WithMessage(err, "get order")
+	}
+
+	err = w.walletService.ChargeOrderTx(ctx, tx, order, entity.OrderActorSystem)
+	switch {
+	case errors.Is(err, domain.ErrInsufficientFunds):
+		err = w.orderPayments.CancelTx(ctx, tx, entity.OrderStatusChange{
+			OrderId: order.Id,
+			From:    entity.OrderItemStatusProcess,
+			To:      entity.OrderItemStatusCanceled,
+			Actor:   entity.OrderActorSystem,
+			Reason:  domain.ErrInsufficientFunds.Error(),
+		}, entity.OrderPayment{})
+		if err != nil {
+			return nil, false, errors.WithMessage(err, "cancel order")
+		}
+		order.Status = entity.OrderItemStatusCanceled
+		return order, false, nil
+	case err != nil:
+		return nil, false, errors.WithMessage(err, "charge order")
+	}
+
+	err = w.orderPayments.PayTx(ctx, tx, entity.OrderStatusChange{
+		OrderId: order.Id,
+		From:    entity.OrderItemStatusProcess,
+		To:      entity.OrderItemStatusPaid,
+		Actor:   entity.OrderActorSystem,
+		Reason:  "оплата из кошелька",
+	}, entity.OrderPayment{})
+	if err != nil {
+		return nil, false, errors.WithMessage(err, "pay order")
+	}
+	order.Status = entity.OrderItemStatusPaid
+	return order, true, nil
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/Falokut/go-kit/http/apierrors"
+	"github.com/pkg/errors"
+)
+
+type PaymentLedgerRepo interface {
+	GetPayments(ctx context.Context, filter entity.PaymentsFilter) ([]entity.Payment, error)
+	CountPayments(ctx context.Context, filter entity.PaymentsFilter) (int64, error)
+	InsertOrderPayment(ctx context.Context, orderId string, status string, result entity.PaymentResult) error
+	GetPaymentDiscrepancies(ctx context.Context, from time.Time, to time.Time) ([]entity.PaymentDiscrepancy, error)
+}
+
+type PaymentLedger struct {
+	repo PaymentLedgerRepo
+}
+
+func NewPaymentLedger(repo PaymentLedgerRepo) PaymentLedger {
+	return PaymentLedger{
+		repo: repo,
+	}
+}
+
+const defaultPaymentsLimit = 50
+
+func (s PaymentLedger) ListPayments(ctx context.Context, req domain.GetPaymentsRequest) (*domain.GetPaymentsResponse, error) {
+	filter := entity.PaymentsFilter{
+		OrderId: req.OrderId,
+		Method:  req.Method,
+		Status:  req.Status,
+		Limit:   req.Limit,
+		Offset:  req.Offset,
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultPaymentsLimit
+	}
+
+	total, err := s.repo.CountPayments(ctx, filter)
+	if err != nil {
+		return nil, errors.WithMessage(err, "count payments")
+	}
+	payments, err := s.repo.GetPayments(ctx, filter)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get payments")
+	}
+
+	resp := &domain.GetPaymentsResponse{
+		Payments: make([]domain.Payment, len(payments)),
+		Total:    total,
+	}
+	for i, payment := range payments {
+		resp.Payments[i] = domain.Payment{
+			Id:                      payment.Id,
+			OrderId:                 payment.OrderId,
+			Method:                  payment.Method,
+			Status:                  payment.Status,
+			Amount:                  payment.Amount,
+			Currency:                payment.Currency,
+			TelegramPaymentChargeId: payment.TelegramPaymentChargeId,
+			ProviderPaymentChargeId: payment.ProviderPaymentChargeId,
+			CreatedAt:               payment.CreatedAt,
+			UpdatedAt:               payment.UpdatedAt,
+		}
+		if payment.Payload != "" {
+			resp.Payments[i].Payload = json.RawMessage(payment.Payload)
+		}
+	}
+	return resp, nil
+}
+
+// RecordUnappliedPayment records the payment which didn't change the order status,
+// e.g. the payment for the expired order, to be found by the reconciliation
+func (s PaymentLedger) RecordUnappliedPayment(ctx context.Context, orderId string, result entity.PaymentResult) error {
+	err := s.repo.InsertOrderPayment(ctx, orderId, entity.PaymentStatusSucceeded, result)
+	if err != nil {
+		return errors.WithMessage(err, "insert order payment")
+	}
+	return nil
+}
+
+const defaultDiscrepanciesPeriod = 24 * time.Hour
+
+func (s PaymentLedger) ListDiscrepancies(
+	ctx context.Context,
+	req domain.GetPaymentDiscrepanciesRequest,
+) (*domain.PaymentDiscrepanciesReport, error) {
+	to := time.Now().UTC()
+	parsedTo, err := parseOptionalTime(req.To)
+	if err != nil {
+		return nil, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument, "invalid to, must be RFC3339", err)
+	}
+	if parsedTo != nil {
+		to = *parsedTo
+	}
+	from := to.Add(-defaultDiscrepanciesPeriod)
+	parsedFrom, err := parseOptionalTime(req.From)
+	if err != nil {
+		return nil, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument, "invalid from, must be RFC3339", err)
+	}
+	if parsedFrom != nil {
+		from = *parsedFrom
+	}
+	if !from.Before(to) {
+		return nil, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument,
+			"invalid period, from must be before to", errors.New("invalid period"))
+	}
+
+	discrepancies, err := s.repo.GetPaymentDiscrepancies(ctx, from, to)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get payment discrepancies")
+	}
+	resp := &domain.PaymentDiscrepanciesReport{
+		From:          from,
+		To:            to,
+		Discrepancies: make([]domain.PaymentDiscrepancy, len(discrepancies)),
+	}
+	for i, discrepancy := range discrepancies {
+		resp.Discrepancies[i] = domain.PaymentDiscrepancy{
+			Kind:                    discrepancy.Kind,
+			OrderId:                 discrepancy.OrderId,
+			OrderStatus:             discrepancy.OrderStatus,
+			PaymentMethod:           discrepancy.PaymentMethod,
+			OrderAmount:             discrepancy.OrderAmount,
+			PaymentId:               discrepancy.PaymentId,
+			PaymentAmount:           discrepancy.PaymentAmount,
+			Currency:                discrepancy.Currency,
+			TelegramPaymentChargeId: discrepancy.TelegramPaymentChargeId,
+			ProviderPaymentChargeId: discrepancy.ProviderPaymentChargeId,
+			CreatedAt:               discrepancy.CreatedAt,
+		}
+	}
+	return resp, nil
+}
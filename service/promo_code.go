@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/Falokut/go-kit/http/apierrors"
+	"github.com/pkg/errors"
+)
+
+type PromoCodeRepo interface {
+	GetPromoCodes(ctx context.Context) ([]entity.PromoCode, error)
+	DeletePromoCode(ctx context.Context, id int32) error
+}
+
+type AddPromoCodeTx interface {
+	InsertPromoCode(ctx context.Context, promoCode entity.PromoCode) (int32, error)
+	InsertPromoCodeRestaurants(ctx context.Context, promoCodeId int32, restaurantIds []int32) error
+	InsertPromoCodeCategories(ctx context.Context, promoCodeId int32, categoryIds []int32) error
+}
+
+type PromoCodeTxRunner interface {
+	AddPromoCodeTx(ctx context.Context, tx func(ctx context.Context, tx AddPromoCodeTx) error) error
+}
+
+// percent discount can't cover the whole order, the invoice total must be positive
+const maxPromoCodePercent = 99
+
+type PromoCode struct {
+	repo     PromoCodeRepo
+	txRunner PromoCodeTxRunner
+}
+
+func NewPromoCode(repo PromoCodeRepo, txRunner PromoCodeTxRunner) PromoCode {
+	return PromoCode{
+		repo:     repo,
+		txRunner: txRunner,
+	}
+}
+
+func (s PromoCode) GetPromoCodes(ctx context.Context) ([]domain.PromoCode, error) {
+	promoCodes, err := s.repo.GetPromoCodes(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get promo codes")
+	}
+
+	domainPromoCodes := make([]domain.PromoCode, len(promoCodes))
+	for i, promoCode := range promoCodes {
+		domainPromoCodes[i] = domain.PromoCode{
+			Id:            promoCode.Id,
+			Code:          promoCode.Code,
+			DiscountType:  promoCode.DiscountType,
+			DiscountValue: promoCode.DiscountValue,
+			ValidFrom:     promoCode.ValidFrom,
+			ValidTo:       promoCode.ValidTo,
+			TotalLimit:    promoCode.TotalLimit,
+			PerUserLimit:  promoCode.PerUserLimit,
+			RestaurantIds: promoCode.RestaurantIds,
+			CategoryIds:   promoCode.CategoryIds,
+			CreatedAt:     promoCode.CreatedAt,
+		}
+	}
+	return domainPromoCodes, nil
+}
+
+func (s PromoCode) AddPromoCode(ctx context.Context, req domain.AddPromoCodeRequest) (int32, error) {
+	if req.DiscountType == entity.PromoCodeDiscountPercent && req.DiscountValue > maxPromoCodePercent {
+		return 0, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument,
+			"процент скидки должен быть от 1 до 99",
+			errors.New("invalid discount percent"),
+		)
+	}
+	validFrom, err := parseOptionalTime(req.ValidFrom)
+	if err != nil {
+		return 0, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument, "invalid validFrom, must be RFC3339", err)
+	}
+	validTo, err := parseOptionalTime(req.ValidTo)
+	if err != nil {
+		return 0, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument, "invalid validTo, must be RFC3339", err)
+	}
+	if validFrom != nil && validTo != nil && !validTo.After(*validFrom) {
+		return 0, apierrors.NewBusinessError(domain.ErrCodeInvalidArgument,
+			"конец действия промокода должен быть позже его начала",
+			errors.New("invalid promo code period"),
+		)
+	}
+
+	promoCode := entity.PromoCode{
+		Code:          normalizePromoCode(req.Code),
+		DiscountType:  req.DiscountType,
+		DiscountValue: req.DiscountValue,
+		ValidFrom:     validFrom,
+		ValidTo:       validTo,
+		TotalLimit:    req.TotalLimit,
+		PerUserLimit:  req.PerUserLimit,
+		CreatedAt:     time.Now().UTC(),
+	}
+	var id int32
+	err = s.txRunner.AddPromoCodeTx(ctx, func(ctx context.Context, tx AddPromoCodeTx) error {
+		id, err = s.addPromoCode(ctx, tx, promoCode, req.RestaurantIds, req.CategoryIds)
+		if err != nil {
+			return errors.WithMessage(err, "add promo code")
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, errors.WithMessage(err, "add promo code tx")
+	}
+	return id, nil
+}
+
+func (s PromoCode) addPromoCode(
+	ctx context.Context,
+	tx AddPromoCodeTx,
+	promoCode entity.PromoCode,
+	restaurantIds []int32,
+	categoryIds []int32,
+) (int32, error) {
+	id, err := tx.InsertPromoCode(ctx, promoCode)
+	if err != nil {
+		return 0, errors.WithMessage(err, "insert promo code")
+	}
+	if len(restaurantIds) > 0 {
+		err = tx.InsertPromoCodeRestaurants(ctx, id, restaurantIds)
+		if err != nil {
+			return 0, errors.WithMessage(err, "insert promo code restaurants")
+		}
+	}
+	if len(categoryIds) > 0 {
+		err = tx.InsertPromoCodeCategories(ctx, id, categoryIds)
+		if err != nil {
+			return 0, errors.WithMessage(err, "insert promo code categories")
+		}
+	}
+	return id, nil
+}
+
+func (s PromoCode) DeletePromoCode(ctx context.Context, id int32) error {
+	err := s.repo.DeletePromoCode(ctx, id)
+	if err != nil {
+		return errors.WithMessage(err, "delete promo code")
+	}
+	return nil
+}
+
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
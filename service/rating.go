@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/pkg/errors"
+)
+
+type RatingRepo interface {
+	GetOrderRating(ctx context.Context, orderId string) (entity.OrderRating, error)
+	GetRestaurantRatings(ctx context.Context, orderId string) ([]entity.RestaurantRating, error)
+	GetLowRatings(ctx context.Context, maxScore int32, limit int32, offset int32) ([]entity.LowRating, error)
+}
+
+type RatingOrderRepo interface {
+	GetOrder(ctx context.Context, orderId string) (*entity.Order, error)
+}
+
+type RateOrderTx interface {
+	UpsertOrderRating(ctx context.Context, orderId string, score int32) error
+	SetOrderRatingComment(ctx context.Context, orderId string, comment string) error
+	UpsertRestaurantRatings(ctx context.Context, orderId string, ratings []entity.RestaurantRating) error
+}
+
+type RatingTxRunner interface {
+	RateOrderTx(ctx context.Context, tx func(ctx context.Context, tx RateOrderTx) error) error
+}
+
+const (
+	defaultLowRatingMaxScore = 2
+	defaultLowRatingsLimit   = 50
+)
+
+type Rating struct {
+	repo      RatingRepo
+	orderRepo RatingOrderRepo
+	txRunner  RatingTxRunner
+}
+
+func NewRating(repo RatingRepo, orderRepo RatingOrderRepo, txRunner RatingTxRunner) Rating {
+	return Rating{
+		repo:      repo,
+		orderRepo: orderRepo,
+		txRunner:  txRunner,
+	}
+}
+
+func (s Rating) RateOrder(ctx context.Context, userId string, req domain.RateOrderRequest) error {
+	order, err := s.getRatedOrder(ctx, userId, req.Id)
+	if err != nil {
+		return errors.WithMessage(err, "get rated order")
+	}
+	restaurantRatings := make([]entity.RestaurantRating, len(req.Restaurants))
+	for i, restaurant := range req.Restaurants {
+		if !orderHasRestaurant(order, restaurant.RestaurantId) {
+			return domain.ErrFulfilmentNotFound
+		}
+		restaurantRatings[i] = entity.RestaurantRating{
+			RestaurantId: restaurant.RestaurantId,
+			Score:        restaurant.Score,
+		}
+	}
+
+	err = s.txRunner.RateOrderTx(ctx, func(ctx context.Context, tx RateOrderTx) error {
+		err := tx.UpsertOrderRating(ctx, order.Id, req.Score)
+		if err != nil {
+			return errors.WithMessage(err, "upsert order rating")
+		}
+		if req.Comment != "" {
+			err = tx.SetOrderRatingComment(ctx, order.Id, req.Comment)
+			if err != nil {
+				return errors.WithMessage(err, "set order rating comment")
+			}
+		}
+		if len(restaurantRatings) == 0 {
+			return nil
+		}
+		err = tx.UpsertRestaurantRatings(ctx, order.Id, restaurantRatings)
+		if err != nil {
+			return errors.WithMessage(err, "upsert restaurant ratings")
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.WithMessage(err, "rate order tx")
+	}
+	return nil
+}
+
+// RateRestaurant rates a single restaurant of the order, used by the bot
+func (s Rating) RateRestaurant(ctx context.Context, userId string, orderId string, restaurantId int32, score int32) error {
+	order, err := s.getRatedOrder(ctx, userId, orderId)
+	if err != nil {
+		return errors.WithMessage(err, "get rated order")
+	}
+	if !orderHasRestaurant(order, restaurantId) {
+		return domain.ErrFulfilmentNotFound
+	}
+
+	err = s.txRunner.RateOrderTx(ctx, func(ctx context.Context, tx RateOrderTx) error {
+		err := tx.UpsertRestaurantRatings(ctx, order.Id, []entity.RestaurantRating{{
+			RestaurantId: restaurantId,
+			Score:        score,
+		}})
+		if err != nil {
+			return errors.WithMessage(err, "upsert restaurant ratings")
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.WithMessage(err, "rate order tx")
+	}
+	return nil
+}
+
+// CommentOrder sets the comment of the rated order, used by the bot
+func (s Rating) CommentOrder(ctx context.Context, userId string, orderId string, comment string) error {
+	order, err := s.getRatedOrder(ctx, userId, orderId)
+	if err != nil {
+		return errors.WithMessage(err, "get rated order")
+	}
+
+	err = s.txRunner.RateOrderTx(ctx, func(ctx context.Context, tx RateOrderTx) error {
+		err := tx.SetOrderRatingComment(ctx, order.Id, comment)
+		if err != nil {
+			return errors.WithMessage(err, "set order rating comment")
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.WithMessage(err, "rate order tx")
+	}
+	return nil
+}
+
+func (s Rating) GetOrderRating(ctx context.Context, authInfo entity.UserAuthInfo, orderId string) (*domain.OrderRating, error) {
+	order, err := s.orderRepo.GetOrder(ctx, orderId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get order")
+	}
+	if authInfo.RoleName != domain.AdminRoleName && order.UserId != authInfo.UserId {
+		return nil, domain.ErrForbidden
+	}
+
+	rating, err := s.repo.GetOrderRating(ctx, orderId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get order rating")
+	}
+	restaurantRatings, err := s.repo.GetRestaurantRatings(ctx, orderId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get restaurant ratings")
+	}
+	return &domain.OrderRating{
+		OrderId:     rating.OrderId,
+		Score:       rating.Score,
+		Comment:     rating.Comment,
+		Restaurants: restaurantRatingsFromEntity(restaurantRatings),
+		CreatedAt:   rating.CreatedAt,
+	}, nil
+}
+
+func (s Rating) GetLowRatings(ctx context.Context, req domain.GetLowRatingsRequest) ([]domain.LowRating, error) {
+	maxScore := req.MaxScore
+	if maxScore == 0 {
+		maxScore = defaultLowRatingMaxScore
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultLowRatingsLimit
+	}
+	ratings, err := s.repo.GetLowRatings(ctx, maxScore, limit, req.Offset)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get low ratings")
+	}
+
+	lowRatings := make([]domain.LowRating, len(ratings))
+	for i, rating := range ratings {
+		lowRatings[i] = domain.LowRating{
+			OrderId:     rating.OrderId,
+			UserId:      rating.UserId,
+			Username:    rating.Username,
+			Score:       rating.Score,
+			Comment:     rating.Comment,
+			Restaurants: restaurantRatingsFromEntity(rating.Restaurants),
+			CreatedAt:   rating.CreatedAt,
+		}
+	}
+	return lowRatings, nil
+}
+
+// getRatedOrder returns the order if the user can rate it
+func (s Rating) getRatedOrder(ctx context.Context, userId string, orderId string) (*entity.Order, error) {
+	order, err := s.orderRepo.GetOrder(ctx, orderId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get order")
+	}
+	if order.UserId != userId {
+		return nil, domain.ErrForbidden
+	}
+	if order.Status != entity.OrderItemStatusSuccess {
+		return nil, domain.ErrOrderRatingForbidden
+	}
+	return order, nil
+}
+
+func orderHasRestaurant(order *entity.Order, restaurantId int32) bool {
+	for _, item := range order.Items {
+		if item.RestaurantId == restaurantId {
+			return true
+		}
+	}
+	return false
+}
+
+func restaurantRatingsFromEntity(ratings []entity.RestaurantRating) []domain.RestaurantRating {
+	result := make([]domain.RestaurantRating, len(ratings))
+	for i, rating := range ratings {
+		result[i] = domain.RestaurantRating{
+			RestaurantId:   rating.RestaurantId,
+			RestaurantName: rating.RestaurantName,
+			Score:          rating.Score,
+		}
+	}
+	return result
+}
@@ -13,6 +13,7 @@ type DishesRestaurantsRepo interface {
 	GetRestaurant(ctx context.Context, id int32) (entity.Restaurant, error)
 	InsertRestaurant(ctx context.Context, restaurant string) (int32, error)
 	RenameRestaurant(ctx context.Context, id int32, newName string) error
+	UpdateRestaurantTerms(ctx context.Context, id int32, terms entity.RestaurantTerms) error
 	DeleteRestaurant(ctx context.Context, id int32) error
 }
 
@@ -31,10 +32,7 @@ func (s Restaurant) GetAllRestaurants(ctx context.Context) ([]domain.Restaurant,
 	}
 	domainRestaurants := make([]domain.Restaurant, len(restaurants))
 	for i, restaurant := range restaurants {
-		domainRestaurants[i] = domain.Restaurant{
-			Id:   restaurant.Id,
-			Name: restaurant.Name,
-		}
+		domainRestaurants[i] = restaurantFromEntity(restaurant)
 	}
 	return domainRestaurants, nil
 }
@@ -44,10 +42,7 @@ func (s Restaurant) GetRestaurant(ctx context.Context, id int32) (domain.Restaur
 	if err != nil {
 		return domain.Restaurant{}, errors.WithMessage(err, "get restaurant")
 	}
-	return domain.Restaurant{
-		Id:   restaurant.Id,
-		Name: restaurant.Name,
-	}, nil
+	return restaurantFromEntity(restaurant), nil
 }
 
 func (s Restaurant) AddRestaurant(ctx context.Context, restaurant string) (int32, error) {
@@ -66,6 +61,18 @@ func (s Restaurant) RenameRestaurant(ctx context.Context, req domain.RenameResta
 	return nil
 }
 
+func (s Restaurant) SetRestaurantTerms(ctx context.Context, req domain.SetRestaurantTermsRequest) error {
+	err := s.repo.UpdateRestaurantTerms(ctx, req.Id, entity.RestaurantTerms{
+		MinOrderTotal:         req.MinOrderTotal,
+		DeliveryFee:           req.DeliveryFee,
+		FreeDeliveryThreshold: req.FreeDeliveryThreshold,
+	})
+	if err != nil {
+		return errors.WithMessage(err, "update restaurant terms")
+	}
+	return nil
+}
+
 func (s Restaurant) DeleteRestaurant(ctx context.Context, id int32) error {
 	err := s.repo.DeleteRestaurant(ctx, id)
 	if err != nil {
@@ -73,3 +80,15 @@ func (s Restaurant) DeleteRestaurant(ctx context.Context, id int32) error {
 	}
 	return nil
 }
+
+func restaurantFromEntity(restaurant entity.Restaurant) domain.Restaurant {
+	return domain.Restaurant{
+		Id:                    restaurant.Id,
+		Name:                  restaurant.Name,
+		MinOrderTotal:         restaurant.MinOrderTotal,
+		DeliveryFee:           restaurant.DeliveryFee,
+		FreeDeliveryThreshold: restaurant.FreeDeliveryThreshold,
+		Rating:                restaurant.Rating,
+		RatingsCount:          restaurant.RatingsCount,
+	}
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/pkg/errors"
+)
+
+type StarsRateRepo interface {
+	GetStarsRate(ctx context.Context) (entity.StarsRate, error)
+	SetStarPrice(ctx context.Context, starPrice int32) error
+}
+
+type StarsRate struct {
+	repo StarsRateRepo
+}
+
+func NewStarsRate(repo StarsRateRepo) StarsRate {
+	return StarsRate{
+		repo: repo,
+	}
+}
+
+func (s StarsRate) GetStarsRate(ctx context.Context) (*domain.StarsRate, error) {
+	rate, err := s.repo.GetStarsRate(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get stars rate")
+	}
+	return &domain.StarsRate{
+		StarPrice: rate.StarPrice,
+		UpdatedAt: rate.UpdatedAt,
+	}, nil
+}
+
+func (s StarsRate) SetStarsRate(ctx context.Context, req domain.SetStarsRateRequest) error {
+	err := s.repo.SetStarPrice(ctx, req.StarPrice)
+	if err != nil {
+		return errors.WithMessage(err, "set star price")
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+
+	"github.com/pkg/errors"
+)
+
+type WalletTx interface {
+	GetWalletBalanceForUpdate(ctx context.Context, userId string) (int64, error)
+	AddWalletBalance(ctx context.Context, userId string, amount int64) (int64, error)
+	InsertWalletTransaction(ctx context.Context, transaction entity.WalletTransaction) error
+}
+
+type WalletTxRunner interface {
+	WalletTx(ctx context.Context, tx func(ctx context.Context, tx WalletTx) error) error
+}
+
+type WalletRepo interface {
+	GetWallet(ctx context.Context, userId string) (entity.Wallet, error)
+	GetWalletTransactions(ctx context.Context, userId string, limit int32, offset int32) ([]entity.WalletTransaction, error)
+}
+
+type WalletUserRepo interface {
+	GetUserChatId(ctx context.Context, userId string) (int64, error)
+}
+
+type TopUpInvoiceSender interface {
+	SendTopUpInvoice(ctx context.Context, chatId int64, userId string, amount int32) error
+}
+
+const defaultWalletTransactionsLimit = 50
+
+// the orders charged to the company account aren't refunded to the user wallet,
+// canceled orders don't count against the company credit limit
+const corporatePaymentMethod = "corporate"
+
+type Wallet struct {
+	repo          WalletRepo
+	userRepo      WalletUserRepo
+	txRunner      WalletTxRunner
+	invoiceSender TopUpInvoiceSender
+}
+
+func NewWallet(
+	repo WalletRepo,
+	userRepo WalletUserRepo,
+	txRunner WalletTxRunner,
+	invoiceSender TopUpInvoiceSender,
+) Wallet {
+	return Wallet{
+		repo:          repo,
+		userRepo:      userRepo,
+		txRunner:      txRunner,
+		invoiceSender: invoiceSender,
+	}
+}
+
+func (s Wallet) GetWallet(ctx context.Context, userId string) (*domain.Wallet, error) {
+	wallet, err := s.repo.GetWallet(ctx, userId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get wallet")
+	}
+	return &domain.Wallet{Balance: wallet.Balance}, nil
+}
+
+func (s Wallet) GetTransactions(
+	ctx context.Context,
+	userId string,
+	req domain.GetWalletTransactionsRequest,
+) ([]domain.WalletTransaction, error) {
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultWalletTransactionsLimit
+	}
+	transactions, err := s.repo.GetWalletTransactions(ctx, userId, limit, req.Offset)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get wallet transactions")
+	}
+	result := make([]domain.WalletTransaction, len(transactions))
+	for i, transaction := range transactions {
+		result[i] = domain.WalletTransaction{
+			Id:           transaction.Id,
+			Type:         transaction.Type,
+			Amount:       transaction.Amount,
+			BalanceAfter: transaction.BalanceAfter,
+			OrderId:      transaction.OrderId,
+			Comment:      transaction.Comment,
+			CreatedAt:    transaction.CreatedAt,
+		}
+	}
+	return result, nil
+}
+
+// RequestTopUp sends the top-up invoice to the user chat, the wallet is credited once the invoice is paid
+func (s Wallet) RequestTopUp(ctx context.Context, userId string, req domain.TopUpWalletRequest) error {
+	chatId, err := s.userRepo.GetUserChatId(ctx, userId)
+	if err != nil {
+		return errors.WithMessage(err, "get user chat id")
+	}
+	err = s.invoiceSender.SendTopUpInvoice(ctx, chatId, userId, req.Amount)
+	if err != nil {
+		return errors.WithMessage(err, "send top-up invoice")
+	}
+	return nil
+}
+
+// TopUp credits the paid top-up invoice and returns the new balance,
+// the repeated call with the same chargeId doesn't credit the wallet again
+func (s Wallet) TopUp(ctx context.Context, userId string, amount int64, chargeId string, actor string) (int64, error) {
+	var balance int64
+	err := s.txRunner.WalletTx(ctx, func(ctx context.Context, tx WalletTx) error {
+		var err error
+		balance, err = moveWalletFunds(ctx, tx, entity.WalletTransaction{
+			UserId:     userId,
+			Type:       entity.WalletTransactionTopUp,
+			Amount:     amount,
+			ExternalId: chargeId,
+			Actor:      actor,
+			Comment:    "пополнение через telegram",
+		})
+		return err
+	})
+	switch {
+	case errors.Is(err, domain.ErrWalletTransactionExists):
+		wallet, err := s.repo.GetWallet(ctx, userId)
+		if err != nil {
+			return 0, errors.WithMessage(err, "get wallet")
+		}
+		return wallet.Balance, nil
+	case err != nil:
+		return 0, errors.WithMessage(err, "wallet tx")
+	default:
+		return balance, nil
+	}
+}
+
+// Adjust credits or debits the wallet by the admin
+func (s Wallet) Adjust(ctx context.Context, adminId string, req domain.AdjustWalletRequest) (*domain.Wallet, error) {
+	var balance int64
+	err := s.txRunner.WalletTx(ctx, func(ctx context.Context, tx WalletTx) error {
+		var err error
+		balance, err = moveWalletFunds(ctx, tx, entity.WalletTransaction{
+			UserId:  req.Id,
+			Type:    entity.WalletTransactionAdjustment,
+			Amount:  req.Amount,
+			Actor:   entity.UserActor(adminId),
+			Comment: req.Comment,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "wallet tx")
+	}
+	return &domain.Wallet{Balance: balance}, nil
+}
+
+// ChargeOrderTx pays the order from the user wallet within the transaction of the caller
+func (s Wallet) ChargeOrderTx(ctx context.Context, tx WalletTx, order *entity.Order, actor string) error {
+	balance, err := tx.GetWalletBalanceForUpdate(ctx, order.UserId)
+	if err != nil {
+		return errors.WithMessage(err, "get wallet balance")
+	}
+	if balance < int64(order.Total) {
+		return domain.ErrInsufficientFunds
+	}
+	_, err = moveWalletFunds(ctx, tx, entity.WalletTransaction{
+		UserId:  order.UserId,
+		Type:    entity.WalletTransactionCharge,
+		Amount:  -int64(order.Total),
+		OrderId: order.Id,
+		Actor:   actor,
+		Comment: "оплата заказа",
+	})
+	if err != nil {
+		return errors.WithMessage(err, "move wallet funds")
+	}
+	return nil
+}
+
+// refundOrderToWallet returns the refund of the paid order to the user wallet
+func refundOrderToWallet(
+	ctx context.Context,
+	tx WalletTx,
+	order *entity.Order,
+	refund int32,
+	actor string,
+	comment string,
+) error {
+	if refund <= 0 || order.PaymentMethod == corporatePaymentMethod {
+		return nil
+	}
+	_, err := moveWalletFunds(ctx, tx, entity.WalletTransaction{
+		UserId:  order.UserId,
+		Type:    entity.WalletTransactionRefund,
+		Amount:  int64(refund),
+		OrderId: order.Id,
+		Actor:   actor,
+		Comment: comment,
+	})
+	if err != nil {
+		return errors.WithMessage(err, "move wallet funds")
+	}
+	return nil
+}
+
+// moveWalletFunds changes the wallet balance and appends the record to the wallet ledger
+func moveWalletFunds(ctx context.Context, tx WalletTx, transaction entity.WalletTransaction) (int64, error) {
+	balance, err := tx.AddWalletBalance(ctx, transaction.UserId, transaction.Amount)
+	if err != nil {
+		return 0, errors.WithMessage(err, "add wallet balance")
+	}
+	transaction.BalanceAfter = balance
+	transaction.CreatedAt = time.Now().UTC()
+	err = tx.InsertWalletTransaction(ctx, transaction)
+	if err != nil {
+		return 0, errors.WithMessage(err, "insert wallet transaction")
+	}
+	return balance, nil
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// nolint:gochecknoglobals
+var xlsxStaticFiles = []struct {
+	name    string
+	content string
+}{
+	{
+		name: "[Content_Types].xml",
+		content: `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`,
+	},
+	{
+		name: "_rels/.rels",
+		content: `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`,
+	},
+	{
+		name: "xl/workbook.xml",
+		content: `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`,
+	},
+	{
+		name: "xl/_rels/workbook.xml.rels",
+		content: `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`,
+	},
+}
+
+// writeXlsx writes the rows to a single sheet workbook, all cells are written as strings
+func writeXlsx(rows [][]string) ([]byte, error) {
+	var b bytes.Buffer
+	writer := zip.NewWriter(&b)
+	for _, file := range xlsxStaticFiles {
+		w, err := writer.Create(file.name)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "create %s", file.name)
+		}
+		_, err = w.Write([]byte(file.content))
+		if err != nil {
+			return nil, errors.WithMessagef(err, "write %s", file.name)
+		}
+	}
+
+	w, err := writer.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, errors.WithMessage(err, "create sheet")
+	}
+	_, err = w.Write([]byte(xlsxSheet(rows)))
+	if err != nil {
+		return nil, errors.WithMessage(err, "write sheet")
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return nil, errors.WithMessage(err, "close zip writer")
+	}
+	return b.Bytes(), nil
+}
+
+func xlsxSheet(rows [][]string) string {
+	var builder strings.Builder
+	builder.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`)
+	builder.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range rows {
+		fmt.Fprintf(&builder, `<row r="%d">`, i+1)
+		for j, value := range row {
+			fmt.Fprintf(&builder, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">`, xlsxColumn(j), i+1)
+			_ = xml.EscapeText(&builder, []byte(value))
+			builder.WriteString(`</t></is></c>`)
+		}
+		builder.WriteString(`</row>`)
+	}
+	builder.WriteString(`</sheetData></worksheet>`)
+	return builder.String()
+}
+
+// xlsxColumn returns the column name by its zero based index: A, B, ..., Z, AA, ...
+// nolint:mnd
+func xlsxColumn(idx int) string {
+	name := ""
+	for idx >= 0 {
+		name = string(rune('A'+idx%26)) + name
+		idx = idx/26 - 1
+	}
+	return name
+}
@@ -0,0 +1,1811 @@
+// nolint:noctx,funlen,dupl
+package tests_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+	"time"
+
+	"dishes-service-backend/assembly"
+	"dishes-service-backend/conf"
+	"dishes-service-backend/domain"
+	"dishes-service-backend/entity"
+	"dishes-service-backend/repository"
+	"dishes-service-backend/service"
+	"dishes-service-backend/service/payment/expiration"
+	"dishes-service-backend/service/payment/gateway"
+	fake_gateway "dishes-service-backend/service/payment/gateway/fake"
+	"dishes-service-backend/service/payment/invoice"
+	"dishes-service-backend/service/payment/reconciliation"
+	"dishes-service-backend/service/payment/refund"
+	"dishes-service-backend/service/payment/telegram_stars"
+	"dishes-service-backend/transaction"
+
+	"github.com/Falokut/go-kit/db"
+	"github.com/Falokut/go-kit/http/apierrors"
+	"github.com/Falokut/go-kit/http/client"
+	"github.com/Falokut/go-kit/json"
+	"github.com/Falokut/go-kit/jwt"
+	"github.com/Falokut/go-kit/test"
+	"github.com/Falokut/go-kit/test/dbt"
+	"github.com/Falokut/go-kit/test/fake"
+	"github.com/Falokut/go-kit/test/tgt"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"github.com/txix-open/bgjob"
+)
+
+type OrderSuite struct {
+	suite.Suite
+	test             *test.Test
+	adminAccessToken string
+	userAccessToken  string
+	userId           string
+	otherAccessToken string
+
+	dishId int32
+
+	db          *dbt.TestDb
+	orderRepo   repository.Order
+	orderStatus service.OrderStatus
+	cli         *client.Client
+	gateway     *fake_gateway.Server
+}
+
+const gatewayWebhookSecret = "webhook_secret"
+
+func TestOrder(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &OrderSuite{})
+}
+
+func (t *OrderSuite) SetupTest() {
+	test, _ := test.New(t.T())
+	t.test = test
+	t.db = dbt.New(test, db.WithMigrationRunner("../migrations", test.Logger()))
+	t.orderRepo = repository.NewOrder(t.db.Client)
+	t.orderStatus = service.NewOrderStatus(transaction.NewManager(t.db.Client))
+
+	bgjobDb := bgjob.NewPgStore(t.db.Client.DB.DB)
+	bgjobCli := bgjob.NewClient(bgjobDb)
+	tgBot, _ := tgt.TestBot(test)
+
+	t.gateway = fake_gateway.NewServer(gatewayWebhookSecret, "")
+	gatewayServer := httptest.NewServer(t.gateway)
+	t.T().Cleanup(gatewayServer.Close)
+
+	cfg := getConfig()
+	cfg.Payment.Gateway = conf.PaymentGateway{
+		BaseUrl:       fmt.Sprintf("http://%s", gatewayServer.Listener.Addr()),
+		ApiKey:        "api_key",
+		WebhookSecret: gatewayWebhookSecret,
+	}
+	locator := assembly.NewLocator(t.db, bgjobCli, nil, tgBot, t.test.Logger())
+	locatorCfg, err := locator.LocatorConfig(t.T().Context(), cfg)
+	t.Require().NoError(err)
+
+	server := httptest.NewServer(locatorCfg.HttpRouter)
+	t.cli = client.NewWithClient(server.Client())
+	t.cli.GlobalRequestConfig().BaseUrl = fmt.Sprintf("http://%s", server.Listener.Addr())
+	t.gateway.SetWebhookUrl(fmt.Sprintf("http://%s/payments/gateway/webhook", server.Listener.Addr()))
+
+	t.adminAccessToken, _ = t.insertUser(cfg.Auth.Access.Secret, "@admin", true)
+	t.userAccessToken, t.userId = t.insertUser(cfg.Auth.Access.Secret, "@user", false)
+	t.otherAccessToken, _ = t.insertUser(cfg.Auth.Access.Secret, "@other", false)
+
+	restaurantId, err := repository.NewRestaurant(t.db.Client).InsertRestaurant(t.T().Context(), fake.It[string]())
+	t.Require().NoError(err)
+	t.dishId, err = repository.NewDish(t.db.Client).InsertDish(t.T().Context(), &entity.InsertDish{
+		Name:         fake.It[string](),
+		Description:  fake.It[string](),
+		Price:        1000,
+		RestaurantId: restaurantId,
+	})
+	t.Require().NoError(err)
+}
+
+func (t *OrderSuite) insertUser(secret string, username string, admin bool) (string, string) {
+	var userId string
+	t.db.Must().SelectRow(t.T().Context(),
+		&userId,
+		`INSERT INTO users(username,name,admin)
+		VALUES($1,$2,$3)
+		RETURNING id;`,
+		username,
+		"test",
+		admin,
+	)
+
+	roleName := domain.UserRoleName
+	if admin {
+		roleName = domain.AdminRoleName
+	}
+	jwtGen, err := jwt.GenerateToken(secret, time.Hour, &entity.TokenUserInfo{
+		UserId:   userId,
+		RoleName: roleName,
+	})
+	t.Require().NoError(err)
+	return domain.BearerToken + " " + jwtGen.Token, userId
+}
+
+func (t *OrderSuite) insertOrder(userId string, status string) string {
+	order := &entity.Order{
+		Id:            uuid.NewString(),
+		PaymentMethod: "telegram",
+		UserId:        userId,
+		Total:         2000,
+		CreatedAt:     time.Now().UTC(),
+		Status:        status,
+		Items: entity.OrderItems{
+			{DishId: t.dishId, Count: 2, Price: 2000},
+		},
+	}
+	err := t.orderRepo.InsertOrder(t.T().Context(), order)
+	t.Require().NoError(err)
+	err = t.orderRepo.InsertOrderItems(t.T().Context(), order.Id, order.Items)
+	t.Require().NoError(err)
+	return order.Id
+}
+
+func (t *OrderSuite) Test_GetOrder_HappyPath() {
+	orderId := t.insertOrder(t.userId, entity.OrderItemStatusProcess)
+
+	for _, token := range []string{t.userAccessToken, t.adminAccessToken} {
+		var order domain.UserOrder
+		_, err := t.cli.Get("/orders/details/"+orderId).
+			Header(domain.AuthHeaderName, token).
+			StatusCodeToError().
+			JsonResponseBody(&order).
+			Do(t.T().Context())
+		t.Require().NoError(err)
+		t.Require().Equal(orderId, order.Id)
+		t.Require().EqualValues(2000, order.Total)
+		t.Require().Equal(entity.OrderItemStatusProcess, order.Status)
+		t.Require().Len(order.Items, 1)
+		t.Require().Equal(t.dishId, order.Items[0].DishId)
+	}
+}
+
+func (t *OrderSuite) Test_GetOrder_Forbidden() {
+	orderId := t.insertOrder(t.userId, entity.OrderItemStatusProcess)
+
+	resp, err := t.cli.Get("/orders/details/"+orderId).
+		Header(domain.AuthHeaderName, t.otherAccessToken).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(http.StatusForbidden, resp.StatusCode())
+}
+
+func (t *OrderSuite) Test_GetOrder_NotFound() {
+	resp, err := t.cli.Get("/orders/details/"+uuid.NewString()).
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(http.StatusNotFound, resp.StatusCode())
+
+	respBody, err := resp.Body()
+	t.Require().NoError(err)
+	var errorResp apierrors.Error
+	err = json.Unmarshal(respBody, &errorResp)
+	t.Require().NoError(err)
+	t.Require().EqualValues(domain.ErrCodeOrderNotFound, errorResp.ErrorCode)
+}
+
+func (t *OrderSuite) Test_CancelOrder_HappyPath() {
+	orderId := t.insertOrder(t.userId, entity.OrderItemStatusProcess)
+
+	_, err := t.cli.Post("/orders/"+orderId+"/cancel").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		StatusCodeToError().
+		Do(t.T().Context())
+	t.Require().NoError(err)
+
+	status, err := t.orderRepo.GetOrderStatus(t.T().Context(), orderId)
+	t.Require().NoError(err)
+	t.Require().Equal(entity.OrderItemStatusCanceled, status)
+}
+
+func (t *OrderSuite) Test_CancelOrder_AlreadyPaid() {
+	orderId := t.insertOrder(t.userId, entity.OrderItemStatusPaid)
+
+	resp, err := t.cli.Post("/orders/"+orderId+"/cancel").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(http.StatusBadRequest, resp.StatusCode())
+
+	status, err := t.orderRepo.GetOrderStatus(t.T().Context(), orderId)
+	t.Require().NoError(err)
+	t.Require().Equal(entity.OrderItemStatusPaid, status)
+}
+
+func (t *OrderSuite) Test_CancelOrder_Forbidden() {
+	orderId := t.insertOrder(t.userId, entity.OrderItemStatusProcess)
+
+	resp, err := t.cli.Post("/orders/"+orderId+"/cancel").
+		Header(domain.AuthHeaderName, t.otherAccessToken).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(http.StatusForbidden, resp.StatusCode())
+}
+
+func (t *OrderSuite) Test_ListOrders_HappyPath() {
+	paidOrderId := t.insertOrder(t.userId, entity.OrderItemStatusPaid)
+	t.insertOrder(t.userId, entity.OrderItemStatusProcess)
+	t.insertOrder(t.userId, entity.OrderItemStatusProcess)
+
+	var resp domain.GetOrdersResponse
+	_, err := t.cli.Get("/orders").
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		QueryParams(map[string]any{
+			"status": entity.OrderItemStatusPaid,
+			"userId": t.userId,
+		}).
+		StatusCodeToError().
+		JsonResponseBody(&resp).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(1, resp.Total)
+	t.Require().Len(resp.Orders, 1)
+	t.Require().Equal(paidOrderId, resp.Orders[0].Id)
+	t.Require().Equal("@user", resp.Orders[0].Username)
+
+	_, err = t.cli.Get("/orders").
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		QueryParams(map[string]any{
+			"limit": 1,
+		}).
+		StatusCodeToError().
+		JsonResponseBody(&resp).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(3, resp.Total)
+	t.Require().Len(resp.Orders, 1)
+}
+
+func (t *OrderSuite) Test_ListOrders_Forbidden() {
+	resp, err := t.cli.Get("/orders").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(http.StatusForbidden, resp.StatusCode())
+}
+
+func (t *OrderSuite) Test_ChangeOrderStatus_HappyPath() {
+	orderId := t.insertOrder(t.userId, entity.OrderItemStatusPaid)
+
+	_, err := t.cli.Post("/orders/"+orderId+"/status").
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		JsonRequestBody(domain.SetOrderStatusRequest{Status: entity.OrderItemStatusSuccess}).
+		StatusCodeToError().
+		Do(t.T().Context())
+	t.Require().NoError(err)
+
+	status, err := t.orderRepo.GetOrderStatus(t.T().Context(), orderId)
+	t.Require().NoError(err)
+	t.Require().Equal(entity.OrderItemStatusSuccess, status)
+}
+
+func (t *OrderSuite) Test_ChangeOrderStatus_TransitionForbidden() {
+	orderId := t.insertOrder(t.userId, entity.OrderItemStatusCanceled)
+
+	resp, err := t.cli.Post("/orders/"+orderId+"/status").
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		JsonRequestBody(domain.SetOrderStatusRequest{Status: entity.OrderItemStatusPaid}).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(http.StatusBadRequest, resp.StatusCode())
+
+	status, err := t.orderRepo.GetOrderStatus(t.T().Context(), orderId)
+	t.Require().NoError(err)
+	t.Require().Equal(entity.OrderItemStatusCanceled, status)
+}
+
+func (t *OrderSuite) Test_ChangeOrderStatus_MarkPaid() {
+	t.allowOrdering()
+
+	var resp domain.ProcessOrderResponse
+	_, err := t.cli.Post("/orders").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		JsonRequestBody(domain.ProcessOrderRequest{
+			Items:         map[string]int32{fmt.Sprint(t.dishId): 1},
+			PaymentMethod: gateway.PaymentMethod,
+		}).
+		StatusCodeToError().
+		JsonResponseBody(&resp).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+
+	_, err = t.cli.Post("/orders/"+resp.OrderId+"/status").
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		JsonRequestBody(domain.SetOrderStatusRequest{Status: entity.OrderItemStatusPaid}).
+		StatusCodeToError().
+		Do(t.T().Context())
+	t.Require().NoError(err)
+
+	// the order paid by the admin has no payment, so the reconciliation reports it
+	var paymentStatus string
+	t.db.Must().SelectRow(t.T().Context(), &paymentStatus,
+		"SELECT status FROM payments WHERE order_id=$1", resp.OrderId)
+	t.Require().Equal(entity.PaymentStatusFailed, paymentStatus)
+	var report domain.PaymentDiscrepanciesReport
+	_, err = t.cli.Get("/payments/discrepancies").
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		StatusCodeToError().
+		JsonResponseBody(&report).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().Len(report.Discrepancies, 1)
+	t.Require().Equal(entity.DiscrepancyPaidWithoutPayment, report.Discrepancies[0].Kind)
+
+	// the gateway payment left open is canceled by the invoice cleanup job
+	var invoiceJobs int
+	t.db.Must().SelectRow(t.T().Context(), &invoiceJobs,
+		"SELECT count(*) FROM bgjob_job WHERE queue=$1 AND id=$2", invoice.WorkerQueue, invoice.WorkerQueue+"_"+resp.OrderId)
+	t.Require().EqualValues(1, invoiceJobs)
+}
+
+func (t *OrderSuite) Test_GetOrderStatusHistory_HappyPath() {
+	orderId := t.insertOrder(t.userId, entity.OrderItemStatusProcess)
+
+	_, err := t.cli.Post("/orders/"+orderId+"/cancel").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		StatusCodeToError().
+		Do(t.T().Context())
+	t.Require().NoError(err)
+
+	var history []domain.OrderStatusHistory
+	_, err = t.cli.Get("/orders/details/"+orderId+"/history").
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		StatusCodeToError().
+		JsonResponseBody(&history).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().Len(history, 1)
+	t.Require().Equal(entity.OrderItemStatusProcess, history[0].OldStatus)
+	t.Require().Equal(entity.OrderItemStatusCanceled, history[0].NewStatus)
+	t.Require().Equal(entity.UserActor(t.userId), history[0].Actor)
+}
+
+func (t *OrderSuite) allowOrdering() {
+	t.db.Must().Exec(t.T().Context(), "INSERT INTO allow_ordering_audit(start_period) VALUES(now())")
+	t.db.Must().Exec(t.T().Context(),
+		"INSERT INTO users_telegrams(id,chat_id,telegram_id) VALUES($1,$2,$3)",
+		t.userId, 1, 1,
+	)
+}
+
+func (t *OrderSuite) Test_ProcessOrder_IdempotencyKey() {
+	t.allowOrdering()
+
+	req := domain.ProcessOrderRequest{
+		Items:         map[string]int32{fmt.Sprint(t.dishId): 2},
+		PaymentMethod: "telegram",
+	}
+	key := uuid.NewString()
+
+	var first domain.ProcessOrderResponse
+	_, err := t.cli.Post("/orders").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		Header(domain.IdempotencyKeyHeader, key).
+		JsonRequestBody(req).
+		StatusCodeToError().
+		JsonResponseBody(&first).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().NotEmpty(first.OrderId)
+
+	var second domain.ProcessOrderResponse
+	_, err = t.cli.Post("/orders").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		Header(domain.IdempotencyKeyHeader, key).
+		JsonRequestBody(req).
+		StatusCodeToError().
+		JsonResponseBody(&second).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().Equal(first.OrderId, second.OrderId)
+
+	total, err := t.orderRepo.CountOrders(t.T().Context(), entity.OrdersFilter{UserId: t.userId})
+	t.Require().NoError(err)
+	t.Require().EqualValues(1, total)
+
+	req.Items[fmt.Sprint(t.dishId)] = 3
+	resp, err := t.cli.Post("/orders").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		Header(domain.IdempotencyKeyHeader, key).
+		JsonRequestBody(req).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(http.StatusConflict, resp.StatusCode())
+}
+
+func (t *OrderSuite) Test_RepeatOrder_HappyPath() {
+	t.allowOrdering()
+	orderId := t.insertOrder(t.userId, entity.OrderItemStatusSuccess)
+
+	var resp domain.RepeatOrderResponse
+	_, err := t.cli.Post("/orders/"+orderId+"/repeat").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		JsonRequestBody(domain.RepeatOrderRequest{}).
+		StatusCodeToError().
+		JsonResponseBody(&resp).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().NotEqual(orderId, resp.OrderId)
+	t.Require().Empty(resp.MissingDishes)
+
+	order, err := t.orderRepo.GetOrder(t.T().Context(), resp.OrderId)
+	t.Require().NoError(err)
+	t.Require().Equal(entity.OrderItemStatusProcess, order.Status)
+	t.Require().EqualValues(2000, order.Total)
+	t.Require().Len(order.Items, 1)
+	t.Require().EqualValues(2, order.Items[0].Count)
+}
+
+func (t *OrderSuite) Test_RepeatOrder_Forbidden() {
+	orderId := t.insertOrder(t.userId, entity.OrderItemStatusSuccess)
+
+	resp, err := t.cli.Post("/orders/"+orderId+"/repeat").
+		Header(domain.AuthHeaderName, t.otherAccessToken).
+		JsonRequestBody(domain.RepeatOrderRequest{}).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(http.StatusForbidden, resp.StatusCode())
+}
+
+func (t *OrderSuite) Test_ProcessOrder_DeliverySlotCapacity() {
+	t.allowOrdering()
+	date := time.Now().AddDate(0, 0, 1).Format(time.DateOnly)
+
+	var slot domain.AddDeliverySlotResponse
+	_, err := t.cli.Post("/delivery_slots").
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		JsonRequestBody(domain.AddDeliverySlotRequest{
+			Date:      date,
+			StartTime: "12:00",
+			EndTime:   "13:00",
+			Capacity:  1,
+		}).
+		StatusCodeToError().
+		JsonResponseBody(&slot).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+
+	req := domain.ProcessOrderRequest{
+		Items:          map[string]int32{fmt.Sprint(t.dishId): 1},
+		PaymentMethod:  "telegram",
+		DeliverySlotId: slot.Id,
+	}
+	var orderResp domain.ProcessOrderResponse
+	_, err = t.cli.Post("/orders").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		JsonRequestBody(req).
+		StatusCodeToError().
+		JsonResponseBody(&orderResp).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+
+	order, err := t.orderRepo.GetOrder(t.T().Context(), orderResp.OrderId)
+	t.Require().NoError(err)
+	t.Require().Equal(slot.Id, order.DeliverySlotId)
+
+	resp, err := t.cli.Post("/orders").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		JsonRequestBody(req).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(http.StatusBadRequest, resp.StatusCode())
+	respBody, err := resp.Body()
+	t.Require().NoError(err)
+	var errorResp apierrors.Error
+	err = json.Unmarshal(respBody, &errorResp)
+	t.Require().NoError(err)
+	t.Require().EqualValues(domain.ErrCodeDeliverySlotFull, errorResp.ErrorCode)
+
+	var slots []domain.DeliverySlot
+	_, err = t.cli.Get("/delivery_slots").
+		QueryParams(map[string]any{"date": date}).
+		StatusCodeToError().
+		JsonResponseBody(&slots).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().Len(slots, 1)
+	t.Require().EqualValues(0, slots[0].Available)
+}
+
+func (t *OrderSuite) Test_GroupOrder_OrganizerPays() {
+	t.allowOrdering()
+
+	var group domain.CreateGroupOrderResponse
+	_, err := t.cli.Post("/group_orders").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		JsonRequestBody(domain.CreateGroupOrderRequest{PaymentMode: entity.GroupOrderPaymentModeOrganizer}).
+		StatusCodeToError().
+		JsonResponseBody(&group).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+
+	for _, token := range []string{t.userAccessToken, t.otherAccessToken} {
+		_, err = t.cli.Post("/group_orders/"+group.Id+"/items").
+			Header(domain.AuthHeaderName, token).
+			JsonRequestBody(domain.SetGroupOrderItemsRequest{
+				Items: map[string]int32{fmt.Sprint(t.dishId): 1},
+			}).
+			StatusCodeToError().
+			Do(t.T().Context())
+		t.Require().NoError(err)
+	}
+
+	var groupOrder domain.GroupOrder
+	_, err = t.cli.Get("/group_orders/"+group.Id).
+		Header(domain.AuthHeaderName, t.otherAccessToken).
+		StatusCodeToError().
+		JsonResponseBody(&groupOrder).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().Len(groupOrder.Participants, 2)
+	t.Require().EqualValues(2000, groupOrder.Total)
+
+	lockReq := domain.LockGroupOrderRequest{PaymentMethod: "telegram"}
+	resp, err := t.cli.Post("/group_orders/"+group.Id+"/lock").
+		Header(domain.AuthHeaderName, t.otherAccessToken).
+		JsonRequestBody(lockReq).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(http.StatusForbidden, resp.StatusCode())
+
+	var lockResp domain.LockGroupOrderResponse
+	_, err = t.cli.Post("/group_orders/"+group.Id+"/lock").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		JsonRequestBody(lockReq).
+		StatusCodeToError().
+		JsonResponseBody(&lockResp).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().Len(lockResp.Orders, 1)
+	t.Require().Equal(t.userId, lockResp.Orders[0].UserId)
+
+	order, err := t.orderRepo.GetOrder(t.T().Context(), lockResp.Orders[0].OrderId)
+	t.Require().NoError(err)
+	t.Require().Equal(group.Id, order.GroupOrderId)
+	t.Require().EqualValues(2000, order.Total)
+	t.Require().Len(order.Items, 1)
+	t.Require().EqualValues(2, order.Items[0].Count)
+
+	resp, err = t.cli.Post("/group_orders/"+group.Id+"/items").
+		Header(domain.AuthHeaderName, t.otherAccessToken).
+		JsonRequestBody(domain.SetGroupOrderItemsRequest{
+			Items: map[string]int32{fmt.Sprint(t.dishId): 3},
+		}).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(http.StatusBadRequest, resp.StatusCode())
+}
+
+func (t *OrderSuite) Test_Cart_Checkout() {
+	t.allowOrdering()
+
+	_, err := t.cli.Post("/cart/items").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		JsonRequestBody(domain.AddCartItemRequest{DishId: t.dishId, Count: 2}).
+		StatusCodeToError().
+		Do(t.T().Context())
+	t.Require().NoError(err)
+
+	var cart domain.Cart
+	_, err = t.cli.Get("/cart").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		StatusCodeToError().
+		JsonResponseBody(&cart).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().Len(cart.Items, 1)
+	t.Require().EqualValues(2000, cart.Total)
+
+	var checkout domain.ProcessOrderResponse
+	_, err = t.cli.Post("/cart/checkout").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		JsonRequestBody(domain.CheckoutCartRequest{PaymentMethod: "telegram"}).
+		StatusCodeToError().
+		JsonResponseBody(&checkout).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+
+	order, err := t.orderRepo.GetOrder(t.T().Context(), checkout.OrderId)
+	t.Require().NoError(err)
+	t.Require().EqualValues(2000, order.Total)
+
+	_, err = t.cli.Get("/cart").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		StatusCodeToError().
+		JsonResponseBody(&cart).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().Empty(cart.Items)
+
+	resp, err := t.cli.Post("/cart/checkout").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		JsonRequestBody(domain.CheckoutCartRequest{PaymentMethod: "telegram"}).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(http.StatusBadRequest, resp.StatusCode())
+}
+
+func (t *OrderSuite) Test_ProcessOrder_PromoCode() {
+	t.allowOrdering()
+
+	_, err := t.cli.Post("/promo_codes").
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		JsonRequestBody(domain.AddPromoCodeRequest{
+			Code:          "sale10",
+			DiscountType:  entity.PromoCodeDiscountPercent,
+			DiscountValue: 10,
+			PerUserLimit:  1,
+		}).
+		StatusCodeToError().
+		Do(t.T().Context())
+	t.Require().NoError(err)
+
+	req := domain.ProcessOrderRequest{
+		Items:         map[string]int32{fmt.Sprint(t.dishId): 2},
+		PaymentMethod: "telegram",
+		PromoCode:     "SALE10",
+	}
+	var orderResp domain.ProcessOrderResponse
+	_, err = t.cli.Post("/orders").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		JsonRequestBody(req).
+		StatusCodeToError().
+		JsonResponseBody(&orderResp).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+
+	order, err := t.orderRepo.GetOrder(t.T().Context(), orderResp.OrderId)
+	t.Require().NoError(err)
+	t.Require().Equal("SALE10", order.PromoCode)
+	t.Require().EqualValues(200, order.Discount)
+	t.Require().EqualValues(1800, order.Total)
+
+	resp, err := t.cli.Post("/orders").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		JsonRequestBody(req).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(http.StatusBadRequest, resp.StatusCode())
+
+	req.PromoCode = "UNKNOWN"
+	resp, err = t.cli.Post("/orders").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		JsonRequestBody(req).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(http.StatusNotFound, resp.StatusCode())
+}
+
+func (t *OrderSuite) Test_ProcessOrder_RestaurantTerms() {
+	t.allowOrdering()
+	var restaurantId int32
+	t.db.Must().SelectRow(t.T().Context(), &restaurantId, "SELECT restaurant_id FROM dish WHERE id=$1", t.dishId)
+
+	_, err := t.cli.Post(fmt.Sprintf("/restaurants/%d/terms", restaurantId)).
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		JsonRequestBody(domain.SetRestaurantTermsRequest{
+			MinOrderTotal:         2000,
+			DeliveryFee:           300,
+			FreeDeliveryThreshold: 3000,
+		}).
+		StatusCodeToError().
+		Do(t.T().Context())
+	t.Require().NoError(err)
+
+	req := domain.ProcessOrderRequest{
+		Items:         map[string]int32{fmt.Sprint(t.dishId): 1},
+		PaymentMethod: "telegram",
+	}
+	resp, err := t.cli.Post("/orders").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		JsonRequestBody(req).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(http.StatusBadRequest, resp.StatusCode())
+
+	req.Items[fmt.Sprint(t.dishId)] = 2
+	var orderResp domain.ProcessOrderResponse
+	_, err = t.cli.Post("/orders").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		JsonRequestBody(req).
+		StatusCodeToError().
+		JsonResponseBody(&orderResp).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	order, err := t.orderRepo.GetOrder(t.T().Context(), orderResp.OrderId)
+	t.Require().NoError(err)
+	t.Require().EqualValues(300, order.DeliveryFee)
+	t.Require().EqualValues(2300, order.Total)
+
+	req.Items[fmt.Sprint(t.dishId)] = 3
+	_, err = t.cli.Post("/orders").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		JsonRequestBody(req).
+		StatusCodeToError().
+		JsonResponseBody(&orderResp).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	order, err = t.orderRepo.GetOrder(t.T().Context(), orderResp.OrderId)
+	t.Require().NoError(err)
+	t.Require().Zero(order.DeliveryFee)
+	t.Require().EqualValues(3000, order.Total)
+}
+
+func (t *OrderSuite) Test_ProcessOrder_DishOptions() {
+	t.allowOrdering()
+
+	_, err := t.cli.Post(fmt.Sprintf("/dishes/options/%d", t.dishId)).
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		JsonRequestBody(domain.AddDishOptionGroupRequest{
+			Name:     "Размер",
+			Required: true,
+			MaxCount: 1,
+			Options: []domain.AddDishOptionRequest{
+				{Name: "маленькая"},
+				{Name: "большая", PriceDelta: 300},
+			},
+		}).
+		StatusCodeToError().
+		Do(t.T().Context())
+	t.Require().NoError(err)
+
+	var groups []domain.DishOptionGroup
+	_, err = t.cli.Get(fmt.Sprintf("/dishes/options/%d", t.dishId)).
+		StatusCodeToError().
+		JsonResponseBody(&groups).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().Len(groups, 1)
+	t.Require().Len(groups[0].Options, 2)
+	small, large := groups[0].Options[0].Id, groups[0].Options[1].Id
+
+	resp, err := t.cli.Post("/orders").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		JsonRequestBody(domain.ProcessOrderRequest{
+			Items:         map[string]int32{fmt.Sprint(t.dishId): 1},
+			PaymentMethod: "telegram",
+		}).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(http.StatusBadRequest, resp.StatusCode())
+
+	var orderResp domain.ProcessOrderResponse
+	_, err = t.cli.Post("/orders").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		JsonRequestBody(domain.ProcessOrderRequest{
+			ItemsWithOptions: []domain.OrderItemRequest{
+				{DishId: t.dishId, Count: 1, OptionIds: []int32{small}},
+				{DishId: t.dishId, Count: 1, OptionIds: []int32{large}},
+			},
+			PaymentMethod: "telegram",
+		}).
+		StatusCodeToError().
+		JsonResponseBody(&orderResp).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+
+	order, err := t.orderRepo.GetOrder(t.T().Context(), orderResp.OrderId)
+	t.Require().NoError(err)
+	t.Require().Len(order.Items, 2)
+	t.Require().EqualValues(2300, order.Total)
+}
+
+func (t *OrderSuite) Test_CancelOrderItems() {
+	t.allowOrdering()
+	order := &entity.Order{
+		Id:            uuid.NewString(),
+		PaymentMethod: "telegram",
+		UserId:        t.userId,
+		Total:         3000,
+		CreatedAt:     time.Now().UTC(),
+		Status:        entity.OrderItemStatusPaid,
+		Items: entity.OrderItems{
+			{DishId: t.dishId, Count: 2, Price: 2000},
+			{DishId: t.dishId, Count: 1, Price: 1000},
+		},
+	}
+	err := t.orderRepo.InsertOrder(t.T().Context(), order)
+	t.Require().NoError(err)
+	err = t.orderRepo.InsertOrderItems(t.T().Context(), order.Id, order.Items)
+	t.Require().NoError(err)
+
+	stored, err := t.orderRepo.GetOrder(t.T().Context(), order.Id)
+	t.Require().NoError(err)
+	t.Require().Len(stored.Items, 2)
+	itemIds := make(map[int32]int64)
+	for _, item := range stored.Items {
+		t.Require().Equal(entity.ItemStatusActive, item.Status)
+		itemIds[item.Price] = item.Id
+	}
+
+	resp, err := t.cli.Post(fmt.Sprintf("/orders/%s/items/cancel", order.Id)).
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		JsonRequestBody(domain.CancelOrderItemsRequest{ItemIds: []int64{itemIds[1000]}}).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(http.StatusForbidden, resp.StatusCode())
+
+	var cancelResp domain.CancelOrderItemsResponse
+	_, err = t.cli.Post(fmt.Sprintf("/orders/%s/items/cancel", order.Id)).
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		JsonRequestBody(domain.CancelOrderItemsRequest{
+			ItemIds: []int64{itemIds[1000]},
+			Reason:  "блюдо закончилось",
+		}).
+		StatusCodeToError().
+		JsonResponseBody(&cancelResp).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(1000, cancelResp.Refund)
+	t.Require().EqualValues(2000, cancelResp.Total)
+
+	var userOrder domain.UserOrder
+	_, err = t.cli.Get("/orders/details/"+order.Id).
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		StatusCodeToError().
+		JsonResponseBody(&userOrder).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().Equal(entity.OrderItemStatusPaid, userOrder.Status)
+	t.Require().EqualValues(2000, userOrder.Total)
+	t.Require().EqualValues(1000, userOrder.Refunded)
+	for _, item := range userOrder.Items {
+		expected := entity.ItemStatusActive
+		if item.Id == itemIds[1000] {
+			expected = entity.ItemStatusCanceled
+		}
+		t.Require().Equal(expected, item.Status)
+	}
+
+	var errorResp apierrors.Error
+	resp, err = t.cli.Post(fmt.Sprintf("/orders/%s/items/cancel", order.Id)).
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		JsonRequestBody(domain.CancelOrderItemsRequest{ItemIds: []int64{itemIds[1000]}}).
+		JsonResponseBody(&errorResp).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(http.StatusBadRequest, resp.StatusCode())
+	t.Require().EqualValues(domain.ErrCodeItemAlreadyCanceled, errorResp.ErrorCode)
+
+	resp, err = t.cli.Post(fmt.Sprintf("/orders/%s/items/cancel", order.Id)).
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		JsonRequestBody(domain.CancelOrderItemsRequest{ItemIds: []int64{itemIds[2000]}}).
+		JsonResponseBody(&errorResp).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(http.StatusBadRequest, resp.StatusCode())
+	t.Require().EqualValues(domain.ErrCodeOrderItemsCancelAll, errorResp.ErrorCode)
+}
+
+func (t *OrderSuite) Test_CancelOrderItems_Discount() {
+	t.allowOrdering()
+	order := &entity.Order{
+		Id:            uuid.NewString(),
+		PaymentMethod: gateway.PaymentMethod,
+		UserId:        t.userId,
+		Total:         2800,
+		Discount:      200,
+		CreatedAt:     time.Now().UTC(),
+		Status:        entity.OrderItemStatusPaid,
+		Items: entity.OrderItems{
+			{DishId: t.dishId, Count: 1, Price: 1000},
+			{DishId: t.dishId, Count: 1, Price: 1000},
+			{DishId: t.dishId, Count: 1, Price: 1000},
+		},
+	}
+	err := t.orderRepo.InsertOrder(t.T().Context(), order)
+	t.Require().NoError(err)
+	err = t.orderRepo.InsertOrderItems(t.T().Context(), order.Id, order.Items)
+	t.Require().NoError(err)
+	stored, err := t.orderRepo.GetOrder(t.T().Context(), order.Id)
+	t.Require().NoError(err)
+	t.Require().Len(stored.Items, 3)
+
+	cancelItem := func(itemId int64) domain.CancelOrderItemsResponse {
+		var resp domain.CancelOrderItemsResponse
+		_, err := t.cli.Post(fmt.Sprintf("/orders/%s/items/cancel", order.Id)).
+			Header(domain.AuthHeaderName, t.adminAccessToken).
+			JsonRequestBody(domain.CancelOrderItemsRequest{ItemIds: []int64{itemId}}).
+			StatusCodeToError().
+			JsonResponseBody(&resp).
+			Do(t.T().Context())
+		t.Require().NoError(err)
+		return resp
+	}
+
+	// the share is taken from the discount left on the active items, so the rounding doesn't accumulate
+	resp := cancelItem(stored.Items[0].Id)
+	t.Require().EqualValues(934, resp.Refund)
+	t.Require().EqualValues(1866, resp.Total)
+	resp = cancelItem(stored.Items[1].Id)
+	t.Require().EqualValues(933, resp.Refund)
+	t.Require().EqualValues(933, resp.Total)
+
+	// the refunds of the gateway order are returned through the gateway instead of the wallet
+	var refundJobs int
+	t.db.Must().SelectRow(t.T().Context(), &refundJobs,
+		"SELECT count(*) FROM bgjob_job WHERE queue=$1", refund.WorkerQueue)
+	t.Require().EqualValues(2, refundJobs)
+	var wallet domain.Wallet
+	_, err = t.cli.Get("/wallet").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		StatusCodeToError().
+		JsonResponseBody(&wallet).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().Zero(wallet.Balance)
+}
+
+func (t *OrderSuite) Test_SetFulfilmentStatus() {
+	t.allowOrdering()
+	var restaurantId int32
+	t.db.Must().SelectRow(t.T().Context(), &restaurantId, "SELECT restaurant_id FROM dish WHERE id=$1", t.dishId)
+	otherRestaurantId, err := repository.NewRestaurant(t.db.Client).InsertRestaurant(t.T().Context(), fake.It[string]())
+	t.Require().NoError(err)
+	otherDishId, err := repository.NewDish(t.db.Client).InsertDish(t.T().Context(), &entity.InsertDish{
+		Name:         fake.It[string](),
+		Description:  fake.It[string](),
+		Price:        500,
+		RestaurantId: otherRestaurantId,
+	})
+	t.Require().NoError(err)
+
+	var orderResp domain.ProcessOrderResponse
+	_, err = t.cli.Post("/orders").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		JsonRequestBody(domain.ProcessOrderRequest{
+			Items: map[string]int32{
+				fmt.Sprint(t.dishId):    1,
+				fmt.Sprint(otherDishId): 2,
+			},
+			PaymentMethod: "telegram",
+		}).
+		StatusCodeToError().
+		JsonResponseBody(&orderResp).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	err = t.orderStatus.ChangeStatus(t.T().Context(), entity.OrderStatusChange{
+		OrderId: orderResp.OrderId,
+		From:    entity.OrderItemStatusProcess,
+		To:      entity.OrderItemStatusPaid,
+		Actor:   entity.OrderActorSystem,
+	})
+	t.Require().NoError(err)
+	// the status is written only from the expected one
+	err = t.orderRepo.UpdateOrderStatus(t.T().Context(), orderResp.OrderId,
+		entity.OrderItemStatusProcess, entity.OrderItemStatusCanceled)
+	t.Require().ErrorIs(err, domain.ErrOrderStatusConflict)
+
+	var order domain.UserOrder
+	_, err = t.cli.Get("/orders/details/"+orderResp.OrderId).
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		StatusCodeToError().
+		JsonResponseBody(&order).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().Len(order.Fulfilments, 2)
+	for _, fulfilment := range order.Fulfilments {
+		t.Require().Equal(entity.FulfilmentStatusAccepted, fulfilment.Status)
+	}
+
+	setStatus := func(restaurantId int32, status string) (int, domain.UserOrder) {
+		resp, err := t.cli.Post(fmt.Sprintf("/orders/%s/fulfilments", orderResp.OrderId)).
+			Header(domain.AuthHeaderName, t.adminAccessToken).
+			JsonRequestBody(domain.SetFulfilmentStatusRequest{
+				RestaurantId: restaurantId,
+				Status:       status,
+			}).
+			Do(t.T().Context())
+		t.Require().NoError(err)
+
+		var details domain.UserOrder
+		_, err = t.cli.Get("/orders/details/"+orderResp.OrderId).
+			Header(domain.AuthHeaderName, t.adminAccessToken).
+			StatusCodeToError().
+			JsonResponseBody(&details).
+			Do(t.T().Context())
+		t.Require().NoError(err)
+		return resp.StatusCode(), details
+	}
+
+	statusCode, order := setStatus(restaurantId, entity.FulfilmentStatusDelivered)
+	t.Require().EqualValues(http.StatusOK, statusCode)
+	t.Require().Equal(entity.OrderItemStatusPaid, order.Status)
+
+	statusCode, _ = setStatus(restaurantId, entity.FulfilmentStatusReady)
+	t.Require().EqualValues(http.StatusBadRequest, statusCode)
+
+	statusCode, order = setStatus(otherRestaurantId, entity.FulfilmentStatusCanceled)
+	t.Require().EqualValues(http.StatusOK, statusCode)
+	t.Require().Equal(entity.OrderItemStatusSuccess, order.Status)
+	t.Require().EqualValues(1000, order.Total)
+	t.Require().EqualValues(1000, order.Refunded)
+}
+
+func (t *OrderSuite) Test_ChangeOrderStatus_Fulfilments() {
+	t.allowOrdering()
+
+	paidOrder := func() string {
+		var resp domain.ProcessOrderResponse
+		_, err := t.cli.Post("/orders").
+			Header(domain.AuthHeaderName, t.userAccessToken).
+			JsonRequestBody(domain.ProcessOrderRequest{
+				Items:         map[string]int32{fmt.Sprint(t.dishId): 1},
+				PaymentMethod: "telegram",
+			}).
+			StatusCodeToError().
+			JsonResponseBody(&resp).
+			Do(t.T().Context())
+		t.Require().NoError(err)
+		err = t.orderStatus.ChangeStatus(t.T().Context(), entity.OrderStatusChange{
+			OrderId: resp.OrderId,
+			From:    entity.OrderItemStatusProcess,
+			To:      entity.OrderItemStatusPaid,
+			Actor:   entity.OrderActorSystem,
+		})
+		t.Require().NoError(err)
+		return resp.OrderId
+	}
+	// the parts of the order follow the status set by the admin
+	changeStatus := func(orderId string, status string) []domain.OrderFulfilment {
+		_, err := t.cli.Post("/orders/"+orderId+"/status").
+			Header(domain.AuthHeaderName, t.adminAccessToken).
+			JsonRequestBody(domain.SetOrderStatusRequest{Status: status}).
+			StatusCodeToError().
+			Do(t.T().Context())
+		t.Require().NoError(err)
+		var details domain.UserOrder
+		_, err = t.cli.Get("/orders/details/"+orderId).
+			Header(domain.AuthHeaderName, t.adminAccessToken).
+			StatusCodeToError().
+			JsonResponseBody(&details).
+			Do(t.T().Context())
+		t.Require().NoError(err)
+		t.Require().Equal(status, details.Status)
+		return details.Fulfilments
+	}
+
+	fulfilments := changeStatus(paidOrder(), entity.OrderItemStatusSuccess)
+	t.Require().Len(fulfilments, 1)
+	t.Require().Equal(entity.FulfilmentStatusDelivered, fulfilments[0].Status)
+
+	fulfilments = changeStatus(paidOrder(), entity.OrderItemStatusCanceled)
+	t.Require().Len(fulfilments, 1)
+	t.Require().Equal(entity.FulfilmentStatusCanceled, fulfilments[0].Status)
+}
+
+func (t *OrderSuite) Test_RateOrder() {
+	t.allowOrdering()
+	var restaurantId int32
+	t.db.Must().SelectRow(t.T().Context(), &restaurantId, "SELECT restaurant_id FROM dish WHERE id=$1", t.dishId)
+
+	rateOrder := func(orderId string, req domain.RateOrderRequest) (int, int) {
+		resp, err := t.cli.Post(fmt.Sprintf("/orders/%s/rating", orderId)).
+			Header(domain.AuthHeaderName, t.userAccessToken).
+			JsonRequestBody(req).
+			Do(t.T().Context())
+		t.Require().NoError(err)
+		if resp.StatusCode() == http.StatusOK {
+			return resp.StatusCode(), 0
+		}
+		respBody, err := resp.Body()
+		t.Require().NoError(err)
+		var errorResp apierrors.Error
+		err = json.Unmarshal(respBody, &errorResp)
+		t.Require().NoError(err)
+		return resp.StatusCode(), errorResp.ErrorCode
+	}
+
+	paidOrderId := t.insertOrder(t.userId, entity.OrderItemStatusPaid)
+	statusCode, errorCode := rateOrder(paidOrderId, domain.RateOrderRequest{Score: 5})
+	t.Require().EqualValues(http.StatusBadRequest, statusCode)
+	t.Require().EqualValues(domain.ErrCodeOrderRatingForbidden, errorCode)
+
+	orderId := t.insertOrder(t.userId, entity.OrderItemStatusSuccess)
+	statusCode, errorCode = rateOrder(orderId, domain.RateOrderRequest{
+		Score:       2,
+		Restaurants: []domain.RestaurantScore{{RestaurantId: restaurantId + 1000, Score: 2}},
+	})
+	t.Require().EqualValues(http.StatusNotFound, statusCode)
+	t.Require().EqualValues(domain.ErrCodeFulfilmentNotFound, errorCode)
+
+	statusCode, _ = rateOrder(orderId, domain.RateOrderRequest{
+		Score:       2,
+		Restaurants: []domain.RestaurantScore{{RestaurantId: restaurantId, Score: 1}},
+		Comment:     "остыло",
+	})
+	t.Require().EqualValues(http.StatusOK, statusCode)
+	// the comment is kept when it isn't passed
+	statusCode, _ = rateOrder(orderId, domain.RateOrderRequest{Score: 1})
+	t.Require().EqualValues(http.StatusOK, statusCode)
+
+	var rating domain.OrderRating
+	_, err := t.cli.Get(fmt.Sprintf("/orders/details/%s/rating", orderId)).
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		StatusCodeToError().
+		JsonResponseBody(&rating).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(1, rating.Score)
+	t.Require().Equal("остыло", rating.Comment)
+	t.Require().Len(rating.Restaurants, 1)
+	t.Require().EqualValues(1, rating.Restaurants[0].Score)
+
+	var restaurant domain.Restaurant
+	_, err = t.cli.Get(fmt.Sprintf("/restaurants/%d", restaurantId)).
+		StatusCodeToError().
+		JsonResponseBody(&restaurant).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().InDelta(1, restaurant.Rating, 0.001)
+	t.Require().EqualValues(1, restaurant.RatingsCount)
+
+	var lowRatings []domain.LowRating
+	_, err = t.cli.Get("/ratings/low").
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		StatusCodeToError().
+		JsonResponseBody(&lowRatings).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().Len(lowRatings, 1)
+	t.Require().Equal(orderId, lowRatings[0].OrderId)
+	t.Require().Equal(t.userId, lowRatings[0].UserId)
+}
+
+func (t *OrderSuite) Test_ProcessOrder_OrderLimits() {
+	t.allowOrdering()
+
+	processOrder := func(count int32) (int, int) {
+		resp, err := t.cli.Post("/orders").
+			Header(domain.AuthHeaderName, t.userAccessToken).
+			JsonRequestBody(domain.ProcessOrderRequest{
+				Items:         map[string]int32{fmt.Sprint(t.dishId): count},
+				PaymentMethod: "telegram",
+			}).
+			Do(t.T().Context())
+		t.Require().NoError(err)
+		if resp.StatusCode() == http.StatusOK {
+			return resp.StatusCode(), 0
+		}
+		respBody, err := resp.Body()
+		t.Require().NoError(err)
+		var errorResp apierrors.Error
+		err = json.Unmarshal(respBody, &errorResp)
+		t.Require().NoError(err)
+		return resp.StatusCode(), errorResp.ErrorCode
+	}
+
+	_, err := t.cli.Post("/order_limits").
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		JsonRequestBody(domain.OrderLimits{MaxDishCount: 3}).
+		StatusCodeToError().
+		Do(t.T().Context())
+	t.Require().NoError(err)
+
+	statusCode, errorCode := processOrder(5)
+	t.Require().EqualValues(http.StatusBadRequest, statusCode)
+	t.Require().EqualValues(domain.ErrCodeOrderLimitExceeded, errorCode)
+
+	// the user limits replace the global ones
+	_, err = t.cli.Post("/order_limits/users/"+t.userId).
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		JsonRequestBody(domain.SetUserOrderLimitsRequest{OrdersPerDay: 1}).
+		StatusCodeToError().
+		Do(t.T().Context())
+	t.Require().NoError(err)
+
+	var limits domain.UserOrderLimits
+	_, err = t.cli.Get("/order_limits/users/"+t.userId).
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		StatusCodeToError().
+		JsonResponseBody(&limits).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().True(limits.Overridden)
+	t.Require().EqualValues(1, limits.OrdersPerDay)
+	t.Require().EqualValues(0, limits.MaxDishCount)
+
+	statusCode, _ = processOrder(5)
+	t.Require().EqualValues(http.StatusOK, statusCode)
+	statusCode, errorCode = processOrder(1)
+	t.Require().EqualValues(http.StatusBadRequest, statusCode)
+	t.Require().EqualValues(domain.ErrCodeOrderLimitExceeded, errorCode)
+
+	_, err = t.cli.Delete("/order_limits/users/"+t.userId).
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		StatusCodeToError().
+		Do(t.T().Context())
+	t.Require().NoError(err)
+
+	statusCode, _ = processOrder(1)
+	t.Require().EqualValues(http.StatusOK, statusCode)
+}
+
+func (t *OrderSuite) Test_ProcessOrder_Corporate() {
+	t.allowOrdering()
+
+	processOrder := func(count int32) (int, int) {
+		resp, err := t.cli.Post("/orders").
+			Header(domain.AuthHeaderName, t.userAccessToken).
+			JsonRequestBody(domain.ProcessOrderRequest{
+				Items:         map[string]int32{fmt.Sprint(t.dishId): count},
+				PaymentMethod: "corporate",
+			}).
+			Do(t.T().Context())
+		t.Require().NoError(err)
+		if resp.StatusCode() == http.StatusOK {
+			return resp.StatusCode(), 0
+		}
+		respBody, err := resp.Body()
+		t.Require().NoError(err)
+		var errorResp apierrors.Error
+		err = json.Unmarshal(respBody, &errorResp)
+		t.Require().NoError(err)
+		return resp.StatusCode(), errorResp.ErrorCode
+	}
+
+	statusCode, errorCode := processOrder(1)
+	t.Require().EqualValues(http.StatusBadRequest, statusCode)
+	t.Require().EqualValues(domain.ErrCodeUserCompanyNotFound, errorCode)
+
+	var company domain.AddCompanyResponse
+	_, err := t.cli.Post("/companies").
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		JsonRequestBody(domain.AddCompanyRequest{Name: "test company", CreditLimit: 1500}).
+		StatusCodeToError().
+		JsonResponseBody(&company).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	companyPath := fmt.Sprintf("/companies/%d", company.Id)
+
+	_, err = t.cli.Post(companyPath+"/users").
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		JsonRequestBody(domain.AddCompanyUserRequest{UserId: t.userId}).
+		StatusCodeToError().
+		Do(t.T().Context())
+	t.Require().NoError(err)
+
+	statusCode, errorCode = processOrder(2)
+	t.Require().EqualValues(http.StatusBadRequest, statusCode)
+	t.Require().EqualValues(domain.ErrCodeCreditLimitExceeded, errorCode)
+
+	statusCode, _ = processOrder(1)
+	t.Require().EqualValues(http.StatusOK, statusCode)
+
+	orderId := t.insertOrder(t.userId, entity.OrderItemStatusPaid)
+	t.db.Must().Exec(t.T().Context(), "UPDATE orders SET company_id=$1 WHERE id=$2", company.Id, orderId)
+
+	var statement domain.CompanyStatement
+	_, err = t.cli.Get(companyPath+"/statement").
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		QueryParams(map[string]any{"month": time.Now().Format(entity.MonthFormat)}).
+		StatusCodeToError().
+		JsonResponseBody(&statement).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(company.Id, statement.CompanyId)
+	t.Require().Len(statement.Orders, 1)
+	t.Require().EqualValues(orderId, statement.Orders[0].OrderId)
+	t.Require().EqualValues(2000, statement.Total)
+}
+
+func (t *OrderSuite) Test_ProcessOrder_Cash() {
+	t.allowOrdering()
+
+	var resp domain.ProcessOrderResponse
+	_, err := t.cli.Post("/orders").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		JsonRequestBody(domain.ProcessOrderRequest{
+			Items:         map[string]int32{fmt.Sprint(t.dishId): 2},
+			PaymentMethod: "cash",
+		}).
+		StatusCodeToError().
+		JsonResponseBody(&resp).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().NotEmpty(resp.OrderId)
+	t.Require().Empty(resp.PaymentUrl)
+
+	order, err := t.orderRepo.GetOrder(t.T().Context(), resp.OrderId)
+	t.Require().NoError(err)
+	t.Require().Equal(entity.OrderItemStatusProcess, order.Status)
+
+	// the order waits for payment until pickup and doesn't expire
+	var expirationJobs, cashJobs int
+	t.db.Must().SelectRow(t.T().Context(), &expirationJobs,
+		"SELECT count(*) FROM bgjob_job WHERE queue='payment-expiration' AND id=$1", resp.OrderId)
+	t.Require().Zero(expirationJobs)
+	t.db.Must().SelectRow(t.T().Context(), &cashJobs,
+		"SELECT count(*) FROM bgjob_job WHERE queue='cash-payment' AND id=$1", resp.OrderId)
+	t.Require().EqualValues(1, cashJobs)
+
+	_, err = t.cli.Post("/orders/"+resp.OrderId+"/cancel").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		StatusCodeToError().
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.db.Must().SelectRow(t.T().Context(), &cashJobs,
+		"SELECT count(*) FROM bgjob_job WHERE queue='cash-payment' AND id=$1", resp.OrderId)
+	t.Require().Zero(cashJobs)
+}
+
+func (t *OrderSuite) Test_Wallet() {
+	t.allowOrdering()
+
+	getWallet := func() int64 {
+		var wallet domain.Wallet
+		_, err := t.cli.Get("/wallet").
+			Header(domain.AuthHeaderName, t.userAccessToken).
+			StatusCodeToError().
+			JsonResponseBody(&wallet).
+			Do(t.T().Context())
+		t.Require().NoError(err)
+		return wallet.Balance
+	}
+	adjust := func(amount int64) (int, int) {
+		resp, err := t.cli.Post("/wallet/users/"+t.userId+"/adjust").
+			Header(domain.AuthHeaderName, t.adminAccessToken).
+			JsonRequestBody(domain.AdjustWalletRequest{Amount: amount, Comment: "test"}).
+			Do(t.T().Context())
+		t.Require().NoError(err)
+		if resp.StatusCode() == http.StatusOK {
+			return resp.StatusCode(), 0
+		}
+		respBody, err := resp.Body()
+		t.Require().NoError(err)
+		var errorResp apierrors.Error
+		err = json.Unmarshal(respBody, &errorResp)
+		t.Require().NoError(err)
+		return resp.StatusCode(), errorResp.ErrorCode
+	}
+	t.Require().Zero(getWallet())
+
+	resp, err := t.cli.Post("/orders").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		JsonRequestBody(domain.ProcessOrderRequest{
+			Items:         map[string]int32{fmt.Sprint(t.dishId): 1},
+			PaymentMethod: "wallet",
+		}).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(http.StatusBadRequest, resp.StatusCode())
+	respBody, err := resp.Body()
+	t.Require().NoError(err)
+	var errorResp apierrors.Error
+	err = json.Unmarshal(respBody, &errorResp)
+	t.Require().NoError(err)
+	t.Require().EqualValues(domain.ErrCodeInsufficientFunds, errorResp.ErrorCode)
+
+	statusCode, _ := adjust(500)
+	t.Require().EqualValues(http.StatusOK, statusCode)
+	statusCode, errorCode := adjust(-1000)
+	t.Require().EqualValues(http.StatusBadRequest, statusCode)
+	t.Require().EqualValues(domain.ErrCodeInsufficientFunds, errorCode)
+	t.Require().EqualValues(500, getWallet())
+
+	// the canceled paid order is refunded to the wallet
+	orderId := t.insertOrder(t.userId, entity.OrderItemStatusPaid)
+	_, err = t.cli.Post("/orders/"+orderId+"/status").
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		JsonRequestBody(domain.SetOrderStatusRequest{Status: entity.OrderItemStatusCanceled}).
+		StatusCodeToError().
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(2500, getWallet())
+	order, err := t.orderRepo.GetOrder(t.T().Context(), orderId)
+	t.Require().NoError(err)
+	t.Require().Equal(entity.RefundStatusRefunded, order.RefundStatus)
+
+	var transactions []domain.WalletTransaction
+	_, err = t.cli.Get("/wallet/transactions").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		StatusCodeToError().
+		JsonResponseBody(&transactions).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().Len(transactions, 2)
+	t.Require().Equal(entity.WalletTransactionRefund, transactions[0].Type)
+	t.Require().Equal(orderId, transactions[0].OrderId)
+	t.Require().EqualValues(2000, transactions[0].Amount)
+	t.Require().EqualValues(2500, transactions[0].BalanceAfter)
+	t.Require().Equal(entity.WalletTransactionAdjustment, transactions[1].Type)
+
+	_, err = t.db.Client.Exec(t.T().Context(), "UPDATE wallet_transactions SET amount=1")
+	t.Require().Error(err)
+
+	_, err = t.cli.Post("/orders").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		JsonRequestBody(domain.ProcessOrderRequest{
+			Items:         map[string]int32{fmt.Sprint(t.dishId): 2},
+			PaymentMethod: "wallet",
+		}).
+		StatusCodeToError().
+		Do(t.T().Context())
+	t.Require().NoError(err)
+}
+
+func (t *OrderSuite) Test_ProcessOrder_Gateway() {
+	t.allowOrdering()
+
+	processOrder := func() domain.ProcessOrderResponse {
+		var resp domain.ProcessOrderResponse
+		_, err := t.cli.Post("/orders").
+			Header(domain.AuthHeaderName, t.userAccessToken).
+			JsonRequestBody(domain.ProcessOrderRequest{
+				Items:         map[string]int32{fmt.Sprint(t.dishId): 2},
+				PaymentMethod: gateway.PaymentMethod,
+			}).
+			StatusCodeToError().
+			JsonResponseBody(&resp).
+			Do(t.T().Context())
+		t.Require().NoError(err)
+		t.Require().NotEmpty(resp.PaymentUrl)
+		return resp
+	}
+
+	resp := processOrder()
+	paymentId := path.Base(resp.PaymentUrl)
+	payment, ok := t.gateway.Payment(paymentId)
+	t.Require().True(ok)
+	t.Require().Equal(gateway.StatusPending, payment.Status)
+	t.Require().Equal(resp.OrderId, payment.Metadata.OrderId)
+	t.Require().EqualValues(2000, payment.Amount.Value)
+
+	// the webhook with the wrong signature is rejected
+	webhookResp, err := t.cli.Post("/payments/gateway/webhook").
+		Header(gateway.SignatureHeader, gateway.Sign("wrong_secret", []byte("{}"))).
+		RequestBody([]byte("{}")).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(http.StatusUnauthorized, webhookResp.StatusCode())
+
+	err = t.gateway.Complete(t.T().Context(), paymentId, true)
+	t.Require().NoError(err)
+	order, err := t.orderRepo.GetOrder(t.T().Context(), resp.OrderId)
+	t.Require().NoError(err)
+	t.Require().Equal(entity.OrderItemStatusPaid, order.Status)
+	t.Require().Equal(paymentId, order.PaymentChargeId)
+
+	// the canceled paid order is refunded through the gateway by the refund worker
+	_, err = t.cli.Post("/orders/"+resp.OrderId+"/status").
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		JsonRequestBody(domain.SetOrderStatusRequest{Status: entity.OrderItemStatusCanceled}).
+		StatusCodeToError().
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	order, err = t.orderRepo.GetOrder(t.T().Context(), resp.OrderId)
+	t.Require().NoError(err)
+	t.Require().Equal(entity.RefundStatusPending, order.RefundStatus)
+	var refundJobs int
+	t.db.Must().SelectRow(t.T().Context(), &refundJobs,
+		"SELECT count(*) FROM bgjob_job WHERE queue=$1 AND id=$2", refund.WorkerQueue, refund.WorkerQueue+"_"+resp.OrderId)
+	t.Require().EqualValues(1, refundJobs)
+	var wallet domain.Wallet
+	_, err = t.cli.Get("/wallet").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		StatusCodeToError().
+		JsonResponseBody(&wallet).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().Zero(wallet.Balance)
+
+	resp = processOrder()
+	err = t.gateway.Complete(t.T().Context(), path.Base(resp.PaymentUrl), false)
+	t.Require().NoError(err)
+	order, err = t.orderRepo.GetOrder(t.T().Context(), resp.OrderId)
+	t.Require().NoError(err)
+	t.Require().Equal(entity.OrderItemStatusCanceled, order.Status)
+
+	// the charge differing from the order total doesn't pay the order, but is kept for the reconciliation
+	resp = processOrder()
+	err = t.gateway.CompleteWithAmount(t.T().Context(), path.Base(resp.PaymentUrl), 1500)
+	t.Require().NoError(err)
+	order, err = t.orderRepo.GetOrder(t.T().Context(), resp.OrderId)
+	t.Require().NoError(err)
+	t.Require().Equal(entity.OrderItemStatusProcess, order.Status)
+	var charged int64
+	t.db.Must().SelectRow(t.T().Context(), &charged,
+		"SELECT amount FROM payments WHERE order_id=$1 AND status=$2", resp.OrderId, entity.PaymentStatusSucceeded)
+	t.Require().EqualValues(1500, charged)
+	var report domain.PaymentDiscrepanciesReport
+	_, err = t.cli.Get("/payments/discrepancies").
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		StatusCodeToError().
+		JsonResponseBody(&report).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().Len(report.Discrepancies, 1)
+	t.Require().Equal(resp.OrderId, report.Discrepancies[0].OrderId)
+	t.Require().Equal(entity.DiscrepancyAmountMismatch, report.Discrepancies[0].Kind)
+}
+
+func (t *OrderSuite) Test_ProcessOrder_TelegramStars() {
+	t.allowOrdering()
+
+	req := domain.ProcessOrderRequest{
+		Items:         map[string]int32{fmt.Sprint(t.dishId): 2},
+		PaymentMethod: telegram_stars.PaymentMethod,
+	}
+	// paying with stars is unavailable until the admin sets the rate
+	resp, err := t.cli.Post("/orders").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		JsonRequestBody(req).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(http.StatusBadRequest, resp.StatusCode())
+	respBody, err := resp.Body()
+	t.Require().NoError(err)
+	var errorResp apierrors.Error
+	err = json.Unmarshal(respBody, &errorResp)
+	t.Require().NoError(err)
+	t.Require().EqualValues(domain.ErrCodeStarsRateNotSet, errorResp.ErrorCode)
+
+	_, err = t.cli.Post("/payments/stars_rate").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		JsonRequestBody(domain.SetStarsRateRequest{StarPrice: 150}).
+		StatusCodeToError().
+		Do(t.T().Context())
+	t.Require().Error(err)
+
+	_, err = t.cli.Post("/payments/stars_rate").
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		JsonRequestBody(domain.SetStarsRateRequest{StarPrice: 150}).
+		StatusCodeToError().
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	var rate domain.StarsRate
+	_, err = t.cli.Get("/payments/stars_rate").
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		StatusCodeToError().
+		JsonResponseBody(&rate).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	t.Require().EqualValues(150, rate.StarPrice)
+	t.Require().EqualValues(14, entity.StarsRate{StarPrice: rate.StarPrice}.StarsAmount(2000))
+
+	var processResp domain.ProcessOrderResponse
+	_, err = t.cli.Post("/orders").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		JsonRequestBody(req).
+		StatusCodeToError().
+		JsonResponseBody(&processResp).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	var paymentJobs int
+	t.db.Must().SelectRow(t.T().Context(), &paymentJobs,
+		"SELECT count(*) FROM bgjob_job WHERE queue=$1 AND id=$2", telegram_stars.WorkerQueue, processResp.OrderId)
+	t.Require().EqualValues(1, paymentJobs)
+
+	// the pending payment is recorded in stars as it is invoiced
+	var pending entity.Payment
+	t.db.Must().SelectRow(t.T().Context(), &pending,
+		"SELECT amount, currency FROM payments WHERE order_id=$1", processResp.OrderId)
+	t.Require().EqualValues(14, pending.Amount)
+	t.Require().Equal(entity.CurrencyStars, pending.Currency)
+}
+
+func (t *OrderSuite) Test_Payments() {
+	t.allowOrdering()
+
+	processOrder := func() domain.ProcessOrderResponse {
+		var resp domain.ProcessOrderResponse
+		_, err := t.cli.Post("/orders").
+			Header(domain.AuthHeaderName, t.userAccessToken).
+			JsonRequestBody(domain.ProcessOrderRequest{
+				Items:         map[string]int32{fmt.Sprint(t.dishId): 2},
+				PaymentMethod: gateway.PaymentMethod,
+			}).
+			StatusCodeToError().
+			JsonResponseBody(&resp).
+			Do(t.T().Context())
+		t.Require().NoError(err)
+		return resp
+	}
+	getPayments := func(orderId string) []domain.Payment {
+		var resp domain.GetPaymentsResponse
+		_, err := t.cli.Get("/payments").
+			Header(domain.AuthHeaderName, t.adminAccessToken).
+			QueryParams(map[string]any{"orderId": orderId}).
+			StatusCodeToError().
+			JsonResponseBody(&resp).
+			Do(t.T().Context())
+		t.Require().NoError(err)
+		t.Require().EqualValues(len(resp.Payments), resp.Total)
+		return resp.Payments
+	}
+
+	_, err := t.cli.Get("/payments").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		StatusCodeToError().
+		Do(t.T().Context())
+	t.Require().Error(err)
+
+	paid := processOrder()
+	payments := getPayments(paid.OrderId)
+	t.Require().Len(payments, 1)
+	t.Require().Equal(entity.PaymentStatusPending, payments[0].Status)
+	t.Require().Equal(gateway.PaymentMethod, payments[0].Method)
+	t.Require().EqualValues(2000, payments[0].Amount)
+	t.Require().Equal(entity.CurrencyRub, payments[0].Currency)
+	t.Require().Empty(payments[0].Payload)
+
+	paymentId := path.Base(paid.PaymentUrl)
+	err = t.gateway.Complete(t.T().Context(), paymentId, true)
+	t.Require().NoError(err)
+	payments = getPayments(paid.OrderId)
+	t.Require().Len(payments, 1)
+	t.Require().Equal(entity.PaymentStatusSucceeded, payments[0].Status)
+	t.Require().Equal(paymentId, payments[0].ProviderPaymentChargeId)
+	t.Require().Empty(payments[0].TelegramPaymentChargeId)
+	var event gateway.WebhookEvent
+	err = json.Unmarshal(payments[0].Payload, &event)
+	t.Require().NoError(err)
+	t.Require().Equal(gateway.EventPaymentSucceeded, event.Event)
+
+	canceled := processOrder()
+	err = t.gateway.Complete(t.T().Context(), path.Base(canceled.PaymentUrl), false)
+	t.Require().NoError(err)
+	payments = getPayments(canceled.OrderId)
+	t.Require().Len(payments, 1)
+	t.Require().Equal(entity.PaymentStatusFailed, payments[0].Status)
+	t.Require().NotEmpty(payments[0].Payload)
+}
+
+func (t *OrderSuite) Test_PaymentDiscrepancies() {
+	t.allowOrdering()
+
+	var scheduledJobs int
+	t.db.Must().SelectRow(t.T().Context(), &scheduledJobs,
+		"SELECT count(*) FROM bgjob_job WHERE queue=$1", reconciliation.WorkerQueue)
+	t.Require().EqualValues(1, scheduledJobs)
+
+	processOrder := func() (string, string) {
+		var resp domain.ProcessOrderResponse
+		_, err := t.cli.Post("/orders").
+			Header(domain.AuthHeaderName, t.userAccessToken).
+			JsonRequestBody(domain.ProcessOrderRequest{
+				Items:         map[string]int32{fmt.Sprint(t.dishId): 2},
+				PaymentMethod: gateway.PaymentMethod,
+			}).
+			StatusCodeToError().
+			JsonResponseBody(&resp).
+			Do(t.T().Context())
+		t.Require().NoError(err)
+		return resp.OrderId, path.Base(resp.PaymentUrl)
+	}
+
+	// the payment confirmed by the provider after the order had expired
+	lateOrderId, latePaymentId := processOrder()
+	err := t.orderStatus.ChangeStatus(t.T().Context(), entity.OrderStatusChange{
+		OrderId: lateOrderId,
+		From:    entity.OrderItemStatusProcess,
+		To:      entity.OrderItemStatusCanceled,
+		Actor:   entity.OrderActorSystem,
+		Reason:  "истёк срок оплаты",
+	})
+	t.Require().NoError(err)
+	err = t.gateway.Complete(t.T().Context(), latePaymentId, true)
+	t.Require().NoError(err)
+
+	// the paid order without the payment record
+	missingOrderId, missingPaymentId := processOrder()
+	err = t.gateway.Complete(t.T().Context(), missingPaymentId, true)
+	t.Require().NoError(err)
+	t.db.Must().Exec(t.T().Context(), "DELETE FROM payments WHERE order_id=$1", missingOrderId)
+
+	// the paid amount differs from the order total
+	mismatchOrderId, mismatchPaymentId := processOrder()
+	err = t.gateway.Complete(t.T().Context(), mismatchPaymentId, true)
+	t.Require().NoError(err)
+	t.db.Must().Exec(t.T().Context(), "UPDATE payments SET amount=1 WHERE order_id=$1", mismatchOrderId)
+
+	// the consistent order isn't reported
+	okOrderId, okPaymentId := processOrder()
+	err = t.gateway.Complete(t.T().Context(), okPaymentId, true)
+	t.Require().NoError(err)
+
+	var report domain.PaymentDiscrepanciesReport
+	_, err = t.cli.Get("/payments/discrepancies").
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		StatusCodeToError().
+		JsonResponseBody(&report).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	kinds := make(map[string]string)
+	for _, discrepancy := range report.Discrepancies {
+		kinds[discrepancy.OrderId] = discrepancy.Kind
+	}
+	t.Require().Equal(map[string]string{
+		lateOrderId:     entity.DiscrepancyCanceledOrderPayment,
+		missingOrderId:  entity.DiscrepancyPaidWithoutPayment,
+		mismatchOrderId: entity.DiscrepancyAmountMismatch,
+	}, kinds)
+	t.Require().Len(report.Discrepancies, 3)
+	t.Require().NotContains(kinds, okOrderId)
+
+	_, err = t.cli.Get("/payments/discrepancies").
+		Header(domain.AuthHeaderName, t.adminAccessToken).
+		QueryParams(map[string]any{"from": time.Now().Add(time.Hour).Format(time.RFC3339)}).
+		StatusCodeToError().
+		Do(t.T().Context())
+	t.Require().Error(err)
+}
+
+func (t *OrderSuite) Test_PaymentExpirationReminder() {
+	bgjobCli := bgjob.NewClient(bgjob.NewPgStore(t.db.Client.DB.DB))
+	jobRepo := repository.NewJob(t.db.Client)
+	getJobTypes := func() []string {
+		var types []string
+		t.db.Must().Select(t.T().Context(), &types,
+			"SELECT type FROM bgjob_job WHERE queue=$1 ORDER BY next_run_at", expiration.WorkerQueue)
+		return types
+	}
+
+	orderId := uuid.NewString()
+	expirationService := expiration.NewExpiration(bgjobCli, jobRepo, 30*time.Minute, 10*time.Minute)
+	err := expirationService.AddOrder(t.T().Context(), orderId)
+	t.Require().NoError(err)
+	t.Require().Equal([]string{expiration.ReminderType, expiration.WorkerType}, getJobTypes())
+
+	err = expirationService.RemoveOrder(t.T().Context(), orderId)
+	t.Require().NoError(err)
+	t.Require().Empty(getJobTypes())
+
+	// the reminder isn't sent if it doesn't fit into the expiration delay
+	expirationService = expiration.NewExpiration(bgjobCli, jobRepo, 10*time.Minute, 10*time.Minute)
+	err = expirationService.AddOrder(t.T().Context(), orderId)
+	t.Require().NoError(err)
+	t.Require().Equal([]string{expiration.WorkerType}, getJobTypes())
+}
+
+func (t *OrderSuite) Test_OrderInvoiceMessage() {
+	t.allowOrdering()
+
+	var resp domain.ProcessOrderResponse
+	_, err := t.cli.Post("/orders").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		JsonRequestBody(domain.ProcessOrderRequest{
+			Items:         map[string]int32{fmt.Sprint(t.dishId): 1},
+			PaymentMethod: "telegram",
+		}).
+		StatusCodeToError().
+		JsonResponseBody(&resp).
+		Do(t.T().Context())
+	t.Require().NoError(err)
+
+	// the invoice isn't sent until the payment worker runs
+	message, err := t.orderRepo.GetOrderInvoiceMessage(t.T().Context(), resp.OrderId)
+	t.Require().NoError(err)
+	t.Require().Zero(message)
+
+	expected := entity.InvoiceMessage{ChatId: 12345, MessageId: 42}
+	err = t.orderRepo.SetOrderInvoiceMessage(t.T().Context(), resp.OrderId, expected)
+	t.Require().NoError(err)
+	message, err = t.orderRepo.GetOrderInvoiceMessage(t.T().Context(), resp.OrderId)
+	t.Require().NoError(err)
+	t.Require().Equal(expected, message)
+
+	_, err = t.orderRepo.GetOrderInvoiceMessage(t.T().Context(), uuid.NewString())
+	t.Require().ErrorIs(err, domain.ErrOrderNotFound)
+
+	// the canceled order invoice is deleted from the chat by the job enqueued with the cancellation
+	_, err = t.cli.Post("/orders/"+resp.OrderId+"/cancel").
+		Header(domain.AuthHeaderName, t.userAccessToken).
+		StatusCodeToError().
+		Do(t.T().Context())
+	t.Require().NoError(err)
+	var invoiceJobs int
+	t.db.Must().SelectRow(t.T().Context(), &invoiceJobs,
+		"SELECT count(*) FROM bgjob_job WHERE queue=$1 AND id=$2", invoice.WorkerQueue, invoice.WorkerQueue+"_"+resp.OrderId)
+	t.Require().EqualValues(1, invoiceJobs)
+}
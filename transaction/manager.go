@@ -4,6 +4,7 @@ import (
 	"context"
 	"dishes-service-backend/repository"
 	"dishes-service-backend/service"
+	"dishes-service-backend/service/payment/corporate"
 	"github.com/Falokut/go-kit/db"
 )
 
@@ -64,17 +65,31 @@ func (m Manager) EditDishTx(ctx context.Context, editDishTx func(ctx context.Con
 type processOrderTx struct {
 	repository.Dish
 	repository.Order
+	repository.DeliverySlot
+	repository.PromoCode
+	repository.Restaurant
+	repository.DishOption
+	repository.OrderLimits
+	repository.Payment
+}
+
+func newProcessOrderTx(tx *db.Tx) processOrderTx {
+	return processOrderTx{
+		Dish:         repository.NewDish(tx),
+		Order:        repository.NewOrder(tx),
+		DeliverySlot: repository.NewDeliverySlot(tx),
+		PromoCode:    repository.NewPromoCode(tx),
+		Restaurant:   repository.NewRestaurant(tx),
+		DishOption:   repository.NewDishOption(tx),
+		OrderLimits:  repository.NewOrderLimits(tx),
+		Payment:      repository.NewPayment(tx),
+	}
 }
 
 func (m Manager) ProcessOrderTx(ctx context.Context, orderTx func(ctx context.Context, tx service.ProcessOrderTx) error) error {
 	return m.db.RunInTransaction(ctx,
 		func(ctx context.Context, tx *db.Tx) error {
-			return orderTx(ctx,
-				processOrderTx{
-					Dish:  repository.NewDish(tx),
-					Order: repository.NewOrder(tx),
-				},
-			)
+			return orderTx(ctx, newProcessOrderTx(tx))
 		},
 	)
 }
@@ -94,3 +109,195 @@ func (m Manager) SetOrderingAllowedTx(ctx context.Context, orderTx func(ctx cont
 		},
 	)
 }
+
+type orderStatusTx struct {
+	repository.Order
+	repository.Wallet
+	repository.Payment
+	repository.Job
+}
+
+func newOrderStatusTx(tx *db.Tx) orderStatusTx {
+	return orderStatusTx{
+		Order:   repository.NewOrder(tx),
+		Wallet:  repository.NewWallet(tx),
+		Payment: repository.NewPayment(tx),
+		Job:     repository.NewJob(tx),
+	}
+}
+
+func (m Manager) OrderStatusTx(ctx context.Context, statusTx func(ctx context.Context, tx service.OrderStatusTx) error) error {
+	return m.db.RunInTransaction(ctx,
+		func(ctx context.Context, tx *db.Tx) error {
+			return statusTx(ctx,
+				newOrderStatusTx(tx),
+			)
+		},
+	)
+}
+
+func (m Manager) OrderPaymentTx(ctx context.Context, paymentTx func(ctx context.Context, tx service.OrderPaymentTx) error) error {
+	return m.db.RunInTransaction(ctx,
+		func(ctx context.Context, tx *db.Tx) error {
+			return paymentTx(ctx,
+				newOrderStatusTx(tx),
+			)
+		},
+	)
+}
+
+func (m Manager) CancelOrderItemsTx(ctx context.Context, cancelTx func(ctx context.Context, tx service.CancelOrderItemsTx) error) error {
+	return m.db.RunInTransaction(ctx,
+		func(ctx context.Context, tx *db.Tx) error {
+			return cancelTx(ctx,
+				newOrderStatusTx(tx),
+			)
+		},
+	)
+}
+
+func (m Manager) SetFulfilmentStatusTx(ctx context.Context, statusTx func(ctx context.Context, tx service.SetFulfilmentStatusTx) error) error {
+	return m.db.RunInTransaction(ctx,
+		func(ctx context.Context, tx *db.Tx) error {
+			return statusTx(ctx,
+				newOrderStatusTx(tx),
+			)
+		},
+	)
+}
+
+type groupOrderItemsTx struct {
+	repository.Dish
+	repository.GroupOrder
+}
+
+func (m Manager) GroupOrderItemsTx(ctx context.Context, itemsTx func(ctx context.Context, tx service.GroupOrderItemsTx) error) error {
+	return m.db.RunInTransaction(ctx,
+		func(ctx context.Context, tx *db.Tx) error {
+			return itemsTx(ctx,
+				groupOrderItemsTx{
+					Dish:       repository.NewDish(tx),
+					GroupOrder: repository.NewGroupOrder(tx),
+				},
+			)
+		},
+	)
+}
+
+type lockGroupOrderTx struct {
+	processOrderTx
+	repository.GroupOrder
+}
+
+func (m Manager) LockGroupOrderTx(ctx context.Context, lockTx func(ctx context.Context, tx service.LockGroupOrderTx) error) error {
+	return m.db.RunInTransaction(ctx,
+		func(ctx context.Context, tx *db.Tx) error {
+			return lockTx(ctx,
+				lockGroupOrderTx{
+					processOrderTx: newProcessOrderTx(tx),
+					GroupOrder:     repository.NewGroupOrder(tx),
+				},
+			)
+		},
+	)
+}
+
+type checkoutCartTx struct {
+	processOrderTx
+	repository.Cart
+}
+
+func (m Manager) CheckoutCartTx(ctx context.Context, cartTx func(ctx context.Context, tx service.CheckoutCartTx) error) error {
+	return m.db.RunInTransaction(ctx,
+		func(ctx context.Context, tx *db.Tx) error {
+			return cartTx(ctx,
+				checkoutCartTx{
+					processOrderTx: newProcessOrderTx(tx),
+					Cart:           repository.NewCart(tx),
+				},
+			)
+		},
+	)
+}
+
+type promoCodeTx struct {
+	repository.PromoCode
+}
+
+func (m Manager) AddPromoCodeTx(ctx context.Context, addTx func(ctx context.Context, tx service.AddPromoCodeTx) error) error {
+	return m.db.RunInTransaction(ctx,
+		func(ctx context.Context, tx *db.Tx) error {
+			return addTx(ctx,
+				promoCodeTx{
+					PromoCode: repository.NewPromoCode(tx),
+				},
+			)
+		},
+	)
+}
+
+type dishOptionTx struct {
+	repository.DishOption
+}
+
+func (m Manager) AddDishOptionGroupTx(ctx context.Context, addTx func(ctx context.Context, tx service.AddDishOptionGroupTx) error) error {
+	return m.db.RunInTransaction(ctx,
+		func(ctx context.Context, tx *db.Tx) error {
+			return addTx(ctx,
+				dishOptionTx{
+					DishOption: repository.NewDishOption(tx),
+				},
+			)
+		},
+	)
+}
+
+type ratingTx struct {
+	repository.Rating
+}
+
+func (m Manager) RateOrderTx(ctx context.Context, rateTx func(ctx context.Context, tx service.RateOrderTx) error) error {
+	return m.db.RunInTransaction(ctx,
+		func(ctx context.Context, tx *db.Tx) error {
+			return rateTx(ctx,
+				ratingTx{
+					Rating: repository.NewRating(tx),
+				},
+			)
+		},
+	)
+}
+
+type chargeCompanyTx struct {
+	orderStatusTx
+	repository.Company
+}
+
+func (m Manager) ChargeCompanyTx(ctx context.Context, chargeTx func(ctx context.Context, tx corporate.ChargeTx) error) error {
+	return m.db.RunInTransaction(ctx,
+		func(ctx context.Context, tx *db.Tx) error {
+			return chargeTx(ctx,
+				chargeCompanyTx{
+					orderStatusTx: newOrderStatusTx(tx),
+					Company:       repository.NewCompany(tx),
+				},
+			)
+		},
+	)
+}
+
+type walletTx struct {
+	repository.Wallet
+}
+
+func (m Manager) WalletTx(ctx context.Context, walletTxFunc func(ctx context.Context, tx service.WalletTx) error) error {
+	return m.db.RunInTransaction(ctx,
+		func(ctx context.Context, tx *db.Tx) error {
+			return walletTxFunc(ctx,
+				walletTx{
+					Wallet: repository.NewWallet(tx),
+				},
+			)
+		},
+	)
+}